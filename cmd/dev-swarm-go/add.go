@@ -6,10 +6,10 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/spf13/cobra"
 	"github.com/nathanbarrett/dev-swarm-go/internal/config"
 	"github.com/nathanbarrett/dev-swarm-go/internal/git"
 	"github.com/nathanbarrett/dev-swarm-go/internal/github"
+	"github.com/spf13/cobra"
 )
 
 var (
@@ -64,8 +64,18 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("path is not a git repository: %s", localPath)
 	}
 
+	// Load existing config
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		// If config doesn't exist, create default
+		cfg = config.DefaultConfig()
+	}
+
 	// Check GitHub access
-	ghClient := github.NewClient()
+	ghClient, err := github.New(cfg.Settings.GitHub)
+	if err != nil {
+		return err
+	}
 	if !ghClient.RepoExists(addRepo) {
 		return fmt.Errorf("cannot access repository: %s", addRepo)
 	}
@@ -73,7 +83,6 @@ func runAdd(cmd *cobra.Command, args []string) error {
 	// Auto-detect branch if not specified
 	branch := addBranch
 	if branch == "" {
-		var err error
 		branch, err = git.GetDefaultBranch(localPath)
 		if err != nil {
 			branch = "main" // fallback
@@ -87,13 +96,6 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		name = parts[len(parts)-1]
 	}
 
-	// Load existing config
-	cfg, err := config.Load(cfgFile)
-	if err != nil {
-		// If config doesn't exist, create default
-		cfg = config.DefaultConfig()
-	}
-
 	// Check if repo already exists
 	for _, cb := range cfg.Codebases {
 		if cb.Repo == addRepo {
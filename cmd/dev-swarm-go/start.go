@@ -7,16 +7,17 @@ import (
 	"os/signal"
 	"syscall"
 
-	"github.com/spf13/cobra"
 	"github.com/nathanbarrett/dev-swarm-go/internal/config"
 	"github.com/nathanbarrett/dev-swarm-go/internal/github"
 	"github.com/nathanbarrett/dev-swarm-go/internal/lock"
 	"github.com/nathanbarrett/dev-swarm-go/internal/orchestrator"
 	"github.com/nathanbarrett/dev-swarm-go/internal/tui"
+	"github.com/spf13/cobra"
 )
 
 var (
 	daemonMode bool
+	dryRun     bool
 )
 
 func newStartCmd() *cobra.Command {
@@ -25,11 +26,16 @@ func newStartCmd() *cobra.Command {
 		Short: "Start the orchestrator",
 		Long: `Start the dev-swarm orchestrator.
 
-By default, starts with a TUI to monitor progress. Use --daemon to run in the background.`,
+By default, starts with a TUI to monitor progress. Use --daemon to run in the background.
+
+Use --dry-run to run the full poll cycle without changing anything: no worktrees,
+no sessions, no label changes, comments or merges. What would have happened is
+logged and shown in the TUI.`,
 		RunE: runStart,
 	}
 
 	cmd.Flags().BoolVarP(&daemonMode, "daemon", "d", false, "run in daemon mode (no TUI)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "report what would happen without changing issues, worktrees or state")
 
 	return cmd
 }
@@ -42,16 +48,18 @@ func runStart(cmd *cobra.Command, args []string) error {
 	}
 
 	// Verify dependencies
-	if err := verifyDependencies(); err != nil {
+	if err := verifyDependencies(cfg); err != nil {
 		return err
 	}
 
-	// Acquire lock
-	lck := lock.New()
-	if err := lck.Acquire(); err != nil {
-		return fmt.Errorf("failed to acquire lock: %w (is dev-swarm already running?)", err)
+	// Acquire lock. Dry runs change nothing, so they may run next to a live instance.
+	if !dryRun {
+		lck := lock.New()
+		if err := lck.Acquire(); err != nil {
+			return fmt.Errorf("failed to acquire lock: %w (is dev-swarm already running?)", err)
+		}
+		defer lck.Release()
 	}
-	defer lck.Release()
 
 	// Set up signal handling
 	sigChan := make(chan os.Signal, 1)
@@ -77,6 +85,7 @@ func runStart(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create orchestrator: %w", err)
 	}
+	orch.SetDryRun(dryRun)
 
 	// Start orchestrator
 	if err := orch.Start(); err != nil {
@@ -107,17 +116,28 @@ func runStart(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func verifyDependencies() error {
-	ghClient := github.NewClient()
+func verifyDependencies(cfg *config.Config) error {
+	if cfg.Settings.GitHub.BackendName() == config.GitHubBackendAPI {
+		// Check the API token
+		ghClient, err := github.New(cfg.Settings.GitHub)
+		if err != nil {
+			return err
+		}
+		if !ghClient.IsAuthenticated() {
+			return fmt.Errorf("GitHub API token was rejected. Check settings.github.token")
+		}
+	} else {
+		ghClient := github.NewClient()
 
-	// Check gh CLI
-	if !ghClient.IsInstalled() {
-		return fmt.Errorf("gh CLI is not installed. Install: https://cli.github.com")
-	}
+		// Check gh CLI
+		if !ghClient.IsInstalled() {
+			return fmt.Errorf("gh CLI is not installed. Install: https://cli.github.com")
+		}
 
-	// Check gh authentication
-	if !ghClient.IsAuthenticated() {
-		return fmt.Errorf("gh CLI is not authenticated. Run: gh auth login")
+		// Check gh authentication
+		if !ghClient.IsAuthenticated() {
+			return fmt.Errorf("gh CLI is not authenticated. Run: gh auth login")
+		}
 	}
 
 	// Check claude CLI
@@ -3,9 +3,9 @@ package main
 import (
 	"fmt"
 
-	"github.com/spf13/cobra"
 	"github.com/nathanbarrett/dev-swarm-go/internal/config"
 	"github.com/nathanbarrett/dev-swarm-go/internal/github"
+	"github.com/spf13/cobra"
 )
 
 var (
@@ -18,7 +18,7 @@ func newSyncLabelsCmd() *cobra.Command {
 		Short: "Sync labels to repositories",
 		Long: `Create dev-swarm labels in GitHub repositories.
 
-This creates any missing labels with the correct colors and descriptions.
+This creates any missing workflow labels with the correct colors and descriptions.
 
 Example:
   dev-swarm sync-labels owner/repo
@@ -37,17 +37,9 @@ func runSyncLabels(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	ghClient := github.NewClient()
-
-	// Build label list
-	labels := cfg.Labels.GetAllLabels()
-	labelInfos := make([]github.LabelInfo, 0, len(labels))
-	for _, l := range labels {
-		labelInfos = append(labelInfos, github.LabelInfo{
-			Name:        l.Name,
-			Color:       l.Color,
-			Description: l.Description,
-		})
+	ghClient, err := github.New(cfg.Settings.GitHub)
+	if err != nil {
+		return err
 	}
 
 	var repos []string
@@ -65,6 +57,21 @@ func runSyncLabels(cmd *cobra.Command, args []string) error {
 	for _, repo := range repos {
 		fmt.Printf("Syncing labels for %s...\n", repo)
 
+		// Use the repo's own workflow when it is configured
+		workflow := cfg.GetWorkflow()
+		if cb := cfg.GetCodebaseByRepo(repo); cb != nil {
+			workflow = cb.GetWorkflow()
+		}
+
+		labelInfos := make([]github.LabelInfo, 0, len(workflow.States))
+		for _, l := range workflow.States {
+			labelInfos = append(labelInfos, github.LabelInfo{
+				Name:        l.Name,
+				Color:       l.Color,
+				Description: l.Description,
+			})
+		}
+
 		if err := ghClient.SyncLabels(repo, labelInfos); err != nil {
 			fmt.Printf("  Error: %v\n", err)
 			continue
@@ -1,6 +1,6 @@
 module github.com/nathanbarrett/dev-swarm-go
 
-go 1.21
+go 1.24.0
 
 require (
 	github.com/charmbracelet/bubbles v0.21.0
@@ -0,0 +1,202 @@
+// Package command parses the slash commands users put in issue comments.
+package command
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Command names, without the leading slash
+const (
+	Approve  = "approve"
+	Revise   = "revise"
+	Block    = "block"
+	Retry    = "retry"
+	Cancel   = "cancel"
+	SkipPlan = "skip-plan"
+	RerunCI  = "rerun-ci"
+)
+
+// Names lists the supported commands in the order they are documented
+var Names = []string{Approve, Revise, Block, Retry, Cancel, SkipPlan, RerunCI}
+
+// Command is a slash command parsed from a comment
+type Command struct {
+	Name string // Command name without the slash
+	Args string // Notes following the command; only /revise takes them
+}
+
+// String formats the command as it is written in a comment
+func (c *Command) String() string {
+	return "/" + c.Name
+}
+
+// ParseError describes a comment that holds a command that cannot be run
+type ParseError struct {
+	Command string // Command as written, e.g. "/aprove"
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	if e.Command == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Command, e.Message)
+}
+
+// commandLine matches a line that starts with a slash and a word, with
+// anything stuck to the word after it. Only words naming a supported command,
+// or close to one, make it a command line, so paths such as "/etc is
+// read-only" stay ordinary text.
+var commandLine = regexp.MustCompile(`^/([a-z][a-z-]*)(\S*)(?:\s+(.*))?$`)
+
+// IsCommand checks if a comment holds a supported slash command, whether or
+// not it is used or spelled correctly
+func IsCommand(body string) bool {
+	return len(commandLines(body)) > 0
+}
+
+// Parse returns the slash command in a comment, or nil if there is none.
+// Commands must start a line outside code blocks and quotes, and a comment
+// may hold only one. /revise takes the rest of the comment as its notes.
+// Lines starting with an unknown /word are not commands, unless the word is
+// a typo of one.
+func Parse(body string) (*Command, error) {
+	lines := commandLines(body)
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	first := lines[0]
+	if first.err != nil {
+		return nil, first.err
+	}
+	cmd := &Command{Name: first.name, Args: first.args}
+
+	if cmd.Name == Revise {
+		// Everything after /revise belongs to the notes
+		cmd.Args = strings.TrimSpace(strings.Join(append([]string{first.args}, first.rest...), "\n"))
+		if cmd.Args == "" {
+			return nil, &ParseError{Command: cmd.String(), Message: "notes are required, e.g. /revise use the existing cache"}
+		}
+		return cmd, nil
+	}
+
+	if len(lines) > 1 {
+		return nil, &ParseError{Message: "only one command per comment is supported"}
+	}
+	if cmd.Args != "" {
+		return nil, &ParseError{Command: cmd.String(), Message: "takes no arguments"}
+	}
+	return cmd, nil
+}
+
+// parsedLine is a comment line that starts with a slash command
+type parsedLine struct {
+	name string
+	args string
+	rest []string    // Lines after the command
+	err  *ParseError // Set for a misspelled command
+}
+
+// commandLines finds the lines of a comment that start with a supported
+// slash command or a misspelling of one
+func commandLines(body string) []parsedLine {
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+
+	var found []parsedLine
+	inFence := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		m := commandLine.FindStringSubmatch(trimmed)
+		if m == nil {
+			continue
+		}
+		word, junk := m[1], m[2]
+		line := parsedLine{name: word, args: strings.TrimSpace(m[3]), rest: lines[i+1:]}
+		switch closest := closestName(word); {
+		case isKnown(word) && junk != "":
+			line.err = &ParseError{Command: "/" + word, Message: fmt.Sprintf("unexpected %q right after the command", junk)}
+		case isKnown(word):
+		case closest != "":
+			line.err = &ParseError{Command: "/" + word + junk, Message: "not a command, the closest is /" + closest}
+		default:
+			continue
+		}
+		found = append(found, line)
+	}
+	return found
+}
+
+// closestName returns the supported command a word is likely a typo of, or ""
+// if it is not close to any. Short words must be within one edit, so paths
+// such as "/tmp" do not count as typos.
+func closestName(word string) string {
+	maxDistance := 1
+	if len(word) > 5 {
+		maxDistance = 2
+	}
+
+	closest, best := "", maxDistance+1
+	for _, n := range Names {
+		if d := editDistance(word, n); d < best {
+			closest, best = n, d
+		}
+	}
+	return closest
+}
+
+// editDistance counts the insertions, deletions, substitutions and swaps of
+// adjacent characters that turn a into b
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// isKnown checks if a name is a supported command
+func isKnown(name string) bool {
+	for _, n := range Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Usage lists the supported commands for help and error messages
+func Usage() string {
+	names := make([]string, len(Names))
+	for i, n := range Names {
+		names[i] = "`/" + n + "`"
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,187 @@
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantName string
+		wantArgs string
+		wantErr  string
+	}{
+		{
+			name: "no command",
+			body: "Looks good to me, approved",
+		},
+		{
+			name:     "approve",
+			body:     "/approve",
+			wantName: Approve,
+		},
+		{
+			name:     "command after prose",
+			body:     "Thanks for the plan.\n\n/skip-plan\n\nThis one is small.",
+			wantName: SkipPlan,
+		},
+		{
+			name:     "indented command with trailing space",
+			body:     "  /rerun-ci  ",
+			wantName: RerunCI,
+		},
+		{
+			name:     "revise with inline notes",
+			body:     "/revise use the existing cache",
+			wantName: Revise,
+			wantArgs: "use the existing cache",
+		},
+		{
+			name:     "revise takes the rest of the comment",
+			body:     "/revise\n- drop the migration\n- keep the API stable",
+			wantName: Revise,
+			wantArgs: "- drop the migration\n- keep the API stable",
+		},
+		{
+			name:    "revise without notes",
+			body:    "/revise",
+			wantErr: "notes are required",
+		},
+		{
+			name:    "misspelled command",
+			body:    "/aprove",
+			wantErr: "/aprove: not a command, the closest is /approve",
+		},
+		{
+			name:    "swapped letters",
+			body:    "/cancle",
+			wantErr: "the closest is /cancel",
+		},
+		{
+			name:    "misspelled command with notes",
+			body:    "/revice use the existing cache",
+			wantErr: "the closest is /revise",
+		},
+		{
+			name:    "trailing punctuation",
+			body:    "/approve.",
+			wantErr: `/approve: unexpected "." right after the command`,
+		},
+		{
+			name:    "trailing punctuation before notes",
+			body:    "/revise: use the existing cache",
+			wantErr: `unexpected ":"`,
+		},
+		{
+			name: "unknown command is ordinary text",
+			body: "/deploy now",
+		},
+		{
+			name: "short word close to no command",
+			body: "/lib is missing",
+		},
+		{
+			name:    "arguments on a command without them",
+			body:    "/block waiting on design",
+			wantErr: "takes no arguments",
+		},
+		{
+			name:    "two commands",
+			body:    "/approve\n/rerun-ci",
+			wantErr: "only one command",
+		},
+		{
+			name: "command in a code block",
+			body: "Run this:\n```\n/approve\n```",
+		},
+		{
+			name: "command in a quote",
+			body: "> /approve\n\nWhy was this approved?",
+		},
+		{
+			name: "slash in the middle of a line",
+			body: "Use and/or here, see /usr/local/bin",
+		},
+		{
+			name: "path at the start of a line",
+			body: "/usr/local/bin is missing",
+		},
+		{
+			name: "paths at the start of several lines",
+			body: "/etc is mounted read-only\n/tmp fills up during the build",
+		},
+		{
+			name:     "command followed by a path",
+			body:     "/approve\n/tmp cleanup can wait",
+			wantName: Approve,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := Parse(tt.body)
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("Parse() error = nil, want error containing %q", tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Parse() error = %q, want it to contain %q", err.Error(), tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if tt.wantName == "" {
+				if cmd != nil {
+					t.Errorf("Parse() = %+v, want nil", cmd)
+				}
+				return
+			}
+			if cmd == nil {
+				t.Fatalf("Parse() = nil, want %q", tt.wantName)
+			}
+			if cmd.Name != tt.wantName {
+				t.Errorf("Name = %q, want %q", cmd.Name, tt.wantName)
+			}
+			if cmd.Args != tt.wantArgs {
+				t.Errorf("Args = %q, want %q", cmd.Args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestIsCommand(t *testing.T) {
+	tests := []struct {
+		body string
+		want bool
+	}{
+		{"/approve", true},
+		{"/block waiting on design", true},
+		{"/aprove", true},
+		{"/approve!", true},
+		{"/deploy", false},
+		{"/etc is mounted read-only", false},
+		{"/tmp fills up", false},
+		{"approved", false},
+		{"```\n/approve\n```", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsCommand(tt.body); got != tt.want {
+			t.Errorf("IsCommand(%q) = %v, want %v", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestUsage(t *testing.T) {
+	usage := Usage()
+	for _, name := range Names {
+		if !strings.Contains(usage, "`/"+name+"`") {
+			t.Errorf("Usage() = %q, missing /%s", usage, name)
+		}
+	}
+}
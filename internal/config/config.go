@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
@@ -43,6 +45,21 @@ func LogFilePath() string {
 	return filepath.Join(ConfigDir(), "dev-swarm.log")
 }
 
+// HookLogFilePath returns the path of the log hook output is written to
+func HookLogFilePath() string {
+	return filepath.Join(ConfigDir(), "hooks.log")
+}
+
+// StateFilePath returns the persisted orchestrator state file path
+func StateFilePath() string {
+	return filepath.Join(ConfigDir(), "state.json")
+}
+
+// IdentityKeyPath returns the path of the key that signs AI comment markers
+func IdentityKeyPath() string {
+	return filepath.Join(ConfigDir(), "identity.json")
+}
+
 // expandPath expands ~ to home directory
 func expandPath(path string) string {
 	if strings.HasPrefix(path, "~/") {
@@ -89,6 +106,9 @@ func Load(path string) (*Config, error) {
 	// Merge per-codebase labels with global labels
 	mergeLabelOverrides(cfg)
 
+	// Build the workflow graph for every codebase
+	resolveWorkflows(cfg)
+
 	// Validate
 	if err := Validate(cfg); err != nil {
 		return nil, err
@@ -110,12 +130,43 @@ func applyDefaults(cfg *Config) {
 	if cfg.Settings.MaxConcurrentSessions == 0 {
 		cfg.Settings.MaxConcurrentSessions = defaults.MaxConcurrentSessions
 	}
-	if len(cfg.Settings.ApprovalKeywords) == 0 {
-		cfg.Settings.ApprovalKeywords = defaults.ApprovalKeywords
-	}
 	if cfg.Settings.OutputBufferLines == 0 {
 		cfg.Settings.OutputBufferLines = defaults.OutputBufferLines
 	}
+	if cfg.Settings.MaxSessionAttempts == 0 {
+		cfg.Settings.MaxSessionAttempts = defaults.MaxSessionAttempts
+	}
+	if cfg.Settings.RetryBackoff == 0 {
+		cfg.Settings.RetryBackoff = defaults.RetryBackoff
+	}
+	if cfg.Settings.MaxRetryBackoff == 0 {
+		cfg.Settings.MaxRetryBackoff = defaults.MaxRetryBackoff
+	}
+	// Session limits are only defaulted when unset, since 0 disables them
+	if cfg.Settings.SessionMaxRuntime == nil {
+		cfg.Settings.SessionMaxRuntime = defaults.SessionMaxRuntime
+	}
+	if cfg.Settings.SessionIdleTimeout == nil {
+		cfg.Settings.SessionIdleTimeout = defaults.SessionIdleTimeout
+	}
+	if cfg.Settings.PriorityLabels == nil {
+		cfg.Settings.PriorityLabels = defaults.PriorityLabels
+	}
+	if cfg.Settings.Webhook.Listen == "" {
+		cfg.Settings.Webhook.Listen = defaults.Webhook.Listen
+	}
+	if cfg.Settings.Webhook.Path == "" {
+		cfg.Settings.Webhook.Path = defaults.Webhook.Path
+	}
+	if cfg.Settings.Webhook.ReconcileInterval == 0 {
+		cfg.Settings.Webhook.ReconcileInterval = defaults.Webhook.ReconcileInterval
+	}
+	if cfg.Settings.Watchdog.GracePeriod == 0 {
+		cfg.Settings.Watchdog.GracePeriod = defaults.Watchdog.GracePeriod
+	}
+	if cfg.Settings.Watchdog.Action == "" {
+		cfg.Settings.Watchdog.Action = defaults.Watchdog.Action
+	}
 }
 
 // expandPaths expands ~ in all path configurations
@@ -170,6 +221,27 @@ func mergeLabelOverrides(cfg *Config) {
 	}
 }
 
+// resolveWorkflows sets the workflow in effect for the config and every codebase.
+// Without an explicit workflow the built-in graph is derived from the labels.
+func resolveWorkflows(cfg *Config) {
+	cfg.resolvedWorkflow = cfg.Workflow
+	if cfg.resolvedWorkflow == nil {
+		cfg.resolvedWorkflow = cfg.Labels.Workflow()
+	}
+
+	for i := range cfg.Codebases {
+		cb := &cfg.Codebases[i]
+		switch {
+		case cb.Workflow != nil:
+			cb.resolvedWorkflow = cb.Workflow
+		case cfg.Workflow == nil && cb.Labels != nil:
+			cb.resolvedWorkflow = cb.Labels.Workflow()
+		default:
+			cb.resolvedWorkflow = cfg.resolvedWorkflow
+		}
+	}
+}
+
 // mergeLabelConfig merges an override into a base label config
 func mergeLabelConfig(base, override *LabelConfig) {
 	if override.Name != "" {
@@ -190,6 +262,30 @@ func mergeLabelConfig(base, override *LabelConfig) {
 	if override.AIAction != "" {
 		base.AIAction = override.AIAction
 	}
+	if override.MaxRuntime != nil {
+		base.MaxRuntime = override.MaxRuntime
+	}
+	if override.IdleTimeout != nil {
+		base.IdleTimeout = override.IdleTimeout
+	}
+	if override.Priority != 0 {
+		base.Priority = override.Priority
+	}
+	if override.Phase != "" {
+		base.Phase = override.Phase
+	}
+	if override.Agent != "" {
+		base.Agent = override.Agent
+	}
+	if override.WorkingLabel != "" {
+		base.WorkingLabel = override.WorkingLabel
+	}
+	if override.ExpectCommits {
+		base.ExpectCommits = true
+	}
+	if override.RequiresPR {
+		base.RequiresPR = true
+	}
 }
 
 // Validate checks the configuration for errors
@@ -204,6 +300,82 @@ func Validate(cfg *Config) error {
 	if cfg.Settings.MaxConcurrentSessions < 1 {
 		return &apperrors.ConfigError{Field: "settings.max_concurrent_sessions", Message: "must be at least 1"}
 	}
+	if cfg.Settings.MaxSessionAttempts < 0 {
+		return &apperrors.ConfigError{Field: "settings.max_session_attempts", Message: "must not be negative"}
+	}
+	if cfg.Settings.RetryBackoff < 0 {
+		return &apperrors.ConfigError{Field: "settings.retry_backoff", Message: "must not be negative"}
+	}
+	if cfg.Settings.MaxRetryBackoff < cfg.Settings.RetryBackoff {
+		return &apperrors.ConfigError{Field: "settings.max_retry_backoff", Message: "must be at least retry_backoff"}
+	}
+	if cfg.Settings.SessionMaxRuntime != nil && *cfg.Settings.SessionMaxRuntime < 0 {
+		return &apperrors.ConfigError{Field: "settings.session_max_runtime", Message: "must not be negative"}
+	}
+	if cfg.Settings.SessionIdleTimeout != nil && *cfg.Settings.SessionIdleTimeout < 0 {
+		return &apperrors.ConfigError{Field: "settings.session_idle_timeout", Message: "must not be negative"}
+	}
+
+	for phase, limit := range cfg.Settings.MaxSessionsPerPhase {
+		if !IsValidPhase(phase) {
+			return &apperrors.ConfigError{
+				Field:   "settings.max_sessions_per_phase",
+				Message: fmt.Sprintf("unknown phase %q", phase),
+			}
+		}
+		if limit < 1 {
+			return &apperrors.ConfigError{
+				Field:   fmt.Sprintf("settings.max_sessions_per_phase.%s", phase),
+				Message: "must be at least 1",
+			}
+		}
+	}
+	if webhook := cfg.Settings.Webhook; webhook.Enabled {
+		if webhook.Secret == "" {
+			return &apperrors.ConfigError{Field: "settings.webhook.secret", Message: "is required when webhooks are enabled"}
+		}
+		if !strings.HasPrefix(webhook.Path, "/") {
+			return &apperrors.ConfigError{Field: "settings.webhook.path", Message: "must start with /"}
+		}
+		if webhook.ReconcileInterval < 1 {
+			return &apperrors.ConfigError{Field: "settings.webhook.reconcile_interval", Message: "must be at least 1"}
+		}
+	}
+	if cfg.Settings.Watchdog.GracePeriod < 0 {
+		return &apperrors.ConfigError{Field: "settings.watchdog.grace_period", Message: "must not be negative"}
+	}
+	if action := cfg.Settings.Watchdog.Action; action != "" && !IsValidWatchdogAction(action) {
+		return &apperrors.ConfigError{
+			Field:   "settings.watchdog.action",
+			Message: fmt.Sprintf("unknown action %q, must be requeue or block", action),
+		}
+	}
+	if _, err := cfg.Settings.Schedule.Build(); err != nil {
+		return &apperrors.ConfigError{Field: "settings.schedule", Message: err.Error()}
+	}
+	if cfg.Settings.Budget.Daily < 0 {
+		return &apperrors.ConfigError{Field: "settings.budget.daily", Message: "must not be negative"}
+	}
+	if cfg.Settings.Budget.Monthly < 0 {
+		return &apperrors.ConfigError{Field: "settings.budget.monthly", Message: "must not be negative"}
+	}
+	if err := validateAgents(cfg); err != nil {
+		return err
+	}
+	if err := validateHooks("settings.hooks", cfg.Settings.Hooks); err != nil {
+		return err
+	}
+	if err := validateNotify(&cfg.Settings.Notify); err != nil {
+		return err
+	}
+	if err := validateGitHub(&cfg.Settings.GitHub); err != nil {
+		return err
+	}
+	if cfg.Workflow != nil {
+		if err := validateWorkflow("workflow", cfg.Workflow); err != nil {
+			return err
+		}
+	}
 
 	// Validate codebases
 	for i, cb := range cfg.Codebases {
@@ -231,8 +403,175 @@ func Validate(cfg *Config) error {
 				Message: "is required",
 			}
 		}
+		if cb.MaxConcurrentSessions < 0 {
+			return &apperrors.ConfigError{
+				Field:   fmt.Sprintf("codebases[%d].max_concurrent_sessions", i),
+				Message: "must not be negative",
+			}
+		}
+		if cb.Merge.Strategy != "" && !IsValidMergeStrategy(cb.Merge.Strategy) {
+			return &apperrors.ConfigError{
+				Field:   fmt.Sprintf("codebases[%d].merge.strategy", i),
+				Message: fmt.Sprintf("unknown strategy %q, must be merge, squash or rebase", cb.Merge.Strategy),
+			}
+		}
+		if cb.Access.Permission != "" && !IsValidPermission(cb.Access.Permission) {
+			return &apperrors.ConfigError{
+				Field:   fmt.Sprintf("codebases[%d].access.permission", i),
+				Message: fmt.Sprintf("unknown permission %q, must be read, triage, write, maintain, admin or none", cb.Access.Permission),
+			}
+		}
+		for _, team := range cb.Access.Teams {
+			if org, slug, ok := strings.Cut(team, "/"); !ok || org == "" || slug == "" || strings.Contains(slug, "/") {
+				return &apperrors.ConfigError{
+					Field:   fmt.Sprintf("codebases[%d].access.teams", i),
+					Message: fmt.Sprintf("team %q must be in org/team-slug format", team),
+				}
+			}
+		}
+		if _, err := cb.Schedule.Build(); err != nil {
+			return &apperrors.ConfigError{
+				Field:   fmt.Sprintf("codebases[%d].schedule", i),
+				Message: err.Error(),
+			}
+		}
+		if cb.Workflow != nil {
+			if err := validateWorkflow(fmt.Sprintf("codebases[%d].workflow", i), cb.Workflow); err != nil {
+				return err
+			}
+			if err := cfg.checkStateAgents(fmt.Sprintf("codebases[%d].workflow", i), cb.Workflow); err != nil {
+				return err
+			}
+		}
+		if cb.Labels != nil {
+			if err := cfg.checkStateAgents(fmt.Sprintf("codebases[%d].labels", i), cb.Labels.Workflow()); err != nil {
+				return err
+			}
+		}
+		if err := cfg.checkAgentName(fmt.Sprintf("codebases[%d].agent", i), cb.Agent); err != nil {
+			return err
+		}
+		if err := validateHooks(fmt.Sprintf("codebases[%d].hooks", i), cb.Hooks); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateNotify checks the notification settings
+func validateNotify(notify *NotifyConfig) error {
+	if notify.Digest < 0 {
+		return &apperrors.ConfigError{Field: "settings.notify.digest", Message: "must not be negative"}
+	}
+	for i, sink := range notify.Sinks {
+		field := fmt.Sprintf("settings.notify.sinks[%d]", i)
+		if !IsValidSinkType(sink.Type) {
+			return &apperrors.ConfigError{
+				Field:   field + ".type",
+				Message: fmt.Sprintf("unknown sink %q, must be desktop, webhook or tui", sink.Type),
+			}
+		}
+		if sink.Type == SinkWebhook && !strings.HasPrefix(sink.URL, "http://") && !strings.HasPrefix(sink.URL, "https://") {
+			return &apperrors.ConfigError{Field: field + ".url", Message: "must be an http or https URL"}
+		}
+	}
+	return nil
+}
+
+// validateGitHub checks the GitHub backend settings
+func validateGitHub(gh *GitHubConfig) error {
+	backend := gh.BackendName()
+	if backend != GitHubBackendGH && backend != GitHubBackendAPI {
+		return &apperrors.ConfigError{
+			Field:   "settings.github.backend",
+			Message: fmt.Sprintf("unknown backend %q, must be gh or api", gh.Backend),
+		}
+	}
+	if gh.APIURL != "" && !strings.HasPrefix(gh.APIURL, "http://") && !strings.HasPrefix(gh.APIURL, "https://") {
+		return &apperrors.ConfigError{Field: "settings.github.api_url", Message: "must be an http or https URL"}
 	}
+	if gh.PageSize < 0 || gh.PageSize > MaxGitHubPageSize {
+		return &apperrors.ConfigError{
+			Field:   "settings.github.page_size",
+			Message: fmt.Sprintf("must be between 1 and %d", MaxGitHubPageSize),
+		}
+	}
+	return nil
+}
 
+// validateHooks checks a list of hooks
+func validateHooks(field string, hooks []HookConfig) error {
+	for i, hook := range hooks {
+		hookField := fmt.Sprintf("%s[%d]", field, i)
+		if !IsValidHookEvent(hook.Event) {
+			return &apperrors.ConfigError{
+				Field: hookField + ".event",
+				Message: fmt.Sprintf("unknown event %q, must be %s, %s, %s, %s, %s, %s or *", hook.Event,
+					HookIssuePickup, HookSessionStarted, HookSessionEnded, HookLabelChanged, HookPRMerged, HookCIFailed),
+			}
+		}
+		if hook.Command == "" {
+			return &apperrors.ConfigError{Field: hookField + ".command", Message: "is required"}
+		}
+		if hook.Timeout < 0 {
+			return &apperrors.ConfigError{Field: hookField + ".timeout", Message: "must not be negative"}
+		}
+		if hook.Veto && hook.Event != HookIssuePickup {
+			return &apperrors.ConfigError{Field: hookField + ".veto", Message: "only issue.pickup hooks can veto"}
+		}
+	}
+	return nil
+}
+
+// validateAgents checks the command agents and the default agent
+func validateAgents(cfg *Config) error {
+	for name, agent := range cfg.Settings.Agents {
+		field := fmt.Sprintf("settings.agents.%s", name)
+		if name == AgentClaude {
+			return &apperrors.ConfigError{Field: field, Message: "claude is a built-in agent and cannot be redefined"}
+		}
+		if agent.Command == "" {
+			return &apperrors.ConfigError{Field: field + ".command", Message: "is required"}
+		}
+		if agent.Prompt != "" && !IsValidPromptMode(agent.Prompt) {
+			return &apperrors.ConfigError{
+				Field:   field + ".prompt",
+				Message: fmt.Sprintf("unknown prompt mode %q, must be file, arg or stdin", agent.Prompt),
+			}
+		}
+		if _, err := regexp.Compile(agent.SuccessPattern); err != nil {
+			return &apperrors.ConfigError{Field: field + ".success_pattern", Message: err.Error()}
+		}
+	}
+
+	if err := cfg.checkAgentName("settings.agent", cfg.Settings.Agent); err != nil {
+		return err
+	}
+	if cfg.Workflow != nil {
+		return cfg.checkStateAgents("workflow", cfg.Workflow)
+	}
+	return cfg.checkStateAgents("labels", cfg.Labels.Workflow())
+}
+
+// checkStateAgents checks that the agents of workflow states are defined
+func (cfg *Config) checkStateAgents(field string, workflow *Workflow) error {
+	for _, state := range workflow.States {
+		if err := cfg.checkAgentName(fmt.Sprintf("%s.%s.agent", field, state.Name), state.Agent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAgentName checks that an agent name is empty, built in or defined
+func (cfg *Config) checkAgentName(field, name string) error {
+	if name == "" || name == AgentClaude {
+		return nil
+	}
+	if _, ok := cfg.Settings.Agents[name]; !ok {
+		return &apperrors.ConfigError{Field: field, Message: fmt.Sprintf("unknown agent %q", name)}
+	}
 	return nil
 }
 
@@ -307,6 +646,32 @@ func (cfg *Config) GetEnabledCodebases() []Codebase {
 	return enabled
 }
 
+// GetWorkflow returns the global workflow graph
+func (cfg *Config) GetWorkflow() *Workflow {
+	if cfg.resolvedWorkflow != nil {
+		return cfg.resolvedWorkflow
+	}
+	if cfg.Workflow != nil {
+		return cfg.Workflow
+	}
+	return cfg.Labels.Workflow()
+}
+
+// GetWorkflow returns the workflow graph in effect for the codebase
+func (cb *Codebase) GetWorkflow() *Workflow {
+	if cb.resolvedWorkflow != nil {
+		return cb.resolvedWorkflow
+	}
+	if cb.Workflow != nil {
+		return cb.Workflow
+	}
+	if cb.Labels != nil {
+		return cb.Labels.Workflow()
+	}
+	labels := DefaultLabels()
+	return labels.Workflow()
+}
+
 // GetCodebaseByName returns a codebase by name
 func (cfg *Config) GetCodebaseByName(name string) *Codebase {
 	for i := range cfg.Codebases {
@@ -327,13 +692,43 @@ func (cfg *Config) GetCodebaseByRepo(repo string) *Codebase {
 	return nil
 }
 
-// IsApprovalKeyword checks if a string contains an approval keyword
-func (cfg *Config) IsApprovalKeyword(text string) bool {
-	lower := strings.ToLower(text)
-	for _, keyword := range cfg.Settings.ApprovalKeywords {
-		if strings.Contains(lower, strings.ToLower(keyword)) {
-			return true
+// AgentFor returns the agent for sessions of a codebase picked up from a
+// label: the label's agent, else the codebase's, else the default agent
+func (cfg *Config) AgentFor(codebase *Codebase, label *LabelConfig) string {
+	if label != nil && label.Agent != "" {
+		return label.Agent
+	}
+	if codebase != nil && codebase.Agent != "" {
+		return codebase.Agent
+	}
+	if cfg.Settings.Agent != "" {
+		return cfg.Settings.Agent
+	}
+	return AgentClaude
+}
+
+// SessionLimits returns the max runtime and inactivity timeout for sessions
+// picked up from a label, falling back to the global settings. A zero
+// duration means the limit is disabled.
+func (cfg *Config) SessionLimits(label *LabelConfig) (maxRuntime, idleTimeout time.Duration) {
+	runtimeMinutes := cfg.Settings.SessionMaxRuntime
+	idleMinutes := cfg.Settings.SessionIdleTimeout
+	if label != nil {
+		if label.MaxRuntime != nil {
+			runtimeMinutes = label.MaxRuntime
 		}
+		if label.IdleTimeout != nil {
+			idleMinutes = label.IdleTimeout
+		}
+	}
+	return minutes(runtimeMinutes), minutes(idleMinutes)
+}
+
+// minutes converts an optional number of minutes to a duration, treating
+// unset as zero
+func minutes(m *int) time.Duration {
+	if m == nil {
+		return 0
 	}
-	return false
+	return time.Duration(*m) * time.Minute
 }
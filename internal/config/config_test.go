@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	apperrors "github.com/nathanbarrett/dev-swarm-go/internal/errors"
 )
@@ -182,6 +183,334 @@ func TestValidate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "default_branch",
 		},
+		{
+			name: "max retry backoff below retry backoff",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+					RetryBackoff:          120,
+					MaxRetryBackoff:       60,
+				},
+			},
+			wantErr: true,
+			errMsg:  "max_retry_backoff",
+		},
+		{
+			name: "unknown session phase",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+					MaxSessionsPerPhase:   map[string]int{"reviewing": 1},
+				},
+			},
+			wantErr: true,
+			errMsg:  "max_sessions_per_phase",
+		},
+		{
+			name: "zero phase limit",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+					MaxSessionsPerPhase:   map[string]int{"planning": 0},
+				},
+			},
+			wantErr: true,
+			errMsg:  "max_sessions_per_phase",
+		},
+		{
+			name: "negative codebase session limit",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+				},
+				Codebases: []Codebase{
+					{
+						Repo:                  "owner/repo",
+						LocalPath:             "/path",
+						DefaultBranch:         "main",
+						MaxConcurrentSessions: -1,
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "max_concurrent_sessions",
+		},
+		{
+			name: "unknown merge strategy",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+				},
+				Codebases: []Codebase{
+					{
+						Repo:          "owner/repo",
+						LocalPath:     "/path",
+						DefaultBranch: "main",
+						Merge:         MergeConfig{Strategy: "fast-forward"},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "merge.strategy",
+		},
+		{
+			name: "unknown access permission",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+				},
+				Codebases: []Codebase{
+					{
+						Repo:          "owner/repo",
+						LocalPath:     "/path",
+						DefaultBranch: "main",
+						Access:        AccessConfig{Permission: "owner"},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "access.permission",
+		},
+		{
+			name: "team without org",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+				},
+				Codebases: []Codebase{
+					{
+						Repo:          "owner/repo",
+						LocalPath:     "/path",
+						DefaultBranch: "main",
+						Access:        AccessConfig{Teams: []string{"maintainers"}},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "access.teams",
+		},
+		{
+			name: "webhook without secret",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+					Webhook: WebhookConfig{
+						Enabled:           true,
+						Listen:            "127.0.0.1:8787",
+						Path:              "/webhook",
+						ReconcileInterval: 900,
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "settings.webhook.secret",
+		},
+		{
+			name: "invalid watchdog action",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+					Watchdog:              WatchdogConfig{GracePeriod: 10, Action: "ignore"},
+				},
+			},
+			wantErr: true,
+			errMsg:  "settings.watchdog.action",
+		},
+		{
+			name: "negative monthly budget",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+					Budget:                BudgetConfig{Daily: 20, Monthly: -1},
+				},
+			},
+			wantErr: true,
+			errMsg:  "settings.budget.monthly",
+		},
+		{
+			name: "invalid codebase schedule",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+				},
+				Codebases: []Codebase{
+					{
+						Repo:          "owner/repo",
+						LocalPath:     "/path",
+						DefaultBranch: "main",
+						Schedule:      &ScheduleConfig{Windows: []string{"weekdays 09:00-17:00"}},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "codebases[0].schedule",
+		},
+		{
+			name: "unknown codebase agent",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+				},
+				Codebases: []Codebase{
+					{
+						Repo:          "owner/repo",
+						LocalPath:     "/path",
+						DefaultBranch: "main",
+						Agent:         "codex",
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "codebases[0].agent",
+		},
+		{
+			name: "agent without command",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+					Agents:                map[string]AgentConfig{"codex": {Args: []string{"exec"}}},
+				},
+			},
+			wantErr: true,
+			errMsg:  "settings.agents.codex.command",
+		},
+		{
+			name: "invalid agent prompt mode",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+					Agents:                map[string]AgentConfig{"codex": {Command: "codex", Prompt: "pipe"}},
+				},
+			},
+			wantErr: true,
+			errMsg:  "settings.agents.codex.prompt",
+		},
+		{
+			name: "redefined claude agent",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+					Agents:                map[string]AgentConfig{"claude": {Command: "claude"}},
+				},
+			},
+			wantErr: true,
+			errMsg:  "settings.agents.claude",
+		},
+		{
+			name: "veto on a non-pickup hook",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+					Hooks:                 []HookConfig{{Event: HookSessionEnded, Command: "true", Veto: true}},
+				},
+			},
+			wantErr: true,
+			errMsg:  "settings.hooks[0].veto",
+		},
+		{
+			name: "unknown codebase hook event",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+				},
+				Codebases: []Codebase{
+					{
+						Repo:          "owner/repo",
+						LocalPath:     "/path",
+						DefaultBranch: "main",
+						Hooks:         []HookConfig{{Event: "issue.closed", Command: "true"}},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "codebases[0].hooks[0].event",
+		},
+		{
+			name: "webhook sink without url",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+					Notify:                NotifyConfig{Sinks: []SinkConfig{{Type: SinkWebhook}}},
+				},
+			},
+			wantErr: true,
+			errMsg:  "settings.notify.sinks[0].url",
+		},
+		{
+			name: "unknown github backend",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+					GitHub:                GitHubConfig{Backend: "graphql"},
+				},
+			},
+			wantErr: true,
+			errMsg:  "settings.github.backend",
+		},
+		{
+			name: "github page size too large",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+					GitHub:                GitHubConfig{PageSize: 500},
+				},
+			},
+			wantErr: true,
+			errMsg:  "settings.github.page_size",
+		},
+		{
+			name: "valid command agent",
+			config: &Config{
+				Settings: Settings{
+					PollInterval:          60,
+					ActivePollInterval:    10,
+					MaxConcurrentSessions: 5,
+					Agent:                 "codex",
+					Agents:                map[string]AgentConfig{"codex": {Command: "codex", Prompt: "stdin"}},
+				},
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -220,6 +549,55 @@ func TestApplyDefaults(t *testing.T) {
 	if cfg.Settings.OutputBufferLines != defaults.OutputBufferLines {
 		t.Errorf("OutputBufferLines = %d, want %d", cfg.Settings.OutputBufferLines, defaults.OutputBufferLines)
 	}
+	if cfg.Settings.Webhook.ReconcileInterval != defaults.Webhook.ReconcileInterval {
+		t.Errorf("Webhook.ReconcileInterval = %d, want %d", cfg.Settings.Webhook.ReconcileInterval, defaults.Webhook.ReconcileInterval)
+	}
+	if cfg.Settings.Watchdog != defaults.Watchdog {
+		t.Errorf("Watchdog = %+v, want %+v", cfg.Settings.Watchdog, defaults.Watchdog)
+	}
+}
+
+func TestSessionLimits(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	data := `
+settings:
+  session_idle_timeout: 0
+labels:
+  ready_to_plan:
+    max_runtime: 0
+  ready_to_implement:
+    max_runtime: 240
+`
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		label       *LabelConfig
+		wantRuntime time.Duration
+	}{
+		{"no label", nil, 120 * time.Minute},
+		{"disabled by label", &cfg.Labels.ReadyToPlan, 0},
+		{"overridden by label", &cfg.Labels.ReadyToImplement, 240 * time.Minute},
+		{"inherited", &cfg.Labels.CodeReview, 120 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxRuntime, idleTimeout := cfg.SessionLimits(tt.label)
+			if maxRuntime != tt.wantRuntime {
+				t.Errorf("maxRuntime = %s, want %s", maxRuntime, tt.wantRuntime)
+			}
+			if idleTimeout != 0 {
+				t.Errorf("idleTimeout = %s, want 0 since the limit is disabled", idleTimeout)
+			}
+		})
+	}
 }
 
 func TestExpandPaths(t *testing.T) {
@@ -275,6 +653,32 @@ func TestMergeLabelConfig(t *testing.T) {
 	}
 }
 
+func TestAgentFor(t *testing.T) {
+	cfg := &Config{Settings: Settings{Agent: "wrapper"}}
+	codebase := &Codebase{Agent: "codex"}
+
+	tests := []struct {
+		name     string
+		cfg      *Config
+		codebase *Codebase
+		label    *LabelConfig
+		want     string
+	}{
+		{"label agent", cfg, codebase, &LabelConfig{Agent: "aider"}, "aider"},
+		{"codebase agent", cfg, codebase, &LabelConfig{}, "codex"},
+		{"default agent", cfg, &Codebase{}, nil, "wrapper"},
+		{"built-in agent", &Config{}, &Codebase{}, nil, AgentClaude},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.AgentFor(tt.codebase, tt.label); got != tt.want {
+				t.Errorf("AgentFor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGetEnabledCodebases(t *testing.T) {
 	cfg := &Config{
 		Codebases: []Codebase{
@@ -341,39 +745,6 @@ func TestGetCodebaseByRepo(t *testing.T) {
 	}
 }
 
-func TestIsApprovalKeyword(t *testing.T) {
-	cfg := &Config{
-		Settings: Settings{
-			ApprovalKeywords: []string{"approved", "lgtm", "ship it"},
-		},
-	}
-
-	tests := []struct {
-		text string
-		want bool
-	}{
-		{"approved", true},
-		{"APPROVED", true},
-		{"Looks approved to me", true},
-		{"lgtm", true},
-		{"LGTM!", true},
-		{"ship it", true},
-		{"Ship It Now!", true},
-		{"rejected", false},
-		{"needs changes", false},
-		{"", false},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.text, func(t *testing.T) {
-			got := cfg.IsApprovalKeyword(tt.text)
-			if got != tt.want {
-				t.Errorf("IsApprovalKeyword(%q) = %v, want %v", tt.text, got, tt.want)
-			}
-		})
-	}
-}
-
 func TestLoadNonExistent(t *testing.T) {
 	_, err := Load("/nonexistent/path/config.yaml")
 	if err != apperrors.ErrConfigNotFound {
@@ -7,17 +7,34 @@ func DefaultSettings() Settings {
 		ActivePollInterval:    10,
 		MaxConcurrentSessions: 5,
 		AutoMergeOnApproval:   true,
-		ApprovalKeywords: []string{
-			"approved",
-			"lgtm",
-			"ship it",
-			"merge it",
-			"looks good",
+		OutputBufferLines:     1000,
+		MaxSessionAttempts:    3,
+		RetryBackoff:          60,
+		MaxRetryBackoff:       3600,
+		SessionMaxRuntime:     intPtr(120),
+		SessionIdleTimeout:    intPtr(30),
+		PriorityLabels: map[string]int{
+			"priority:critical": 100,
+			"priority:high":     50,
+			"priority:low":      -50,
+		},
+		Webhook: WebhookConfig{
+			Listen:            "127.0.0.1:8787",
+			Path:              "/webhook",
+			ReconcileInterval: 900,
+		},
+		Watchdog: WatchdogConfig{
+			GracePeriod: 10,
+			Action:      WatchdogRequeue,
 		},
-		OutputBufferLines: 1000,
 	}
 }
 
+// intPtr returns a pointer to v, for settings where 0 differs from unset
+func intPtr(v int) *int {
+	return &v
+}
+
 // DefaultLabels returns the default label configurations
 func DefaultLabels() Labels {
 	return Labels{
@@ -27,19 +44,20 @@ func DefaultLabels() Labels {
 			Description: "Ready for AI to create implementation plan",
 			Owner:       "user",
 			AIPickup:    "always",
+			Phase:       "planning",
 			AIAction: `You are creating an implementation plan for this issue.
+dev-swarm has already moved the issue to ai:planning.
 
 Steps:
-1. Change the label from user:ready-to-plan to ai:planning
-2. Analyze the issue requirements thoroughly
-3. Write a detailed implementation plan as a comment including:
+1. Analyze the issue requirements thoroughly
+2. Write a detailed implementation plan as a comment including:
    - **Summary**: Brief overview of the approach
    - **Files to Modify/Create**: List each file and what changes are needed
    - **Implementation Steps**: Detailed step-by-step instructions
    - **Edge Cases**: Potential issues to handle
    - **Testing Strategy**: How to verify the implementation
-4. Wrap your comment with AI markers (see below)
-5. Change the label from ai:planning to user:plan-review`,
+3. Wrap your comment with AI markers (see below)
+4. Change the label from ai:planning to user:plan-review`,
 		},
 		PlanReview: LabelConfig{
 			Name:        "user:plan-review",
@@ -47,38 +65,45 @@ Steps:
 			Description: "Implementation plan ready for user review",
 			Owner:       "user",
 			AIPickup:    "on_user_comment",
+			Priority:    10,
+			Phase:       "planning",
 			AIAction: `The user has commented on your implementation plan.
+dev-swarm has already moved the issue to ai:planning.
+Plans are approved with the /approve command, which dev-swarm handles itself.
 
-Check the user's comment:
-- If it contains an approval keyword (approved, lgtm, ship it, etc.):
-  → Change label from user:plan-review to user:ready-to-implement
-- If it contains feedback, questions, or change requests:
-  → Change label from user:plan-review to ai:planning
+Treat the user's comment as feedback, questions, or change requests:
+  → Answer any questions
   → Revise the implementation plan based on the feedback
   → Add a new comment with the updated plan
   → Change label from ai:planning to user:plan-review`,
 		},
 		ReadyToImplement: LabelConfig{
-			Name:        "user:ready-to-implement",
-			Color:       "0052CC",
-			Description: "Plan approved, ready for AI to implement",
-			Owner:       "user",
-			AIPickup:    "always",
+			Name:          "user:ready-to-implement",
+			Color:         "0052CC",
+			Description:   "Plan approved, ready for AI to implement",
+			Owner:         "user",
+			AIPickup:      "always",
+			ExpectCommits: true,
+			Priority:      20,
+			Phase:         "implementing",
 			AIAction: `You are implementing an approved plan.
+dev-swarm has already moved the issue to ai:implementing.
+
+If a PR already exists for this issue, a reviewer requested changes:
+address the review on the existing branch and PR instead of starting over.
 
 Steps:
-1. Change label from user:ready-to-implement to ai:implementing
-2. Read the implementation plan from the issue comments
-3. Create clean, well-documented code following the plan
-4. Follow existing code style and patterns in the repository
-5. Write tests as specified in the plan
-6. Make atomic commits with clear messages that reference the issue:
+1. Read the implementation plan from the issue comments
+2. Create clean, well-documented code following the plan
+3. Follow existing code style and patterns in the repository
+4. Write tests as specified in the plan
+5. Make atomic commits with clear messages that reference the issue:
    - "Add dark mode context provider (fixes #42)"
    - "Add theme toggle component (#42)"
-7. Create a Pull Request with:
+6. Push the branch and create a Pull Request with:
    - Title: Clear description referencing issue number
    - Body: Summary of changes, link to issue (Closes #XX)
-8. Change label from ai:implementing to user:code-review`,
+7. Change label from ai:implementing to user:code-review`,
 		},
 		CodeReview: LabelConfig{
 			Name:        "user:code-review",
@@ -86,19 +111,22 @@ Steps:
 			Description: "PR created, awaiting user code review",
 			Owner:       "user",
 			AIPickup:    "on_user_comment",
+			RequiresPR:  true,
+			Priority:    30,
+			Phase:       "implementing",
 			AIAction: `The user has commented on your Pull Request.
+dev-swarm has already moved the issue to ai:implementing.
+
+Do not merge the PR yourself: dev-swarm merges it once an approving
+review is submitted and the required checks pass.
 
 Check the user's comment or review:
-- If it contains an approval keyword (approved, lgtm, ship it, etc.):
-  → Merge the PR using: gh pr merge {number} --merge --delete-branch
-  → Change label from user:code-review to ai:done
-  → Add a closing comment summarizing what was implemented
 - If it contains change requests or feedback:
-  → Change label from user:code-review to ai:implementing
   → Address each review comment specifically
   → Push new commits with clear messages
-  → Change label from ai:implementing to user:code-review
-  → Reply to review comments explaining your changes`,
+  → Reply to review comments explaining your changes
+- If it asks a question, answer it in a comment
+Then change label from ai:implementing to user:code-review`,
 		},
 		Blocked: LabelConfig{
 			Name:        "user:blocked",
@@ -122,21 +150,24 @@ Check the user's comment or review:
 			AIPickup:    "never",
 		},
 		CIFailed: LabelConfig{
-			Name:        "ai:ci-failed",
-			Color:       "D93F0B",
-			Description: "CI failed, AI will analyze and fix",
-			Owner:       "ai",
-			AIPickup:    "always",
+			Name:          "ai:ci-failed",
+			Color:         "D93F0B",
+			Description:   "CI failed, AI will analyze and fix",
+			Owner:         "ai",
+			AIPickup:      "always",
+			ExpectCommits: true,
+			Priority:      40,
+			Phase:         "ci_fix",
 			AIAction: `The CI pipeline has failed. You need to fix it.
+dev-swarm has already moved the issue to ai:implementing.
 
 Steps:
-1. Change label from ai:ci-failed to ai:implementing
-2. Fetch and analyze the CI logs using: gh run view --log-failed
-3. Identify the cause of failure (test failures, build errors, lint errors)
-4. Fix the issues in your code
-5. Commit with a clear message: "Fix CI failures (#XX)"
-6. Push the fix
-7. Wait briefly for CI to start, then change label to user:code-review
+1. Fetch and analyze the CI logs using: gh run view --log-failed
+2. Identify the cause of failure (test failures, build errors, lint errors)
+3. Fix the issues in your code
+4. Commit with a clear message: "Fix CI failures (#XX)"
+5. Push the fix
+6. Wait briefly for CI to start, then change label from ai:implementing to user:code-review
 
 Do NOT change unrelated code. Focus only on fixing the CI failure.`,
 		},
@@ -162,13 +193,9 @@ func DefaultAIInstructions() AIInstructions {
    gh issue edit {number} --remove-label "old:label" --add-label "new:label"
    ` + "```" + `
 
-2. **Comment Markers**: Wrap ALL your comments with these markers so the system
-   can distinguish AI comments from user comments:
-   ` + "```" + `
-   <!-- dev-swarm:ai -->
-   Your comment content here
-   <!-- /dev-swarm:ai -->
-   ` + "```" + `
+2. **Comment Markers**: Wrap ALL your comments with the markers given in the
+   task context so the system can distinguish AI comments from user comments.
+   The start marker is signed for each issue; copy it exactly.
 
 3. **Commit Messages**: Always reference the issue number:
    - "Add feature X (#42)"
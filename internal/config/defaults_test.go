@@ -22,15 +22,20 @@ func TestDefaultSettings(t *testing.T) {
 	if settings.OutputBufferLines != 1000 {
 		t.Errorf("OutputBufferLines = %d, want 1000", settings.OutputBufferLines)
 	}
-
-	expectedKeywords := []string{"approved", "lgtm", "ship it", "merge it", "looks good"}
-	if len(settings.ApprovalKeywords) != len(expectedKeywords) {
-		t.Errorf("len(ApprovalKeywords) = %d, want %d", len(settings.ApprovalKeywords), len(expectedKeywords))
+	if settings.MaxSessionAttempts != 3 {
+		t.Errorf("MaxSessionAttempts = %d, want 3", settings.MaxSessionAttempts)
+	}
+	if settings.RetryBackoff != 60 {
+		t.Errorf("RetryBackoff = %d, want 60", settings.RetryBackoff)
+	}
+	if settings.MaxRetryBackoff != 3600 {
+		t.Errorf("MaxRetryBackoff = %d, want 3600", settings.MaxRetryBackoff)
 	}
-	for i, kw := range expectedKeywords {
-		if settings.ApprovalKeywords[i] != kw {
-			t.Errorf("ApprovalKeywords[%d] = %q, want %q", i, settings.ApprovalKeywords[i], kw)
-		}
+	if settings.PriorityLabels["priority:high"] <= 0 {
+		t.Errorf("PriorityLabels[priority:high] = %d, want > 0", settings.PriorityLabels["priority:high"])
+	}
+	if settings.PriorityLabels["priority:low"] >= 0 {
+		t.Errorf("PriorityLabels[priority:low] = %d, want < 0", settings.PriorityLabels["priority:low"])
 	}
 }
 
@@ -77,6 +82,10 @@ func TestDefaultLabels(t *testing.T) {
 	if labels.CIFailed.AIPickup != "always" {
 		t.Errorf("CIFailed.AIPickup = %q, want %q", labels.CIFailed.AIPickup, "always")
 	}
+	// CI fixes are picked up before new plans
+	if labels.CIFailed.Priority <= labels.ReadyToPlan.Priority {
+		t.Errorf("CIFailed.Priority = %d, want above ReadyToPlan.Priority %d", labels.CIFailed.Priority, labels.ReadyToPlan.Priority)
+	}
 
 	// Test Done
 	if labels.Done.Name != "ai:done" {
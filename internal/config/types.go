@@ -1,34 +1,241 @@
 package config
 
+import "github.com/nathanbarrett/dev-swarm-go/internal/schedule"
+
 // Config represents the complete dev-swarm configuration
 type Config struct {
-	Settings       Settings          `yaml:"settings"`
-	Labels         Labels            `yaml:"labels"`
-	AIInstructions AIInstructions    `yaml:"ai_instructions"`
-	Codebases      []Codebase        `yaml:"codebases"`
+	Settings       Settings       `yaml:"settings"`
+	Labels         Labels         `yaml:"labels"`
+	Workflow       *Workflow      `yaml:"workflow,omitempty"` // Replaces labels when set
+	AIInstructions AIInstructions `yaml:"ai_instructions"`
+	Codebases      []Codebase     `yaml:"codebases"`
+
+	resolvedWorkflow *Workflow // Workflow in effect, set by Load
 }
 
 // Settings contains global settings
 type Settings struct {
-	PollInterval          int      `yaml:"poll_interval"`
-	ActivePollInterval    int      `yaml:"active_poll_interval"`
-	MaxConcurrentSessions int      `yaml:"max_concurrent_sessions"`
-	AutoMergeOnApproval   bool     `yaml:"auto_merge_on_approval"`
-	ApprovalKeywords      []string `yaml:"approval_keywords"`
-	OutputBufferLines     int      `yaml:"output_buffer_lines"`
+	PollInterval          int                    `yaml:"poll_interval"`
+	ActivePollInterval    int                    `yaml:"active_poll_interval"`
+	MaxConcurrentSessions int                    `yaml:"max_concurrent_sessions"`
+	AutoMergeOnApproval   bool                   `yaml:"auto_merge_on_approval"`
+	OutputBufferLines     int                    `yaml:"output_buffer_lines"`
+	MaxSessionAttempts    int                    `yaml:"max_session_attempts"`   // Failed sessions before an issue is blocked
+	RetryBackoff          int                    `yaml:"retry_backoff"`          // Seconds to wait after the first failure
+	MaxRetryBackoff       int                    `yaml:"max_retry_backoff"`      // Upper bound for the exponential backoff
+	SessionMaxRuntime     *int                   `yaml:"session_max_runtime"`    // Minutes a session may run; 0 disables the limit
+	SessionIdleTimeout    *int                   `yaml:"session_idle_timeout"`   // Minutes a session may go without output; 0 disables the limit
+	PriorityLabels        map[string]int         `yaml:"priority_labels"`        // Issue label -> pickup queue weight
+	MaxSessionsPerPhase   map[string]int         `yaml:"max_sessions_per_phase"` // Phase -> concurrent session cap
+	Webhook               WebhookConfig          `yaml:"webhook"`
+	Watchdog              WatchdogConfig         `yaml:"watchdog"`
+	Schedule              ScheduleConfig         `yaml:"schedule,omitempty"`  // When new sessions may start
+	BotLogin              string                 `yaml:"bot_login,omitempty"` // Separate account dev-swarm and its sessions post as
+	Budget                BudgetConfig           `yaml:"budget,omitempty"`    // Spend limits for new sessions
+	Agent                 string                 `yaml:"agent,omitempty"`     // Default agent; defaults to the built-in claude agent
+	Agents                map[string]AgentConfig `yaml:"agents,omitempty"`    // Command agents by name
+	Hooks                 []HookConfig           `yaml:"hooks,omitempty"`     // Commands run on orchestrator events
+	Notify                NotifyConfig           `yaml:"notify,omitempty"`    // Notifications when issues need a user
+	GitHub                GitHubConfig           `yaml:"github,omitempty"`    // How dev-swarm talks to GitHub
+}
+
+// WebhookConfig configures the embedded GitHub webhook receiver
+type WebhookConfig struct {
+	Enabled           bool   `yaml:"enabled"`
+	Listen            string `yaml:"listen"`             // Address to listen on, e.g. "127.0.0.1:8787"
+	Path              string `yaml:"path"`               // URL path GitHub delivers to
+	Secret            string `yaml:"secret"`             // Shared secret for X-Hub-Signature-256
+	ReconcileInterval int    `yaml:"reconcile_interval"` // Seconds between fallback polls while webhooks are enabled
+}
+
+// WatchdogConfig configures the recovery of issues left in a working state
+// without a running session
+type WatchdogConfig struct {
+	GracePeriod int    `yaml:"grace_period"` // Minutes an issue may sit in a working state without a session
+	Action      string `yaml:"action"`       // "requeue" or "block"
+}
+
+// AgentConfig describes a coding agent run as a command. In args and env
+// values, {prompt_file}, {prompt}, {worktree}, {repo} and {issue} are
+// replaced for each session.
+type AgentConfig struct {
+	Command          string            `yaml:"command"`
+	Args             []string          `yaml:"args,omitempty"`
+	Prompt           string            `yaml:"prompt,omitempty"`             // "file" (default), "arg" or "stdin"
+	Env              map[string]string `yaml:"env,omitempty"`                // Added to the session environment
+	SuccessExitCodes []int             `yaml:"success_exit_codes,omitempty"` // Exit codes that count as success; defaults to 0
+	SuccessPattern   string            `yaml:"success_pattern,omitempty"`    // Regexp some output line must match to succeed
+}
+
+// GitHubConfig chooses how dev-swarm talks to GitHub
+type GitHubConfig struct {
+	Backend  string `yaml:"backend,omitempty"`   // "gh" (default) runs the gh CLI, "api" calls the REST API directly
+	Token    string `yaml:"token,omitempty"`     // Token for the api backend; defaults to GH_TOKEN, GITHUB_TOKEN or gh's token
+	APIURL   string `yaml:"api_url,omitempty"`   // REST API root for the api backend, e.g. for GitHub Enterprise
+	PageSize int    `yaml:"page_size,omitempty"` // Items per page of list requests, up to MaxGitHubPageSize
+}
+
+// GitHub backends
+const (
+	GitHubBackendGH  = "gh"
+	GitHubBackendAPI = "api"
+)
+
+// BackendName returns the configured backend, defaulting to the gh CLI
+func (g *GitHubConfig) BackendName() string {
+	if g.Backend == "" {
+		return GitHubBackendGH
+	}
+	return g.Backend
+}
+
+// Page sizes of GitHub list requests
+const (
+	DefaultGitHubPageSize = 100
+	MaxGitHubPageSize     = 100 // The most GitHub returns per page
+)
+
+// PerPage returns the configured page size, defaulting to DefaultGitHubPageSize
+func (g *GitHubConfig) PerPage() int {
+	if g.PageSize == 0 {
+		return DefaultGitHubPageSize
+	}
+	return g.PageSize
+}
+
+// NotifyConfig sends notifications when issues move to a state that waits
+// for a user
+type NotifyConfig struct {
+	Digest int          `yaml:"digest,omitempty"` // Seconds to collect notifications into one message; 0 sends each at once
+	Sinks  []SinkConfig `yaml:"sinks,omitempty"`
+}
+
+// SinkConfig configures where notifications go and which ones
+type SinkConfig struct {
+	Type      string   `yaml:"type"`                // "desktop", "webhook" or "tui"
+	URL       string   `yaml:"url,omitempty"`       // Webhook URL
+	Codebases []string `yaml:"codebases,omitempty"` // Only these codebases, by name; all if empty
+	States    []string `yaml:"states,omitempty"`    // Only these labels; every state that waits for a user if empty
+}
+
+// Notification sink types
+const (
+	SinkDesktop = "desktop"
+	SinkWebhook = "webhook"
+	SinkTUI     = "tui"
+)
+
+// IsValidSinkType checks if a string names a known notification sink
+func IsValidSinkType(sink string) bool {
+	return sink == SinkDesktop || sink == SinkWebhook || sink == SinkTUI
+}
+
+// HookConfig runs a command when an orchestrator event happens. The command
+// runs with sh -c and gets the event as a JSON document on stdin.
+type HookConfig struct {
+	Event   string `yaml:"event"`             // Event name, or "*" for every event
+	Command string `yaml:"command"`           // Shell command to run
+	Timeout int    `yaml:"timeout,omitempty"` // Seconds before the hook is killed; defaults to 30
+	Veto    bool   `yaml:"veto,omitempty"`    // issue.pickup only: a failing hook skips the pickup
+}
+
+// Hook events
+const (
+	HookIssuePickup    = "issue.pickup"
+	HookSessionStarted = "session.started"
+	HookSessionEnded   = "session.ended"
+	HookLabelChanged   = "label.changed"
+	HookPRMerged       = "pr.merged"
+	HookCIFailed       = "ci.failed"
+	HookAnyEvent       = "*"
+)
+
+// IsValidHookEvent checks if a string names a hook event or "*"
+func IsValidHookEvent(event string) bool {
+	switch event {
+	case HookIssuePickup, HookSessionStarted, HookSessionEnded, HookLabelChanged,
+		HookPRMerged, HookCIFailed, HookAnyEvent:
+		return true
+	}
+	return false
+}
+
+// AgentClaude is the name of the built-in Claude Code agent
+const AgentClaude = "claude"
+
+// How a command agent receives its prompt
+const (
+	PromptFile  = "file"  // Path of the prompt file, as {prompt_file} or appended to args
+	PromptArg   = "arg"   // Prompt text, as {prompt} or appended to args
+	PromptStdin = "stdin" // Prompt text on stdin
+)
+
+// IsValidPromptMode checks if a string names a known way to pass the prompt
+func IsValidPromptMode(mode string) bool {
+	return mode == PromptFile || mode == PromptArg || mode == PromptStdin
+}
+
+// PromptMode returns how the agent receives its prompt, defaulting to a file
+func (a *AgentConfig) PromptMode() string {
+	if a.Prompt == "" {
+		return PromptFile
+	}
+	return a.Prompt
+}
+
+// BudgetConfig caps what sessions may spend, in US dollars as reported by the
+// agent. Days and months follow the local time zone; zero means no limit.
+type BudgetConfig struct {
+	Daily   float64 `yaml:"daily,omitempty"`
+	Monthly float64 `yaml:"monthly,omitempty"`
+}
+
+// ScheduleConfig restricts when new sessions may start. Windows and pauses
+// are weekly ranges such as "mon-fri 18:00-08:00" or "sat-sun".
+type ScheduleConfig struct {
+	Timezone string   `yaml:"timezone,omitempty"` // IANA time zone; defaults to the local time zone
+	Windows  []string `yaml:"windows,omitempty"`  // Sessions only start inside these; any time if empty
+	Pause    []string `yaml:"pause,omitempty"`    // Sessions never start inside these
+}
+
+// Build parses the schedule. Returns nil if it has no windows or pauses.
+func (s *ScheduleConfig) Build() (*schedule.Schedule, error) {
+	if s == nil || (len(s.Windows) == 0 && len(s.Pause) == 0) {
+		return nil, nil
+	}
+	return schedule.New(s.Timezone, s.Windows, s.Pause)
+}
+
+// Watchdog actions for issues left in a working state
+const (
+	WatchdogRequeue = "requeue"
+	WatchdogBlock   = "block"
+)
+
+// IsValidWatchdogAction checks if a string names a known watchdog action
+func IsValidWatchdogAction(action string) bool {
+	return action == WatchdogRequeue || action == WatchdogBlock
+}
+
+// RecoveryAction returns the configured watchdog action, defaulting to requeue
+func (w *WatchdogConfig) RecoveryAction() string {
+	if w.Action == "" {
+		return WatchdogRequeue
+	}
+	return w.Action
 }
 
 // Labels contains all label configurations
 type Labels struct {
-	ReadyToPlan       LabelConfig `yaml:"ready_to_plan"`
-	PlanReview        LabelConfig `yaml:"plan_review"`
-	ReadyToImplement  LabelConfig `yaml:"ready_to_implement"`
-	CodeReview        LabelConfig `yaml:"code_review"`
-	Blocked           LabelConfig `yaml:"blocked"`
-	Planning          LabelConfig `yaml:"planning"`
-	Implementing      LabelConfig `yaml:"implementing"`
-	CIFailed          LabelConfig `yaml:"ci_failed"`
-	Done              LabelConfig `yaml:"done"`
+	ReadyToPlan      LabelConfig `yaml:"ready_to_plan"`
+	PlanReview       LabelConfig `yaml:"plan_review"`
+	ReadyToImplement LabelConfig `yaml:"ready_to_implement"`
+	CodeReview       LabelConfig `yaml:"code_review"`
+	Blocked          LabelConfig `yaml:"blocked"`
+	Planning         LabelConfig `yaml:"planning"`
+	Implementing     LabelConfig `yaml:"implementing"`
+	CIFailed         LabelConfig `yaml:"ci_failed"`
+	Done             LabelConfig `yaml:"done"`
 }
 
 // LabelConfig represents a single label configuration
@@ -36,9 +243,25 @@ type LabelConfig struct {
 	Name        string `yaml:"name"`
 	Color       string `yaml:"color"`
 	Description string `yaml:"description"`
-	Owner       string `yaml:"owner"`       // "user" or "ai"
-	AIPickup    string `yaml:"ai_pickup"`   // "always", "never", "on_user_comment"
-	AIAction    string `yaml:"ai_action"`   // Instructions for AI when this label is picked up
+	Owner       string `yaml:"owner"`                  // "user" or "ai"
+	AIPickup    string `yaml:"ai_pickup"`              // "always", "never", "on_user_comment"
+	AIAction    string `yaml:"ai_action"`              // Instructions for AI when this label is picked up
+	MaxRuntime  *int   `yaml:"max_runtime,omitempty"`  // Minutes; overrides settings.session_max_runtime, 0 disables
+	IdleTimeout *int   `yaml:"idle_timeout,omitempty"` // Minutes; overrides settings.session_idle_timeout, 0 disables
+	Priority    int    `yaml:"priority,omitempty"`     // Pickup queue weight for issues in this state
+	Phase       string `yaml:"phase,omitempty"`        // "planning", "implementing" or "ci_fix"
+	Agent       string `yaml:"agent,omitempty"`        // Agent for sessions picked up from this state; overrides the codebase agent
+
+	// Workflow graph
+	Role        string   `yaml:"role,omitempty"`        // Built-in behaviour bound to this state
+	Transitions []string `yaml:"transitions,omitempty"` // Labels this state may move to
+	Initial     bool     `yaml:"initial,omitempty"`     // Entry point for new issues
+	Terminal    bool     `yaml:"terminal,omitempty"`    // Ends the workflow
+
+	// Session verification
+	WorkingLabel  string `yaml:"working_label,omitempty"`  // Label applied while a session works on this state
+	ExpectCommits bool   `yaml:"expect_commits,omitempty"` // Sessions from this state must push new commits
+	RequiresPR    bool   `yaml:"requires_pr,omitempty"`    // Issues entering this state must have an open PR
 }
 
 // AIInstructions contains global AI instructions
@@ -48,12 +271,100 @@ type AIInstructions struct {
 
 // Codebase represents a single repository configuration
 type Codebase struct {
-	Name          string  `yaml:"name"`
-	Repo          string  `yaml:"repo"`           // "owner/repo" format
-	LocalPath     string  `yaml:"local_path"`
-	DefaultBranch string  `yaml:"default_branch"`
-	Enabled       bool    `yaml:"enabled"`
-	Labels        *Labels `yaml:"labels,omitempty"` // Per-codebase label overrides
+	Name          string    `yaml:"name"`
+	Repo          string    `yaml:"repo"` // "owner/repo" format
+	LocalPath     string    `yaml:"local_path"`
+	DefaultBranch string    `yaml:"default_branch"`
+	Enabled       bool      `yaml:"enabled"`
+	Labels        *Labels   `yaml:"labels,omitempty"`   // Per-codebase label overrides
+	Workflow      *Workflow `yaml:"workflow,omitempty"` // Per-codebase workflow; defaults to the global one
+	Weight        int       `yaml:"weight,omitempty"`   // Pickup queue weight for this codebase's issues
+
+	MaxConcurrentSessions int `yaml:"max_concurrent_sessions,omitempty"` // Per-codebase cap; 0 uses only the global cap
+
+	Merge    MergeConfig     `yaml:"merge,omitempty"`    // How approved PRs are merged
+	Access   AccessConfig    `yaml:"access,omitempty"`   // Whose comments and reviews trigger AI work
+	Schedule *ScheduleConfig `yaml:"schedule,omitempty"` // Replaces the global schedule when set
+	Agent    string          `yaml:"agent,omitempty"`    // Agent for this codebase's sessions; overrides settings.agent
+	Hooks    []HookConfig    `yaml:"hooks,omitempty"`    // Run in addition to the global hooks
+
+	resolvedWorkflow *Workflow // Workflow in effect, set by Load
+}
+
+// MergeConfig configures how the orchestrator merges approved PRs
+type MergeConfig struct {
+	Strategy   string   `yaml:"strategy,omitempty"`    // "merge", "squash" or "rebase"; defaults to "merge"
+	KeepBranch bool     `yaml:"keep_branch,omitempty"` // Keep the head branch after merging
+	Reviewers  []string `yaml:"reviewers,omitempty"`   // Logins whose reviews count; defaults to repo owners, members and collaborators
+}
+
+// AccessConfig limits who can trigger AI work through comments, reviews and
+// slash commands. Users and team members are always allowed; anyone else
+// needs at least the fallback repository permission.
+type AccessConfig struct {
+	Users      []string `yaml:"users,omitempty"`      // Allowed logins
+	Teams      []string `yaml:"teams,omitempty"`      // Allowed teams as "org/team-slug"
+	Permission string   `yaml:"permission,omitempty"` // Fallback: "read", "triage", "write" (default), "maintain", "admin" or "none"
+}
+
+// PermissionNone disables the repository permission fallback
+const PermissionNone = "none"
+
+// permissionLevels orders the repository permissions GitHub reports
+var permissionLevels = map[string]int{
+	"read":     1,
+	"triage":   2,
+	"write":    3,
+	"maintain": 4,
+	"admin":    5,
+}
+
+// IsValidPermission checks if a string names a repository permission or "none"
+func IsValidPermission(permission string) bool {
+	_, ok := permissionLevels[permission]
+	return ok || permission == PermissionNone
+}
+
+// MinPermission returns the fallback permission, defaulting to write
+func (a *AccessConfig) MinPermission() string {
+	if a.Permission == "" {
+		return "write"
+	}
+	return a.Permission
+}
+
+// PermissionAllows checks if a user's repository permission meets the fallback
+func (a *AccessConfig) PermissionAllows(have string) bool {
+	want := a.MinPermission()
+	if want == PermissionNone {
+		return false
+	}
+	level, ok := permissionLevels[have]
+	return ok && level >= permissionLevels[want]
+}
+
+// Merge strategies supported by gh pr merge
+const (
+	MergeStrategyMerge  = "merge"
+	MergeStrategySquash = "squash"
+	MergeStrategyRebase = "rebase"
+)
+
+// IsValidMergeStrategy checks if a string names a known merge strategy
+func IsValidMergeStrategy(strategy string) bool {
+	switch strategy {
+	case MergeStrategyMerge, MergeStrategySquash, MergeStrategyRebase:
+		return true
+	}
+	return false
+}
+
+// MergeStrategy returns the configured merge strategy, defaulting to a merge commit
+func (m *MergeConfig) MergeStrategy() string {
+	if m.Strategy == "" {
+		return MergeStrategyMerge
+	}
+	return m.Strategy
 }
 
 // PickupRule defines when AI should pick up an issue
@@ -65,6 +376,24 @@ const (
 	PickupOnUserComment PickupRule = "on_user_comment"
 )
 
+// SessionPhase groups pickup labels for per-phase concurrency limits
+type SessionPhase string
+
+const (
+	PhasePlanning     SessionPhase = "planning"
+	PhaseImplementing SessionPhase = "implementing"
+	PhaseCIFix        SessionPhase = "ci_fix"
+)
+
+// IsValidPhase checks if a string names a known session phase
+func IsValidPhase(phase string) bool {
+	switch SessionPhase(phase) {
+	case PhasePlanning, PhaseImplementing, PhaseCIFix:
+		return true
+	}
+	return false
+}
+
 // LabelOwner defines who owns the current state
 type LabelOwner string
 
@@ -106,3 +106,72 @@ func TestLabelOwnerConstants(t *testing.T) {
 		t.Errorf("OwnerAI = %q, want %q", OwnerAI, "ai")
 	}
 }
+
+func TestMergeStrategy(t *testing.T) {
+	tests := []struct {
+		strategy string
+		want     string
+	}{
+		{"", "merge"},
+		{"squash", "squash"},
+		{"rebase", "rebase"},
+	}
+
+	for _, tt := range tests {
+		m := MergeConfig{Strategy: tt.strategy}
+		if got := m.MergeStrategy(); got != tt.want {
+			t.Errorf("MergeStrategy() with %q = %q, want %q", tt.strategy, got, tt.want)
+		}
+	}
+
+	if IsValidMergeStrategy("fast-forward") {
+		t.Error("IsValidMergeStrategy(fast-forward) = true, want false")
+	}
+}
+
+func TestWatchdogRecoveryAction(t *testing.T) {
+	tests := []struct {
+		action string
+		want   string
+	}{
+		{"", "requeue"},
+		{"requeue", "requeue"},
+		{"block", "block"},
+	}
+
+	for _, tt := range tests {
+		w := WatchdogConfig{Action: tt.action}
+		if got := w.RecoveryAction(); got != tt.want {
+			t.Errorf("RecoveryAction() with %q = %q, want %q", tt.action, got, tt.want)
+		}
+	}
+
+	if IsValidWatchdogAction("ignore") {
+		t.Error("IsValidWatchdogAction(ignore) = true, want false")
+	}
+}
+
+func TestAccessPermissionAllows(t *testing.T) {
+	tests := []struct {
+		permission string
+		have       string
+		want       bool
+	}{
+		{"", "write", true},
+		{"", "triage", false},
+		{"", "admin", true},
+		{"maintain", "write", false},
+		{"maintain", "maintain", true},
+		{"read", "read", true},
+		{"none", "admin", false},
+		{"write", "none", false},
+		{"write", "custom-role", false},
+	}
+
+	for _, tt := range tests {
+		a := AccessConfig{Permission: tt.permission}
+		if got := a.PermissionAllows(tt.have); got != tt.want {
+			t.Errorf("PermissionAllows(%q) with %q = %v, want %v", tt.have, tt.permission, got, tt.want)
+		}
+	}
+}
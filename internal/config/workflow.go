@@ -0,0 +1,314 @@
+package config
+
+import (
+	"fmt"
+
+	apperrors "github.com/nathanbarrett/dev-swarm-go/internal/errors"
+)
+
+// Workflow defines the issue workflow as a graph of label states
+type Workflow struct {
+	States []LabelConfig `yaml:"states"`
+}
+
+// Roles bind workflow states to built-in orchestrator behaviour
+const (
+	RoleReadyToPlan      = "ready_to_plan"
+	RolePlanReview       = "plan_review"
+	RoleReadyToImplement = "ready_to_implement"
+	RoleCodeReview       = "code_review"
+	RoleBlocked          = "blocked"
+	RolePlanning         = "planning"
+	RoleImplementing     = "implementing"
+	RoleCIFailed         = "ci_failed"
+	RoleDone             = "done"
+)
+
+// IsValidRole checks if a string names a known workflow role
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleReadyToPlan, RolePlanReview, RoleReadyToImplement, RoleCodeReview,
+		RoleBlocked, RolePlanning, RoleImplementing, RoleCIFailed, RoleDone:
+		return true
+	}
+	return false
+}
+
+// defaultTransitions are the transitions of the built-in workflow, by role
+var defaultTransitions = map[string][]string{
+	RoleReadyToPlan:      {RolePlanning},
+	RolePlanning:         {RolePlanReview, RoleBlocked},
+	RolePlanReview:       {RoleReadyToImplement, RolePlanning},
+	RoleReadyToImplement: {RoleImplementing},
+	RoleImplementing:     {RoleCodeReview, RoleBlocked},
+	RoleCodeReview:       {RoleDone, RoleImplementing, RoleCIFailed},
+	RoleCIFailed:         {RoleImplementing, RoleCodeReview, RoleBlocked},
+	RoleBlocked:          {RoleReadyToPlan, RolePlanReview, RoleReadyToImplement, RoleCodeReview},
+}
+
+// defaultWorkingRoles are the states the orchestrator moves issues to while
+// a session works on them, by pickup role
+var defaultWorkingRoles = map[string]string{
+	RoleReadyToPlan:      RolePlanning,
+	RolePlanReview:       RolePlanning,
+	RoleReadyToImplement: RoleImplementing,
+	RoleCodeReview:       RoleImplementing,
+	RoleCIFailed:         RoleImplementing,
+}
+
+// Workflow builds the built-in workflow graph from the fixed label set
+func (l *Labels) Workflow() *Workflow {
+	roles := []struct {
+		role  string
+		label LabelConfig
+	}{
+		{RoleReadyToPlan, l.ReadyToPlan},
+		{RolePlanReview, l.PlanReview},
+		{RoleReadyToImplement, l.ReadyToImplement},
+		{RoleCodeReview, l.CodeReview},
+		{RoleBlocked, l.Blocked},
+		{RolePlanning, l.Planning},
+		{RoleImplementing, l.Implementing},
+		{RoleCIFailed, l.CIFailed},
+		{RoleDone, l.Done},
+	}
+
+	names := make(map[string]string, len(roles))
+	for _, r := range roles {
+		names[r.role] = r.label.Name
+	}
+
+	w := &Workflow{States: make([]LabelConfig, 0, len(roles))}
+	for _, r := range roles {
+		state := r.label
+		state.Role = r.role
+		state.Initial = r.role == RoleReadyToPlan
+		state.Terminal = r.role == RoleDone
+		state.Transitions = nil
+		for _, target := range defaultTransitions[r.role] {
+			state.Transitions = append(state.Transitions, names[target])
+		}
+		if state.WorkingLabel == "" {
+			state.WorkingLabel = names[defaultWorkingRoles[r.role]]
+		}
+		w.States = append(w.States, state)
+	}
+	return w
+}
+
+// GetByName returns a workflow state by its label name
+func (w *Workflow) GetByName(name string) *LabelConfig {
+	for i := range w.States {
+		if w.States[i].Name == name {
+			return &w.States[i]
+		}
+	}
+	return nil
+}
+
+// GetByRole returns the workflow state bound to a role
+func (w *Workflow) GetByRole(role string) *LabelConfig {
+	for i := range w.States {
+		if w.States[i].Role == role {
+			return &w.States[i]
+		}
+	}
+	return nil
+}
+
+// RoleLabel returns the label name bound to a role, or "" if the workflow has none
+func (w *Workflow) RoleLabel(role string) string {
+	if state := w.GetByRole(role); state != nil {
+		return state.Name
+	}
+	return ""
+}
+
+// HasRole checks if a label is the state bound to a role
+func (w *Workflow) HasRole(name, role string) bool {
+	state := w.GetByName(name)
+	return state != nil && state.Role == role
+}
+
+// GetPickupLabels returns states that AI should pick up (always or on_user_comment)
+func (w *Workflow) GetPickupLabels() []LabelConfig {
+	var result []LabelConfig
+	for _, state := range w.States {
+		if state.AIPickup == string(PickupAlways) || state.AIPickup == string(PickupOnUserComment) {
+			result = append(result, state)
+		}
+	}
+	return result
+}
+
+// IsWorkingLabel checks if a label is the working state of a pickup state
+func (w *Workflow) IsWorkingLabel(name string) bool {
+	for _, state := range w.States {
+		if name != "" && state.WorkingLabel == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsUser checks if issues in a state wait for a user: the state is owned
+// by the user and the AI does not pick it up on its own
+func (w *Workflow) NeedsUser(name string) bool {
+	state := w.GetByName(name)
+	return state != nil && state.Owner == string(OwnerUser) && state.AIPickup != string(PickupAlways)
+}
+
+// CanTransition checks if the workflow allows moving from one label to another
+func (w *Workflow) CanTransition(from, to string) bool {
+	state := w.GetByName(from)
+	if state == nil {
+		return false
+	}
+	for _, target := range state.Transitions {
+		if target == to {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedOutcomes returns the labels a session picked up from a state may
+// leave the issue in: the transitions of the pickup and working states,
+// excluding the working state itself
+func (w *Workflow) AllowedOutcomes(pickup string) []string {
+	state := w.GetByName(pickup)
+	if state == nil {
+		return nil
+	}
+
+	candidates := state.Transitions
+	if working := w.GetByName(state.WorkingLabel); working != nil {
+		candidates = append(append([]string{}, working.Transitions...), state.Transitions...)
+	} else if state.AIPickup == string(PickupOnUserComment) {
+		// Answering a comment may leave the issue where it is
+		candidates = append([]string{pickup}, state.Transitions...)
+	}
+
+	seen := make(map[string]bool, len(candidates))
+	var outcomes []string
+	for _, label := range candidates {
+		if label == state.WorkingLabel || seen[label] {
+			continue
+		}
+		seen[label] = true
+		outcomes = append(outcomes, label)
+	}
+	return outcomes
+}
+
+// validateWorkflow checks that a workflow is a well-formed graph
+func validateWorkflow(field string, w *Workflow) error {
+	if len(w.States) == 0 {
+		return &apperrors.ConfigError{Field: field + ".states", Message: "must define at least one state"}
+	}
+
+	names := make(map[string]bool, len(w.States))
+	roles := make(map[string]bool)
+	hasInitial := false
+	for i, state := range w.States {
+		stateField := fmt.Sprintf("%s.states[%d]", field, i)
+
+		if state.Name == "" {
+			return &apperrors.ConfigError{Field: stateField + ".name", Message: "is required"}
+		}
+		if names[state.Name] {
+			return &apperrors.ConfigError{Field: stateField + ".name", Message: fmt.Sprintf("duplicate state %q", state.Name)}
+		}
+		names[state.Name] = true
+
+		if state.Owner != string(OwnerUser) && state.Owner != string(OwnerAI) {
+			return &apperrors.ConfigError{Field: stateField + ".owner", Message: "must be \"user\" or \"ai\""}
+		}
+		switch PickupRule(state.AIPickup) {
+		case "", PickupAlways, PickupNever, PickupOnUserComment:
+		default:
+			return &apperrors.ConfigError{Field: stateField + ".ai_pickup", Message: fmt.Sprintf("unknown pickup rule %q", state.AIPickup)}
+		}
+		if state.Phase != "" && !IsValidPhase(state.Phase) {
+			return &apperrors.ConfigError{Field: stateField + ".phase", Message: fmt.Sprintf("unknown phase %q", state.Phase)}
+		}
+		if state.MaxRuntime != nil && *state.MaxRuntime < 0 {
+			return &apperrors.ConfigError{Field: stateField + ".max_runtime", Message: "must not be negative"}
+		}
+		if state.IdleTimeout != nil && *state.IdleTimeout < 0 {
+			return &apperrors.ConfigError{Field: stateField + ".idle_timeout", Message: "must not be negative"}
+		}
+		if state.Role != "" {
+			if !IsValidRole(state.Role) {
+				return &apperrors.ConfigError{Field: stateField + ".role", Message: fmt.Sprintf("unknown role %q", state.Role)}
+			}
+			if roles[state.Role] {
+				return &apperrors.ConfigError{Field: stateField + ".role", Message: fmt.Sprintf("role %q is used by more than one state", state.Role)}
+			}
+			roles[state.Role] = true
+		}
+		if state.Initial {
+			hasInitial = true
+		}
+	}
+
+	if !hasInitial {
+		return &apperrors.ConfigError{Field: field + ".states", Message: "must mark at least one state as initial"}
+	}
+
+	for i, state := range w.States {
+		stateField := fmt.Sprintf("%s.states[%d]", field, i)
+		if state.WorkingLabel != "" && !names[state.WorkingLabel] {
+			return &apperrors.ConfigError{Field: stateField + ".working_label", Message: fmt.Sprintf("unknown state %q", state.WorkingLabel)}
+		}
+		for _, target := range state.Transitions {
+			if !names[target] {
+				return &apperrors.ConfigError{Field: stateField + ".transitions", Message: fmt.Sprintf("unknown state %q", target)}
+			}
+		}
+		if len(state.Transitions) == 0 && !state.Terminal {
+			return &apperrors.ConfigError{
+				Field:   stateField + ".transitions",
+				Message: fmt.Sprintf("state %q has no transitions and is not marked terminal", state.Name),
+			}
+		}
+	}
+
+	reachable := w.reachableStates()
+	for i, state := range w.States {
+		if !reachable[state.Name] {
+			return &apperrors.ConfigError{
+				Field:   fmt.Sprintf("%s.states[%d]", field, i),
+				Message: fmt.Sprintf("state %q is unreachable from the initial states", state.Name),
+			}
+		}
+	}
+
+	return nil
+}
+
+// reachableStates walks the graph from the initial states. The blocked and
+// ci_failed states count as entry points because the orchestrator moves
+// issues there itself.
+func (w *Workflow) reachableStates() map[string]bool {
+	reachable := make(map[string]bool, len(w.States))
+	var pending []string
+	for _, state := range w.States {
+		if state.Initial || state.Role == RoleBlocked || state.Role == RoleCIFailed {
+			reachable[state.Name] = true
+			pending = append(pending, state.Name)
+		}
+	}
+
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		for _, target := range w.GetByName(name).Transitions {
+			if !reachable[target] {
+				reachable[target] = true
+				pending = append(pending, target)
+			}
+		}
+	}
+	return reachable
+}
@@ -0,0 +1,368 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	apperrors "github.com/nathanbarrett/dev-swarm-go/internal/errors"
+)
+
+func TestDefaultWorkflow(t *testing.T) {
+	labels := DefaultLabels()
+	w := labels.Workflow()
+
+	if len(w.States) != 9 {
+		t.Fatalf("len(States) = %d, want 9", len(w.States))
+	}
+	if err := validateWorkflow("workflow", w); err != nil {
+		t.Errorf("validateWorkflow() error = %v", err)
+	}
+
+	if got := w.RoleLabel(RoleBlocked); got != "user:blocked" {
+		t.Errorf("RoleLabel(blocked) = %q, want %q", got, "user:blocked")
+	}
+	if got := w.RoleLabel(RoleCIFailed); got != "ai:ci-failed" {
+		t.Errorf("RoleLabel(ci_failed) = %q, want %q", got, "ai:ci-failed")
+	}
+	if !w.GetByName("ai:done").Terminal {
+		t.Error("ai:done should be terminal")
+	}
+	if !w.GetByName("user:ready-to-plan").Initial {
+		t.Error("user:ready-to-plan should be initial")
+	}
+	if len(w.GetPickupLabels()) != 5 {
+		t.Errorf("len(GetPickupLabels()) = %d, want 5", len(w.GetPickupLabels()))
+	}
+}
+
+func TestWorkflowFollowsRenamedLabels(t *testing.T) {
+	labels := DefaultLabels()
+	labels.Planning.Name = "bot:planning"
+	w := labels.Workflow()
+
+	if !w.CanTransition("user:ready-to-plan", "bot:planning") {
+		t.Error("ready-to-plan should transition to the renamed planning label")
+	}
+	if !w.HasRole("bot:planning", RolePlanning) {
+		t.Error("renamed label should keep the planning role")
+	}
+}
+
+func TestWorkflowCanTransition(t *testing.T) {
+	labels := DefaultLabels()
+	w := labels.Workflow()
+
+	tests := []struct {
+		from string
+		to   string
+		want bool
+	}{
+		{"user:ready-to-plan", "ai:planning", true},
+		{"ai:planning", "user:plan-review", true},
+		{"user:code-review", "ai:done", true},
+		{"user:ready-to-plan", "ai:done", false},
+		{"ai:done", "user:ready-to-plan", false},
+		{"unknown", "ai:done", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			if got := w.CanTransition(tt.from, tt.to); got != tt.want {
+				t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkflowIsWorkingLabel(t *testing.T) {
+	labels := DefaultLabels()
+	w := labels.Workflow()
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"ai:planning", true},
+		{"ai:implementing", true},
+		{"ai:ci-failed", false},
+		{"user:blocked", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := w.IsWorkingLabel(tt.name); got != tt.want {
+			t.Errorf("IsWorkingLabel(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWorkflowNeedsUser(t *testing.T) {
+	labels := DefaultLabels()
+	w := labels.Workflow()
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"user:plan-review", true},
+		{"user:code-review", true},
+		{"user:blocked", true},
+		{"user:ready-to-plan", false},
+		{"ai:implementing", false},
+		{"unknown", false},
+	}
+
+	for _, tt := range tests {
+		if got := w.NeedsUser(tt.name); got != tt.want {
+			t.Errorf("NeedsUser(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWorkflowAllowedOutcomes(t *testing.T) {
+	labels := DefaultLabels()
+	w := labels.Workflow()
+
+	tests := []struct {
+		pickup string
+		want   []string
+	}{
+		{"user:ready-to-plan", []string{"user:plan-review", "user:blocked"}},
+		{"user:plan-review", []string{"user:plan-review", "user:blocked", "user:ready-to-implement"}},
+		{"user:code-review", []string{"user:code-review", "user:blocked", "ai:done", "ai:ci-failed"}},
+		{"unknown", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pickup, func(t *testing.T) {
+			got := w.AllowedOutcomes(tt.pickup)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("AllowedOutcomes(%q) = %v, want %v", tt.pickup, got, tt.want)
+			}
+		})
+	}
+
+	// Without a working label, comment-driven states may stay where they are
+	custom := &Workflow{States: []LabelConfig{
+		{Name: "user:qa", AIPickup: "on_user_comment", Transitions: []string{"ai:done"}},
+		{Name: "ai:done", Terminal: true},
+	}}
+	if got := custom.AllowedOutcomes("user:qa"); strings.Join(got, ",") != "user:qa,ai:done" {
+		t.Errorf("AllowedOutcomes(user:qa) = %v, want [user:qa ai:done]", got)
+	}
+}
+
+func TestValidateWorkflow(t *testing.T) {
+	state := func(name string, transitions ...string) LabelConfig {
+		return LabelConfig{Name: name, Owner: "user", AIPickup: "never", Transitions: transitions}
+	}
+	initial := func(s LabelConfig) LabelConfig {
+		s.Initial = true
+		return s
+	}
+	terminal := func(s LabelConfig) LabelConfig {
+		s.Terminal = true
+		return s
+	}
+	withRole := func(s LabelConfig, role string) LabelConfig {
+		s.Role = role
+		return s
+	}
+	withWorkingLabel := func(s LabelConfig, label string) LabelConfig {
+		s.WorkingLabel = label
+		return s
+	}
+
+	tests := []struct {
+		name    string
+		states  []LabelConfig
+		wantErr string
+	}{
+		{
+			name: "valid custom workflow",
+			states: []LabelConfig{
+				initial(state("ai:triage", "user:qa")),
+				state("user:qa", "ai:docs"),
+				terminal(state("ai:docs")),
+			},
+		},
+		{
+			name:    "no states",
+			states:  nil,
+			wantErr: "at least one state",
+		},
+		{
+			name: "no initial state",
+			states: []LabelConfig{
+				terminal(state("ai:done")),
+			},
+			wantErr: "initial",
+		},
+		{
+			name: "duplicate state",
+			states: []LabelConfig{
+				initial(state("user:qa", "user:qa")),
+				state("user:qa", "user:qa"),
+			},
+			wantErr: "duplicate",
+		},
+		{
+			name: "unknown transition target",
+			states: []LabelConfig{
+				initial(state("user:qa", "ai:missing")),
+			},
+			wantErr: "unknown state",
+		},
+		{
+			name: "dead end without terminal",
+			states: []LabelConfig{
+				initial(state("user:qa", "ai:docs")),
+				state("ai:docs"),
+			},
+			wantErr: "not marked terminal",
+		},
+		{
+			name: "unreachable state",
+			states: []LabelConfig{
+				initial(terminal(state("user:qa"))),
+				terminal(state("ai:docs")),
+			},
+			wantErr: "unreachable",
+		},
+		{
+			name: "unknown role",
+			states: []LabelConfig{
+				withRole(initial(terminal(state("user:qa"))), "qa"),
+			},
+			wantErr: "unknown role",
+		},
+		{
+			name: "duplicate role",
+			states: []LabelConfig{
+				withRole(initial(state("user:qa", "ai:docs")), RoleDone),
+				withRole(terminal(state("ai:docs")), RoleDone),
+			},
+			wantErr: "more than one state",
+		},
+		{
+			name: "invalid owner",
+			states: []LabelConfig{
+				{Name: "user:qa", Owner: "bot", Initial: true, Terminal: true},
+			},
+			wantErr: "owner",
+		},
+		{
+			name: "unknown working label",
+			states: []LabelConfig{
+				withWorkingLabel(initial(terminal(state("user:qa"))), "ai:missing"),
+			},
+			wantErr: "working_label",
+		},
+		{
+			name: "blocked state is reachable without transitions into it",
+			states: []LabelConfig{
+				initial(terminal(state("user:qa"))),
+				withRole(state("user:blocked", "user:qa"), RoleBlocked),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWorkflow("workflow", &Workflow{States: tt.states})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateWorkflow() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("validateWorkflow() error = nil, want error containing %q", tt.wantErr)
+			}
+			if _, ok := err.(*apperrors.ConfigError); !ok {
+				t.Errorf("validateWorkflow() error type = %T, want *ConfigError", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateWorkflow() error = %q, want it to contain %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadCustomWorkflow(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "config-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	data := `
+workflow:
+  states:
+    - name: ai:triage
+      owner: ai
+      ai_pickup: always
+      initial: true
+      transitions: [user:qa]
+    - name: user:qa
+      owner: user
+      ai_pickup: on_user_comment
+      transitions: [ai:done]
+    - name: ai:done
+      owner: ai
+      ai_pickup: never
+      role: done
+      terminal: true
+codebases:
+  - name: default
+    repo: owner/default
+    local_path: /tmp/default
+    default_branch: main
+    enabled: true
+  - name: custom
+    repo: owner/custom
+    local_path: /tmp/custom
+    default_branch: main
+    enabled: true
+    workflow:
+      states:
+        - name: user:todo
+          owner: user
+          ai_pickup: always
+          initial: true
+          terminal: true
+`
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got := cfg.GetWorkflow().RoleLabel(RoleDone); got != "ai:done" {
+		t.Errorf("GetWorkflow().RoleLabel(done) = %q, want %q", got, "ai:done")
+	}
+	if cfg.Codebases[0].GetWorkflow().GetByName("ai:triage") == nil {
+		t.Error("codebase without a workflow should use the global workflow")
+	}
+	if cfg.Codebases[1].GetWorkflow().GetByName("user:todo") == nil {
+		t.Error("codebase workflow should override the global workflow")
+	}
+
+	// Saving must not turn the resolved workflows into explicit config
+	if err := Save(cfg, configPath); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	saved, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(saved), "workflow:") != 2 {
+		t.Errorf("saved config has %d workflows, want 2", strings.Count(string(saved), "workflow:"))
+	}
+}
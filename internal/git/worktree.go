@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -150,3 +151,16 @@ func GetWorktreePath(worktreesDir, codebaseName string, issueNumber int) string
 func GetBranchName(issueNumber int) string {
 	return fmt.Sprintf("claude/issue-%d", issueNumber)
 }
+
+// ParseBranchName returns the issue number of a branch created by GetBranchName
+func ParseBranchName(branch string) (int, bool) {
+	suffix, ok := strings.CutPrefix(branch, "claude/issue-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(suffix)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
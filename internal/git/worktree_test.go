@@ -0,0 +1,27 @@
+package git
+
+import "testing"
+
+func TestParseBranchName(t *testing.T) {
+	tests := []struct {
+		branch string
+		want   int
+		wantOK bool
+	}{
+		{GetBranchName(42), 42, true},
+		{"claude/issue-7", 7, true},
+		{"claude/issue-42-fix", 0, false},
+		{"claude/issue-", 0, false},
+		{"feature/issue-42", 0, false},
+		{"main", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			got, ok := ParseBranchName(tt.branch)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ParseBranchName(%q) = %d, %v, want %d, %v", tt.branch, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
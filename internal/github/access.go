@@ -0,0 +1,43 @@
+package github
+
+import (
+	"fmt"
+)
+
+// GetRepoPermission returns a user's role on a repository: "admin",
+// "maintain", "write", "triage", "read" or "none". Custom roles are reported
+// as the base permission they extend.
+func (c *Client) GetRepoPermission(repo, login string) (string, error) {
+	var result struct {
+		Permission string `json:"permission"`
+		RoleName   string `json:"role_name"`
+	}
+	err := c.RunJSON(&result, "api", fmt.Sprintf("repos/%s/collaborators/%s/permission", repo, login))
+	if err != nil {
+		if isNotFound(err) {
+			return "none", nil
+		}
+		return "", err
+	}
+
+	switch result.RoleName {
+	case "admin", "maintain", "write", "triage", "read":
+		return result.RoleName, nil
+	}
+	return result.Permission, nil
+}
+
+// IsTeamMember checks if a user is an active member of an organization team
+func (c *Client) IsTeamMember(org, team, login string) (bool, error) {
+	output, err := c.Run(
+		"api", fmt.Sprintf("orgs/%s/teams/%s/memberships/%s", org, team, login),
+		"-q", ".state",
+	)
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return output == "active", nil
+}
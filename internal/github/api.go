@@ -0,0 +1,162 @@
+package github
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+)
+
+// API is the set of GitHub operations dev-swarm performs. Client runs them
+// through the gh CLI and HTTPClient through the REST API.
+type API interface {
+	IsAuthenticated() bool
+	GetAuthenticatedUser() (string, error)
+	RepoExists(repo string) bool
+
+	GetRepoPermission(repo, login string) (string, error)
+	IsTeamMember(org, team, login string) (bool, error)
+
+	GetBranchHead(repo, branch string) (string, error)
+	CommitsAhead(repo, base, head string) (int, error)
+	DependencyResolved(dep Dependency) (bool, error)
+
+	ListIssuesWithLabel(repo, label string) ([]Issue, error)
+	ListIssuesWithLabels(repo string, labels []string) ([]Issue, error)
+	GetIssue(repo string, number int) (*Issue, error)
+	GetIssueComments(repo string, number int) ([]Comment, error)
+	UpdateIssueLabels(repo string, number int, removeLabels, addLabels []string) error
+	AddIssueComment(repo string, number int, body string) error
+	CloseIssue(repo string, number int) error
+
+	ListLabels(repo string) ([]LabelInfo, error)
+	CreateLabel(repo, name, color, description string) error
+	LabelExists(repo, name string) (bool, error)
+	DeleteLabel(repo, name string) error
+	SyncLabels(repo string, labels []LabelInfo) error
+
+	GetPRForBranch(repo, branch string) (*PullRequest, error)
+	GetPR(repo string, number int) (*PullRequest, error)
+	GetPRMergeStatus(repo string, number int) (*PullRequest, error)
+	CreatePR(repo, title, body, head, base string) (*PullRequest, error)
+	MergePR(repo string, number int, strategy string, deleteRemoteBranch bool) error
+	GetPRReviews(repo string, number int) ([]PRReview, error)
+	GetPRComments(repo string, number int) ([]PRComment, error)
+	GetMergedPRsSince(repo string, since time.Time) ([]PullRequest, error)
+	ListPRs(repo string) ([]PullRequest, error)
+	AddPRComment(repo string, number int, body string) error
+
+	GetPRChecks(repo string, number int) ([]PRCheck, error)
+	PRChecksPassing(repo string, number int) (bool, error)
+	PRChecksRunning(repo string, number int) (bool, error)
+	PRChecksFailed(repo string, number int) (bool, error)
+	GetLatestWorkflowRun(repo, branch string) (*WorkflowRun, error)
+	GetWorkflowRunLogs(repo string, runID int) (string, error)
+	RerunFailedJobs(repo string, runID int) error
+
+	FetchRepoSnapshot(repo string, labels []string) (*RepoSnapshot, error)
+
+	RateLimit() RateLimit
+	RateLimits() map[string]RateLimit
+}
+
+var (
+	_ API = (*Client)(nil)
+	_ API = (*HTTPClient)(nil)
+)
+
+// New creates the client for the configured backend
+func New(cfg config.GitHubConfig) (API, error) {
+	switch cfg.BackendName() {
+	case config.GitHubBackendAPI:
+		token, err := ResolveToken(cfg.Token)
+		if err != nil {
+			return nil, err
+		}
+		client := NewHTTPClient(cfg.APIURL, token)
+		client.SetPageSize(cfg.PerPage())
+		return client, nil
+	case config.GitHubBackendGH:
+		client := NewClient()
+		client.SetPageSize(cfg.PerPage())
+		return client, nil
+	default:
+		return nil, fmt.Errorf("unknown github backend %q", cfg.Backend)
+	}
+}
+
+// listIssuesWithLabels merges the open issues of each label, dropping duplicates
+func listIssuesWithLabels(api API, repo string, labels []string) ([]Issue, error) {
+	var allIssues []Issue
+	seen := make(map[int]bool)
+
+	for _, label := range labels {
+		issues, err := api.ListIssuesWithLabel(repo, label)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, issue := range issues {
+			if !seen[issue.Number] {
+				seen[issue.Number] = true
+				allIssues = append(allIssues, issue)
+			}
+		}
+	}
+
+	return allIssues, nil
+}
+
+// checksPassing returns true if all checks have passed
+func checksPassing(checks []PRCheck) bool {
+	for _, check := range checks {
+		if check.Status != "completed" {
+			return false // Still running
+		}
+		if check.Conclusion != "success" && check.Conclusion != "skipped" && check.Conclusion != "neutral" {
+			return false // Failed
+		}
+	}
+	return true // Also when no checks are configured
+}
+
+// checksRunning returns true if any checks are still running
+func checksRunning(checks []PRCheck) bool {
+	for _, check := range checks {
+		if check.Status != "completed" {
+			return true
+		}
+	}
+	return false
+}
+
+// checksFailed returns true if any checks have failed
+func checksFailed(checks []PRCheck) bool {
+	for _, check := range checks {
+		if check.Status == "completed" && check.Conclusion == "failure" {
+			return true
+		}
+	}
+	return false
+}
+
+// labelExists checks if a label is in a list of labels
+func labelExists(labels []LabelInfo, name string) bool {
+	for _, l := range labels {
+		if l.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// syncLabels creates or updates each label
+func syncLabels(api API, repo string, labels []LabelInfo) error {
+	for _, label := range labels {
+		err := api.CreateLabel(repo, label.Name, label.Color, label.Description)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
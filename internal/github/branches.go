@@ -0,0 +1,51 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// GetBranchHead returns the commit SHA at the tip of a remote branch, or ""
+// if the branch does not exist
+func (c *Client) GetBranchHead(repo, branch string) (string, error) {
+	output, err := c.Run(
+		"api", fmt.Sprintf("repos/%s/git/ref/heads/%s", repo, branch),
+		"-q", ".object.sha",
+	)
+	if err != nil {
+		if isNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return output, nil
+}
+
+// CommitsAhead returns how many commits head has that base does not
+func (c *Client) CommitsAhead(repo, base, head string) (int, error) {
+	output, err := c.Run(
+		"api", fmt.Sprintf("repos/%s/compare/%s...%s", repo, base, head),
+		"-q", ".ahead_by",
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	ahead, err := strconv.Atoi(output)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse ahead_by %q: %w", output, err)
+	}
+	return ahead, nil
+}
+
+// isNotFound checks if a gh api or REST API error is a 404
+func isNotFound(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusNotFound
+	}
+	return strings.Contains(err.Error(), "HTTP 404") || strings.Contains(err.Error(), "Not Found")
+}
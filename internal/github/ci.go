@@ -25,21 +25,7 @@ func (c *Client) PRChecksPassing(repo string, number int) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-
-	if len(checks) == 0 {
-		return true, nil // No checks configured
-	}
-
-	for _, check := range checks {
-		if check.Status != "completed" {
-			return false, nil // Still running
-		}
-		if check.Conclusion != "success" && check.Conclusion != "skipped" && check.Conclusion != "neutral" {
-			return false, nil // Failed
-		}
-	}
-
-	return true, nil
+	return checksPassing(checks), nil
 }
 
 // PRChecksRunning returns true if any checks are still running
@@ -48,14 +34,7 @@ func (c *Client) PRChecksRunning(repo string, number int) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-
-	for _, check := range checks {
-		if check.Status != "completed" {
-			return true, nil
-		}
-	}
-
-	return false, nil
+	return checksRunning(checks), nil
 }
 
 // PRChecksFailed returns true if any checks have failed
@@ -64,14 +43,7 @@ func (c *Client) PRChecksFailed(repo string, number int) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-
-	for _, check := range checks {
-		if check.Status == "completed" && check.Conclusion == "failure" {
-			return true, nil
-		}
-	}
-
-	return false, nil
+	return checksFailed(checks), nil
 }
 
 // GetLatestWorkflowRun returns the latest workflow run for a branch
@@ -103,3 +75,13 @@ func (c *Client) GetWorkflowRunLogs(repo string, runID int) (string, error) {
 	)
 	return output, err
 }
+
+// RerunFailedJobs re-runs the failed jobs of a workflow run
+func (c *Client) RerunFailedJobs(repo string, runID int) error {
+	_, err := c.Run(
+		"run", "rerun", fmt.Sprintf("%d", runID),
+		"--repo", repo,
+		"--failed",
+	)
+	return err
+}
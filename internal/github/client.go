@@ -1,24 +1,53 @@
 package github
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"net/url"
 	"os/exec"
 	"strings"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
 )
 
 // Client wraps the gh CLI for GitHub operations
-type Client struct{}
+type Client struct {
+	pageSize int
+	limits   *rateLimiter
+}
 
 // NewClient creates a new GitHub client
 func NewClient() *Client {
-	return &Client{}
+	return &Client{pageSize: config.DefaultGitHubPageSize, limits: newRateLimiter()}
+}
+
+// SetPageSize sets the number of items list requests fetch per page
+func (c *Client) SetPageSize(n int) {
+	c.pageSize = n
 }
 
 // Run executes a gh command and returns stdout
 func (c *Client) Run(args ...string) (string, error) {
+	return c.RunInput(nil, args...)
+}
+
+// RunInput executes a gh command with input on stdin and returns stdout.
+// While a rate limit holds back the quota the command spends, it does not run.
+func (c *Client) RunInput(input []byte, args ...string) (string, error) {
+	resource := commandResource(args)
+	if err := c.limits.check(resource); err != nil {
+		return "", err
+	}
+
 	cmd := exec.Command("gh", args...)
+	if input != nil {
+		cmd.Stdin = bytes.NewReader(input)
+	}
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -26,13 +55,38 @@ func (c *Client) Run(args ...string) (string, error) {
 	err := cmd.Run()
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
+			if err := c.limits.refused(resource, 0, nil, stderr.String()); err != nil {
+				return "", err
+			}
 			return "", fmt.Errorf("gh command failed: %s (exit code %d)", stderr.String(), exitErr.ExitCode())
 		}
 		return "", err
 	}
+	c.limits.succeeded()
 	return strings.TrimSpace(stdout.String()), nil
 }
 
+// commandResource returns the quota a gh command spends: gh api spends the
+// one of its path, and the issue, pr and repo commands use GraphQL
+func commandResource(args []string) string {
+	if len(args) == 0 {
+		return "core"
+	}
+	switch args[0] {
+	case "api":
+		for _, arg := range args[1:] {
+			if !strings.HasPrefix(arg, "-") {
+				return resourceOf(arg)
+			}
+		}
+		return "core"
+	case "issue", "pr", "repo":
+		return "graphql"
+	default:
+		return "core"
+	}
+}
+
 // RunJSON executes a gh command and parses JSON output
 func (c *Client) RunJSON(result interface{}, args ...string) error {
 	output, err := c.Run(args...)
@@ -45,6 +99,83 @@ func (c *Client) RunJSON(result interface{}, args ...string) error {
 	return json.Unmarshal([]byte(output), result)
 }
 
+// page fetches a page of a list with gh api, which reports the link to the
+// next page among the response headers
+func (c *Client) page(target string) ([]byte, string, error) {
+	output, err := c.Run("api", "--include", apiPath(target))
+	if err != nil {
+		return nil, "", err
+	}
+	header, body, err := splitIncluded(output)
+	if err != nil {
+		return nil, "", err
+	}
+	c.limits.observe(header)
+	return body, nextPageURL(header.Get("Link")), nil
+}
+
+// perPage returns the number of items to request per page
+func (c *Client) perPage() int {
+	return c.pageSize
+}
+
+// graphql sends a GraphQL request with gh api and returns the response body
+func (c *Client) graphql(body []byte) ([]byte, error) {
+	output, err := c.RunInput(body, "api", "graphql", "--include", "--input", "-")
+	if err != nil {
+		return nil, err
+	}
+	header, data, err := splitIncluded(output)
+	if err != nil {
+		return nil, err
+	}
+	c.limits.observe(header)
+	return data, nil
+}
+
+// RateLimit returns the quota closest to running out, as the last gh api
+// responses reported it. Other gh commands do not report it.
+func (c *Client) RateLimit() RateLimit {
+	return c.limits.status()
+}
+
+// RateLimits returns the quota of every resource, by resource, as the last
+// gh api responses reported them
+func (c *Client) RateLimits() map[string]RateLimit {
+	return c.limits.all()
+}
+
+// apiPath returns the path of an API URL relative to the API root, as gh api
+// takes it. Paths are returned unchanged.
+func apiPath(target string) string {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return target
+	}
+	path := strings.TrimPrefix(strings.TrimPrefix(u.Path, "/api/v3"), "/")
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path
+}
+
+// splitIncluded splits gh api --include output into the response headers and body
+func splitIncluded(output string) (http.Header, []byte, error) {
+	r := textproto.NewReader(bufio.NewReader(strings.NewReader(output)))
+	if _, err := r.ReadLine(); err != nil { // The status line
+		return nil, nil, fmt.Errorf("failed to read gh api response: %w", err)
+	}
+	header, err := r.ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return nil, nil, fmt.Errorf("failed to read gh api response headers: %w", err)
+	}
+	body, err := io.ReadAll(r.R)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read gh api response: %w", err)
+	}
+	return http.Header(header), body, nil
+}
+
 // IsInstalled checks if gh CLI is installed
 func (c *Client) IsInstalled() bool {
 	_, err := exec.LookPath("gh")
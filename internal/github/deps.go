@@ -0,0 +1,136 @@
+package github
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dependency is an issue or PR another issue waits on
+type Dependency struct {
+	Repo   string // "owner/repo" format
+	Number int
+}
+
+// String formats the dependency relative to the repo it was found in
+func (d Dependency) String() string {
+	return fmt.Sprintf("%s#%d", d.Repo, d.Number)
+}
+
+// Ref formats the dependency as GitHub would link it from an issue in repo
+func (d Dependency) Ref(repo string) string {
+	if d.Repo == repo {
+		return fmt.Sprintf("#%d", d.Number)
+	}
+	return d.String()
+}
+
+var (
+	// depKeyword introduces a list of dependencies
+	depKeyword = regexp.MustCompile(`(?i)\b(?:depends\s+on|blocked\s+by)\b`)
+
+	// depRef matches "#12", "owner/repo#7" or an issue or PR URL at the start of the text
+	depRef = regexp.MustCompile(`^(?:https://github\.com/([\w.-]+/[\w.-]+)/(?:issues|pull)/(\d+)|([\w.-]+/[\w.-]+)?#(\d+))\b`)
+
+	// depSeparator matches the text between two dependencies of a list
+	depSeparator = regexp.MustCompile(`^(?:[\s:,&]|\band\b)*`)
+
+	// openTask matches an unchecked task list item
+	openTask = regexp.MustCompile(`^\s*[-*+]\s+\[ \]\s+`)
+)
+
+// ParseDependencies finds dependency references in issue text: "depends on #12",
+// "blocked by owner/repo#7, #9" and unchecked task list items that start with
+// a reference ("- [ ] #12"). References without a repo belong to repo.
+// Fenced code blocks are ignored.
+func ParseDependencies(repo, text string) []Dependency {
+	var deps []Dependency
+	seen := make(map[Dependency]bool)
+	add := func(dep Dependency) {
+		if !seen[dep] {
+			seen[dep] = true
+			deps = append(deps, dep)
+		}
+	}
+
+	inFence := false
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		if loc := openTask.FindStringIndex(line); loc != nil {
+			if dep, _, ok := matchRef(repo, line[loc[1]:]); ok {
+				add(dep)
+			}
+		}
+
+		for _, loc := range depKeyword.FindAllStringIndex(line, -1) {
+			for _, dep := range parseRefList(repo, line[loc[1]:]) {
+				add(dep)
+			}
+		}
+	}
+	return deps
+}
+
+// parseRefList reads the references that directly follow a dependency keyword
+func parseRefList(repo, text string) []Dependency {
+	var deps []Dependency
+	for {
+		text = text[len(depSeparator.FindString(text)):]
+		dep, n, ok := matchRef(repo, text)
+		if !ok {
+			return deps
+		}
+		deps = append(deps, dep)
+		text = text[n:]
+	}
+}
+
+// matchRef parses a reference at the start of text and returns its length
+func matchRef(repo, text string) (Dependency, int, bool) {
+	m := depRef.FindStringSubmatch(text)
+	if m == nil {
+		return Dependency{}, 0, false
+	}
+
+	depRepo, number := m[1], m[2]
+	if number == "" {
+		depRepo, number = m[3], m[4]
+	}
+	if depRepo == "" {
+		depRepo = repo
+	}
+
+	n, err := strconv.Atoi(number)
+	if err != nil || n <= 0 {
+		return Dependency{}, 0, false
+	}
+	return Dependency{Repo: depRepo, Number: n}, len(m[0]), true
+}
+
+// DependencyResolved checks if a dependency is done: a closed issue or a merged PR
+func (c *Client) DependencyResolved(dep Dependency) (bool, error) {
+	var result struct {
+		State       string `json:"state"`
+		PullRequest *struct {
+			MergedAt *time.Time `json:"merged_at"`
+		} `json:"pull_request"`
+	}
+	err := c.RunJSON(&result, "api", fmt.Sprintf("repos/%s/issues/%d", dep.Repo, dep.Number))
+	if err != nil {
+		return false, err
+	}
+
+	if result.PullRequest != nil {
+		return result.PullRequest.MergedAt != nil, nil
+	}
+	return result.State == "closed", nil
+}
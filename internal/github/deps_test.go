@@ -0,0 +1,82 @@
+package github
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDependencies(t *testing.T) {
+	const repo = "owner/app"
+
+	tests := []struct {
+		name string
+		text string
+		want []Dependency
+	}{
+		{
+			name: "depends on",
+			text: "This depends on #12.",
+			want: []Dependency{{repo, 12}},
+		},
+		{
+			name: "blocked by other repo",
+			text: "Blocked by owner/lib#7",
+			want: []Dependency{{"owner/lib", 7}},
+		},
+		{
+			name: "list of references",
+			text: "Depends on: #3, #4 and owner/lib#5 & #6",
+			want: []Dependency{{repo, 3}, {repo, 4}, {"owner/lib", 5}, {repo, 6}},
+		},
+		{
+			name: "issue and PR URLs",
+			text: "blocked by https://github.com/owner/lib/issues/8 and https://github.com/owner/app/pull/9",
+			want: []Dependency{{"owner/lib", 8}, {repo, 9}},
+		},
+		{
+			name: "references after other words are not dependencies",
+			text: "Depends on the API. See #10 for context.",
+			want: nil,
+		},
+		{
+			name: "open task list items",
+			text: "- [ ] #20\n- [x] #21\n* [ ] owner/lib#22 migrate schema\n- [ ] write docs for #23",
+			want: []Dependency{{repo, 20}, {"owner/lib", 22}},
+		},
+		{
+			name: "code blocks are ignored",
+			text: "```\ndepends on #30\n```\ndepends on #31",
+			want: []Dependency{{repo, 31}},
+		},
+		{
+			name: "duplicates are removed",
+			text: "depends on #40\n- [ ] #40\nblocked by owner/app#40",
+			want: []Dependency{{repo, 40}},
+		},
+		{
+			name: "no dependencies",
+			text: "Add dark mode (#42)",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseDependencies(repo, tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDependencies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDependencyRef(t *testing.T) {
+	dep := Dependency{Repo: "owner/lib", Number: 7}
+
+	if got := dep.Ref("owner/lib"); got != "#7" {
+		t.Errorf("Ref(same repo) = %q, want %q", got, "#7")
+	}
+	if got := dep.Ref("owner/app"); got != "owner/lib#7" {
+		t.Errorf("Ref(other repo) = %q, want %q", got, "owner/lib#7")
+	}
+}
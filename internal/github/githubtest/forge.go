@@ -0,0 +1,1213 @@
+// Package githubtest provides an in-memory GitHub for tests
+package githubtest
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/github"
+)
+
+// ErrNotFound is returned for issues, PRs, labels and runs that do not exist
+var ErrNotFound = errors.New("not found")
+
+// Forge is an in-memory GitHub that implements github.API. It models
+// issues, labels, comments, branches, PRs, reviews, checks and workflow runs,
+// and keeps its own clock so tests can script time: everything it records is
+// stamped with Now, which only moves when the test advances it.
+type Forge struct {
+	mu       sync.Mutex
+	now      time.Time
+	user     string
+	repos    map[string]*repo
+	teams    map[string]bool // By "org/team|login"
+	calls    map[string]int
+	failures map[string]error
+	limits   map[string]github.RateLimit // By resource
+}
+
+var _ github.API = (*Forge)(nil)
+
+// repo is the state of one repository
+type repo struct {
+	labels      map[string]github.LabelInfo
+	issues      map[int]*github.Issue
+	prs         map[int]*pullRequest
+	branches    map[string]*branch
+	permissions map[string]string // By lowercased login
+	runs        []*workflowRun
+	nextNumber  int // Issues and PRs share numbers, as on GitHub
+	nextID      int // Comments, reviews and runs
+}
+
+// pullRequest is a PR with its reviews, conversation comments and checks
+type pullRequest struct {
+	github.PullRequest
+	reviews  []github.PRReview
+	comments []github.PRComment
+	checks   []github.PRCheck
+}
+
+// branch is a remote branch
+type branch struct {
+	head  string
+	ahead int // Commits not on the default branch
+}
+
+// workflowRun is a GitHub Actions run on a branch
+type workflowRun struct {
+	github.WorkflowRun
+	branch string
+	logs   string
+	reruns int
+}
+
+// NewForge creates an empty forge authenticated as "dev-swarm" whose clock
+// starts at the current time
+func NewForge() *Forge {
+	return &Forge{
+		now:      time.Now().UTC().Truncate(time.Second),
+		user:     "dev-swarm",
+		repos:    make(map[string]*repo),
+		teams:    make(map[string]bool),
+		calls:    make(map[string]int),
+		failures: make(map[string]error),
+		limits:   make(map[string]github.RateLimit),
+	}
+}
+
+// Now returns the forge's current time
+func (f *Forge) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Advance moves the clock forward
+func (f *Forge) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// SetTime sets the clock
+func (f *Forge) SetTime(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = t
+}
+
+// SetUser sets the login the forge is authenticated as. Comments posted
+// through the API are authored by it.
+func (f *Forge) SetUser(login string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.user = login
+}
+
+// Fail makes every later call of an API method return err; a nil err makes
+// the method succeed again. Methods without an error report false.
+func (f *Forge) Fail(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.failures, method)
+		return
+	}
+	f.failures[method] = err
+}
+
+// SetRateLimit sets the quota of rl.Resource, "core" if empty. Each call
+// spends one request of the quota of its resource: "graphql" for
+// FetchRepoSnapshot, "core" for the rest. Until its HeldUntil, every call
+// fails with a rate limit error, as the clients hold requests back.
+func (f *Forge) SetRateLimit(rl github.RateLimit) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if rl.Resource == "" {
+		rl.Resource = "core"
+	}
+	f.limits[rl.Resource] = rl
+}
+
+// RateLimit returns the quota set with SetRateLimit that is closest to
+// running out, with the latest HeldUntil still to come
+func (f *Forge) RateLimit() github.RateLimit {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var tightest github.RateLimit
+	for _, rl := range f.limits {
+		if !tightest.Known() || rl.Remaining*tightest.Limit < tightest.Remaining*rl.Limit {
+			tightest = rl
+		}
+	}
+	tightest.HeldUntil = f.heldUntil()
+	return tightest
+}
+
+// RateLimits returns the quotas set with SetRateLimit, by resource
+func (f *Forge) RateLimits() map[string]github.RateLimit {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	limits := make(map[string]github.RateLimit, len(f.limits))
+	for resource, rl := range f.limits {
+		rl.HeldUntil = time.Time{}
+		limits[resource] = rl
+	}
+	return limits
+}
+
+// heldUntil returns when calls are no longer held back for a rate limit, or
+// zero if they are not. Callers hold the lock.
+func (f *Forge) heldUntil() time.Time {
+	var until time.Time
+	for _, rl := range f.limits {
+		if f.now.Before(rl.HeldUntil) && rl.HeldUntil.After(until) {
+			until = rl.HeldUntil
+		}
+	}
+	return until
+}
+
+// Calls returns how many times an API method was called
+func (f *Forge) Calls(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+// call records a call of an API method and returns its injected failure.
+// Callers hold the lock.
+func (f *Forge) call(method string) error {
+	f.calls[method]++
+	if until := f.heldUntil(); !until.IsZero() {
+		return &github.RateLimitError{Until: until}
+	}
+	resource := "core"
+	if method == "FetchRepoSnapshot" {
+		resource = "graphql"
+	}
+	if rl, ok := f.limits[resource]; ok && rl.Remaining > 0 {
+		rl.Remaining--
+		f.limits[resource] = rl
+	}
+	return f.failures[method]
+}
+
+// repo returns a repository, creating it on first use. Callers hold the lock.
+func (f *Forge) repo(name string) *repo {
+	r, ok := f.repos[name]
+	if !ok {
+		r = &repo{
+			labels:      make(map[string]github.LabelInfo),
+			issues:      make(map[int]*github.Issue),
+			prs:         make(map[int]*pullRequest),
+			branches:    make(map[string]*branch),
+			permissions: make(map[string]string),
+			nextNumber:  1,
+			nextID:      1,
+		}
+		f.repos[name] = r
+	}
+	return r
+}
+
+// issue returns an issue of a repository. Callers hold the lock.
+func (f *Forge) issue(repoName string, number int) (*github.Issue, error) {
+	issue, ok := f.repo(repoName).issues[number]
+	if !ok {
+		return nil, fmt.Errorf("issue %s#%d: %w", repoName, number, ErrNotFound)
+	}
+	return issue, nil
+}
+
+// pr returns a PR of a repository. Callers hold the lock.
+func (f *Forge) pr(repoName string, number int) (*pullRequest, error) {
+	pr, ok := f.repo(repoName).prs[number]
+	if !ok {
+		return nil, fmt.Errorf("pull request %s#%d: %w", repoName, number, ErrNotFound)
+	}
+	return pr, nil
+}
+
+// openPRForBranch returns the open PR whose head is branch, or nil. Callers
+// hold the lock.
+func (r *repo) openPRForBranch(name string) *pullRequest {
+	for _, pr := range r.prs {
+		if pr.HeadRef == name && pr.State == "OPEN" {
+			return pr
+		}
+	}
+	return nil
+}
+
+// addComment appends a comment to an issue. Callers hold the lock.
+func (f *Forge) addComment(repoName string, issue *github.Issue, author, body string) {
+	r := f.repo(repoName)
+	issue.Comments = append(issue.Comments, github.Comment{
+		ID:        r.nextID,
+		Author:    github.Author{Login: author},
+		Body:      body,
+		CreatedAt: f.now,
+	})
+	r.nextID++
+	issue.UpdatedAt = f.now
+}
+
+// setLabels removes and adds labels on an issue, creating labels the
+// repository does not have yet as GitHub does. Callers hold the lock.
+func (f *Forge) setLabels(repoName string, issue *github.Issue, remove, add []string) {
+	r := f.repo(repoName)
+	labels := issue.Labels[:0]
+	for _, l := range issue.Labels {
+		if !contains(remove, l.Name) {
+			labels = append(labels, l)
+		}
+	}
+	for _, name := range add {
+		if contains(labelNames(labels), name) {
+			continue
+		}
+		info, ok := r.labels[name]
+		if !ok {
+			info = github.LabelInfo{Name: name, Color: "ededed"}
+			r.labels[name] = info
+		}
+		labels = append(labels, github.Label{Name: info.Name, Color: info.Color, Description: info.Description})
+	}
+	issue.Labels = labels
+	issue.UpdatedAt = f.now
+}
+
+// contains checks if a list holds a string
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// labelNames returns the names of labels
+func labelNames(labels []github.Label) []string {
+	names := make([]string, 0, len(labels))
+	for _, l := range labels {
+		names = append(names, l.Name)
+	}
+	return names
+}
+
+// copyIssue returns a copy of an issue that shares no slices with it,
+// without comments unless withComments is set
+func copyIssue(issue *github.Issue, withComments bool) github.Issue {
+	c := *issue
+	c.Labels = append([]github.Label(nil), issue.Labels...)
+	c.Comments = nil
+	if withComments {
+		c.Comments = append([]github.Comment{}, issue.Comments...)
+	}
+	return c
+}
+
+// CreateIssue opens an issue with labels and returns its number
+func (f *Forge) CreateIssue(repoName, title, body string, labels ...string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r := f.repo(repoName)
+	number := r.nextNumber
+	r.nextNumber++
+
+	issue := &github.Issue{
+		Number:    number,
+		Title:     title,
+		Body:      body,
+		State:     "OPEN",
+		URL:       fmt.Sprintf("https://github.com/%s/issues/%d", repoName, number),
+		CreatedAt: f.now,
+	}
+	f.setLabels(repoName, issue, nil, labels)
+	r.issues[number] = issue
+	return number
+}
+
+// Comment posts a comment on an issue as author
+func (f *Forge) Comment(repoName string, number int, author, body string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	issue, err := f.issue(repoName, number)
+	if err != nil {
+		panic(err)
+	}
+	f.addComment(repoName, issue, author, body)
+}
+
+// SetLabels replaces the labels of an issue, as a user or session editing them
+func (f *Forge) SetLabels(repoName string, number int, labels ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	issue, err := f.issue(repoName, number)
+	if err != nil {
+		panic(err)
+	}
+	f.setLabels(repoName, issue, labelNames(issue.Labels), labels)
+}
+
+// Labels returns the label names of an issue
+func (f *Forge) Labels(repoName string, number int) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	issue, err := f.issue(repoName, number)
+	if err != nil {
+		panic(err)
+	}
+	return labelNames(issue.Labels)
+}
+
+// Issue returns a copy of an issue with its comments
+func (f *Forge) Issue(repoName string, number int) github.Issue {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	issue, err := f.issue(repoName, number)
+	if err != nil {
+		panic(err)
+	}
+	return copyIssue(issue, true)
+}
+
+// Close closes an issue
+func (f *Forge) Close(repoName string, number int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	issue, err := f.issue(repoName, number)
+	if err != nil {
+		panic(err)
+	}
+	issue.State = "CLOSED"
+	issue.UpdatedAt = f.now
+}
+
+// PushBranch pushes a commit to a branch, creating it, and returns the new
+// head SHA. An open PR for the branch moves to the new head.
+func (f *Forge) PushBranch(repoName, name string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pushBranch(repoName, name)
+}
+
+// pushBranch pushes a commit to a branch. Callers hold the lock.
+func (f *Forge) pushBranch(repoName, name string) string {
+	r := f.repo(repoName)
+	b, ok := r.branches[name]
+	if !ok {
+		b = &branch{}
+		r.branches[name] = b
+	}
+	b.ahead++
+	b.head = fmt.Sprintf("%040x", r.nextID)
+	r.nextID++
+
+	if pr := r.openPRForBranch(name); pr != nil {
+		pr.HeadOid = b.head
+		pr.checks = nil
+	}
+	return b.head
+}
+
+// OpenPR opens a PR from a branch into main, pushing a commit first if the
+// branch does not exist, and returns its number
+func (f *Forge) OpenPR(repoName, name, title string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.repo(repoName).branches[name]; !ok {
+		f.pushBranch(repoName, name)
+	}
+	pr, err := f.createPR(repoName, title, "", name, "main")
+	if err != nil {
+		panic(err)
+	}
+	return pr.Number
+}
+
+// Review submits a review on a PR's current head as author
+func (f *Forge) Review(repoName string, number int, author, state, body string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pr, err := f.pr(repoName, number)
+	if err != nil {
+		panic(err)
+	}
+	r := f.repo(repoName)
+	review := github.PRReview{
+		ID:                fmt.Sprintf("PRR_%d", r.nextID),
+		Author:            github.Author{Login: author},
+		AuthorAssociation: "COLLABORATOR",
+		Body:              body,
+		State:             state,
+		CreatedAt:         f.now,
+	}
+	review.Commit.Oid = pr.HeadOid
+	r.nextID++
+	pr.reviews = append(pr.reviews, review)
+}
+
+// PRComment posts a conversation comment on a PR as author
+func (f *Forge) PRComment(repoName string, number int, author, body string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pr, err := f.pr(repoName, number)
+	if err != nil {
+		panic(err)
+	}
+	f.addPRComment(repoName, pr, author, body)
+}
+
+// addPRComment appends a conversation comment to a PR. Callers hold the lock.
+func (f *Forge) addPRComment(repoName string, pr *pullRequest, author, body string) {
+	r := f.repo(repoName)
+	pr.comments = append(pr.comments, github.PRComment{
+		ID:        r.nextID,
+		Author:    github.Author{Login: author},
+		Body:      body,
+		CreatedAt: f.now,
+	})
+	r.nextID++
+}
+
+// SetChecks replaces the checks on a PR's head
+func (f *Forge) SetChecks(repoName string, number int, checks ...github.PRCheck) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pr, err := f.pr(repoName, number)
+	if err != nil {
+		panic(err)
+	}
+	pr.checks = append([]github.PRCheck(nil), checks...)
+}
+
+// SetMergeStatus sets what GitHub reports about merging a PR, such as
+// "CONFLICTING", "DIRTY". PRs open as "MERGEABLE", "CLEAN".
+func (f *Forge) SetMergeStatus(repoName string, number int, mergeable, mergeState string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pr, err := f.pr(repoName, number)
+	if err != nil {
+		panic(err)
+	}
+	pr.Mergeable = mergeable
+	pr.MergeStateStatus = mergeState
+}
+
+// PRState returns a copy of a PR
+func (f *Forge) PRState(repoName string, number int) github.PullRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pr, err := f.pr(repoName, number)
+	if err != nil {
+		panic(err)
+	}
+	return pr.PullRequest
+}
+
+// SetPermission sets a user's role on a repository; users without one have "none"
+func (f *Forge) SetPermission(repoName, login, permission string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.repo(repoName).permissions[strings.ToLower(login)] = permission
+}
+
+// SetTeamMember adds a user to an organization team, or removes them
+func (f *Forge) SetTeamMember(org, team, login string, member bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.teams[org+"/"+team+"|"+strings.ToLower(login)] = member
+}
+
+// AddWorkflowRun records a completed workflow run on a branch and returns
+// its ID. logs is what GetWorkflowRunLogs reports.
+func (f *Forge) AddWorkflowRun(repoName, branchName, name, conclusion, logs string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r := f.repo(repoName)
+	run := &workflowRun{
+		WorkflowRun: github.WorkflowRun{
+			ID:         r.nextID,
+			Name:       name,
+			Status:     "completed",
+			Conclusion: conclusion,
+			URL:        fmt.Sprintf("https://github.com/%s/actions/runs/%d", repoName, r.nextID),
+			CreatedAt:  f.now,
+		},
+		branch: branchName,
+		logs:   logs,
+	}
+	r.nextID++
+	r.runs = append(r.runs, run)
+	return run.ID
+}
+
+// Reruns returns how many times the failed jobs of a run were re-run
+func (f *Forge) Reruns(repoName string, runID int) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, run := range f.repo(repoName).runs {
+		if run.ID == runID {
+			return run.reruns
+		}
+	}
+	return 0
+}
+
+// IsAuthenticated reports that the forge is logged in
+func (f *Forge) IsAuthenticated() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.call("IsAuthenticated") == nil
+}
+
+// GetAuthenticatedUser returns the login set with SetUser
+func (f *Forge) GetAuthenticatedUser() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetAuthenticatedUser"); err != nil {
+		return "", err
+	}
+	return f.user, nil
+}
+
+// RepoExists checks if anything was recorded in a repository
+func (f *Forge) RepoExists(repoName string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.repos[repoName]
+	return f.call("RepoExists") == nil && ok
+}
+
+// GetRepoPermission returns a user's role set with SetPermission
+func (f *Forge) GetRepoPermission(repoName, login string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetRepoPermission"); err != nil {
+		return "", err
+	}
+	if permission, ok := f.repo(repoName).permissions[strings.ToLower(login)]; ok {
+		return permission, nil
+	}
+	return "none", nil
+}
+
+// IsTeamMember checks team membership set with SetTeamMember
+func (f *Forge) IsTeamMember(org, team, login string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("IsTeamMember"); err != nil {
+		return false, err
+	}
+	return f.teams[org+"/"+team+"|"+strings.ToLower(login)], nil
+}
+
+// GetBranchHead returns the head SHA of a branch, or "" if it does not exist
+func (f *Forge) GetBranchHead(repoName, name string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetBranchHead"); err != nil {
+		return "", err
+	}
+	if b, ok := f.repo(repoName).branches[name]; ok {
+		return b.head, nil
+	}
+	return "", nil
+}
+
+// CommitsAhead returns how many commits were pushed to head. Branches are
+// never behind base.
+func (f *Forge) CommitsAhead(repoName, base, head string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("CommitsAhead"); err != nil {
+		return 0, err
+	}
+	b, ok := f.repo(repoName).branches[head]
+	if !ok {
+		return 0, fmt.Errorf("branch %s of %s: %w", head, repoName, ErrNotFound)
+	}
+	return b.ahead, nil
+}
+
+// DependencyResolved checks if a dependency is a closed issue or a merged PR
+func (f *Forge) DependencyResolved(dep github.Dependency) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DependencyResolved"); err != nil {
+		return false, err
+	}
+	r := f.repo(dep.Repo)
+	if pr, ok := r.prs[dep.Number]; ok {
+		return pr.Merged, nil
+	}
+	issue, err := f.issue(dep.Repo, dep.Number)
+	if err != nil {
+		return false, err
+	}
+	return issue.State == "CLOSED", nil
+}
+
+// ListIssuesWithLabel returns the open issues with a label, newest first and
+// without comments as the list endpoints report them
+func (f *Forge) ListIssuesWithLabel(repoName, label string) ([]github.Issue, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ListIssuesWithLabel"); err != nil {
+		return nil, err
+	}
+	return f.listIssues(repoName, []string{label}), nil
+}
+
+// ListIssuesWithLabels returns the open issues with any of the labels
+func (f *Forge) ListIssuesWithLabels(repoName string, labels []string) ([]github.Issue, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ListIssuesWithLabels"); err != nil {
+		return nil, err
+	}
+	return f.listIssues(repoName, labels), nil
+}
+
+// listIssues returns the open issues with any of the labels, newest first.
+// Callers hold the lock.
+func (f *Forge) listIssues(repoName string, labels []string) []github.Issue {
+	var issues []github.Issue
+	for _, issue := range f.repo(repoName).issues {
+		if issue.State != "OPEN" {
+			continue
+		}
+		for _, label := range labels {
+			if issue.HasLabel(label) {
+				issues = append(issues, copyIssue(issue, false))
+				break
+			}
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Number > issues[j].Number })
+	return issues
+}
+
+// GetIssue returns an issue with its comments
+func (f *Forge) GetIssue(repoName string, number int) (*github.Issue, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetIssue"); err != nil {
+		return nil, err
+	}
+	issue, err := f.issue(repoName, number)
+	if err != nil {
+		return nil, err
+	}
+	c := copyIssue(issue, true)
+	return &c, nil
+}
+
+// GetIssueComments returns the comments of an issue
+func (f *Forge) GetIssueComments(repoName string, number int) ([]github.Comment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetIssueComments"); err != nil {
+		return nil, err
+	}
+	issue, err := f.issue(repoName, number)
+	if err != nil {
+		return nil, err
+	}
+	return append([]github.Comment{}, issue.Comments...), nil
+}
+
+// UpdateIssueLabels removes and adds labels on an issue
+func (f *Forge) UpdateIssueLabels(repoName string, number int, removeLabels, addLabels []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("UpdateIssueLabels"); err != nil {
+		return err
+	}
+	issue, err := f.issue(repoName, number)
+	if err != nil {
+		return err
+	}
+	f.setLabels(repoName, issue, removeLabels, addLabels)
+	return nil
+}
+
+// AddIssueComment comments on an issue as the authenticated user
+func (f *Forge) AddIssueComment(repoName string, number int, body string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("AddIssueComment"); err != nil {
+		return err
+	}
+	issue, err := f.issue(repoName, number)
+	if err != nil {
+		return err
+	}
+	f.addComment(repoName, issue, f.user, body)
+	return nil
+}
+
+// CloseIssue closes an issue
+func (f *Forge) CloseIssue(repoName string, number int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("CloseIssue"); err != nil {
+		return err
+	}
+	issue, err := f.issue(repoName, number)
+	if err != nil {
+		return err
+	}
+	issue.State = "CLOSED"
+	issue.UpdatedAt = f.now
+	return nil
+}
+
+// ListLabels returns the labels of a repository by name
+func (f *Forge) ListLabels(repoName string) ([]github.LabelInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ListLabels"); err != nil {
+		return nil, err
+	}
+	return f.listLabels(repoName), nil
+}
+
+// listLabels returns the labels of a repository by name. Callers hold the lock.
+func (f *Forge) listLabels(repoName string) []github.LabelInfo {
+	var labels []github.LabelInfo
+	for _, label := range f.repo(repoName).labels {
+		labels = append(labels, label)
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+	return labels
+}
+
+// CreateLabel creates or updates a label
+func (f *Forge) CreateLabel(repoName, name, color, description string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("CreateLabel"); err != nil {
+		return err
+	}
+	f.repo(repoName).labels[name] = github.LabelInfo{Name: name, Color: color, Description: description}
+	return nil
+}
+
+// LabelExists checks if a repository has a label
+func (f *Forge) LabelExists(repoName, name string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("LabelExists"); err != nil {
+		return false, err
+	}
+	_, ok := f.repo(repoName).labels[name]
+	return ok, nil
+}
+
+// DeleteLabel deletes a label and takes it off every issue
+func (f *Forge) DeleteLabel(repoName, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("DeleteLabel"); err != nil {
+		return err
+	}
+	r := f.repo(repoName)
+	if _, ok := r.labels[name]; !ok {
+		return fmt.Errorf("label %s of %s: %w", name, repoName, ErrNotFound)
+	}
+	delete(r.labels, name)
+	for _, issue := range r.issues {
+		if issue.HasLabel(name) {
+			f.setLabels(repoName, issue, []string{name}, nil)
+		}
+	}
+	return nil
+}
+
+// SyncLabels creates or updates each label
+func (f *Forge) SyncLabels(repoName string, labels []github.LabelInfo) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("SyncLabels"); err != nil {
+		return err
+	}
+	for _, label := range labels {
+		f.repo(repoName).labels[label.Name] = label
+	}
+	return nil
+}
+
+// GetPRForBranch returns the open PR for a branch, or nil if there is none
+func (f *Forge) GetPRForBranch(repoName, name string) (*github.PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetPRForBranch"); err != nil {
+		return nil, err
+	}
+	pr := f.repo(repoName).openPRForBranch(name)
+	if pr == nil {
+		return nil, nil
+	}
+	c := pr.PullRequest
+	return &c, nil
+}
+
+// GetPR returns a PR
+func (f *Forge) GetPR(repoName string, number int) (*github.PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetPR"); err != nil {
+		return nil, err
+	}
+	pr, err := f.pr(repoName, number)
+	if err != nil {
+		return nil, err
+	}
+	c := pr.PullRequest
+	return &c, nil
+}
+
+// GetPRMergeStatus returns a PR with its merge status
+func (f *Forge) GetPRMergeStatus(repoName string, number int) (*github.PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetPRMergeStatus"); err != nil {
+		return nil, err
+	}
+	pr, err := f.pr(repoName, number)
+	if err != nil {
+		return nil, err
+	}
+	c := pr.PullRequest
+	return &c, nil
+}
+
+// CreatePR opens a PR from an existing branch
+func (f *Forge) CreatePR(repoName, title, body, head, base string) (*github.PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("CreatePR"); err != nil {
+		return nil, err
+	}
+	pr, err := f.createPR(repoName, title, body, head, base)
+	if err != nil {
+		return nil, err
+	}
+	c := pr.PullRequest
+	return &c, nil
+}
+
+// createPR opens a PR. Callers hold the lock.
+func (f *Forge) createPR(repoName, title, body, head, base string) (*pullRequest, error) {
+	r := f.repo(repoName)
+	b, ok := r.branches[head]
+	if !ok {
+		return nil, fmt.Errorf("branch %s of %s: %w", head, repoName, ErrNotFound)
+	}
+	if r.openPRForBranch(head) != nil {
+		return nil, fmt.Errorf("a pull request for branch %s already exists", head)
+	}
+
+	number := r.nextNumber
+	r.nextNumber++
+	pr := &pullRequest{PullRequest: github.PullRequest{
+		Number:           number,
+		Title:            title,
+		Body:             body,
+		State:            "OPEN",
+		URL:              fmt.Sprintf("https://github.com/%s/pull/%d", repoName, number),
+		HeadRef:          head,
+		BaseRef:          base,
+		CreatedAt:        f.now,
+		HeadOid:          b.head,
+		Mergeable:        "MERGEABLE",
+		MergeStateStatus: "CLEAN",
+	}}
+	r.prs[number] = pr
+	return pr, nil
+}
+
+// MergePR merges an open PR GitHub reports as mergeable
+func (f *Forge) MergePR(repoName string, number int, strategy string, deleteRemoteBranch bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("MergePR"); err != nil {
+		return err
+	}
+	pr, err := f.pr(repoName, number)
+	if err != nil {
+		return err
+	}
+	if pr.State != "OPEN" {
+		return fmt.Errorf("pull request %s#%d is not open", repoName, number)
+	}
+	if pr.MergeBlocked() {
+		return fmt.Errorf("pull request %s#%d is not mergeable", repoName, number)
+	}
+
+	pr.State = "MERGED"
+	pr.Merged = true
+	pr.MergedAt = f.now
+	if deleteRemoteBranch {
+		delete(f.repo(repoName).branches, pr.HeadRef)
+	}
+	return nil
+}
+
+// GetPRReviews returns the reviews of a PR
+func (f *Forge) GetPRReviews(repoName string, number int) ([]github.PRReview, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetPRReviews"); err != nil {
+		return nil, err
+	}
+	pr, err := f.pr(repoName, number)
+	if err != nil {
+		return nil, err
+	}
+	return append([]github.PRReview{}, pr.reviews...), nil
+}
+
+// GetPRComments returns the conversation comments of a PR
+func (f *Forge) GetPRComments(repoName string, number int) ([]github.PRComment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetPRComments"); err != nil {
+		return nil, err
+	}
+	pr, err := f.pr(repoName, number)
+	if err != nil {
+		return nil, err
+	}
+	return append([]github.PRComment{}, pr.comments...), nil
+}
+
+// GetMergedPRsSince returns the PRs merged at or after since, most recently merged first
+func (f *Forge) GetMergedPRsSince(repoName string, since time.Time) ([]github.PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetMergedPRsSince"); err != nil {
+		return nil, err
+	}
+	var prs []github.PullRequest
+	for _, pr := range f.repo(repoName).prs {
+		if pr.Merged && !pr.MergedAt.Before(since) {
+			prs = append(prs, pr.PullRequest)
+		}
+	}
+	sort.Slice(prs, func(i, j int) bool {
+		if !prs[i].MergedAt.Equal(prs[j].MergedAt) {
+			return prs[i].MergedAt.After(prs[j].MergedAt)
+		}
+		return prs[i].Number > prs[j].Number
+	})
+	return prs, nil
+}
+
+// ListPRs returns open PRs, newest first
+func (f *Forge) ListPRs(repoName string) ([]github.PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("ListPRs"); err != nil {
+		return nil, err
+	}
+	var prs []github.PullRequest
+	for _, pr := range f.repo(repoName).prs {
+		if pr.State == "OPEN" {
+			prs = append(prs, pr.PullRequest)
+		}
+	}
+	sort.Slice(prs, func(i, j int) bool { return prs[i].Number > prs[j].Number })
+	return prs, nil
+}
+
+// AddPRComment comments on a PR as the authenticated user
+func (f *Forge) AddPRComment(repoName string, number int, body string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("AddPRComment"); err != nil {
+		return err
+	}
+	pr, err := f.pr(repoName, number)
+	if err != nil {
+		return err
+	}
+	f.addPRComment(repoName, pr, f.user, body)
+	return nil
+}
+
+// GetPRChecks returns the checks on a PR's head
+func (f *Forge) GetPRChecks(repoName string, number int) ([]github.PRCheck, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetPRChecks"); err != nil {
+		return nil, err
+	}
+	pr, err := f.pr(repoName, number)
+	if err != nil {
+		return nil, err
+	}
+	return append([]github.PRCheck(nil), pr.checks...), nil
+}
+
+// PRChecksPassing checks if every check passed; a PR without checks passes
+func (f *Forge) PRChecksPassing(repoName string, number int) (bool, error) {
+	checks, err := f.GetPRChecks(repoName, number)
+	if err != nil {
+		return false, err
+	}
+	for _, check := range checks {
+		if check.Status != "completed" {
+			return false, nil
+		}
+		if check.Conclusion != "success" && check.Conclusion != "skipped" && check.Conclusion != "neutral" {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// PRChecksRunning checks if any check is still running
+func (f *Forge) PRChecksRunning(repoName string, number int) (bool, error) {
+	checks, err := f.GetPRChecks(repoName, number)
+	if err != nil {
+		return false, err
+	}
+	for _, check := range checks {
+		if check.Status != "completed" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// PRChecksFailed checks if any completed check failed
+func (f *Forge) PRChecksFailed(repoName string, number int) (bool, error) {
+	checks, err := f.GetPRChecks(repoName, number)
+	if err != nil {
+		return false, err
+	}
+	for _, check := range checks {
+		if check.Status == "completed" && check.Conclusion == "failure" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetLatestWorkflowRun returns the most recent run on a branch, or nil
+func (f *Forge) GetLatestWorkflowRun(repoName, branchName string) (*github.WorkflowRun, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetLatestWorkflowRun"); err != nil {
+		return nil, err
+	}
+	runs := f.repo(repoName).runs
+	for i := len(runs) - 1; i >= 0; i-- {
+		if runs[i].branch == branchName {
+			run := runs[i].WorkflowRun
+			return &run, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetWorkflowRunLogs returns the logs recorded with AddWorkflowRun
+func (f *Forge) GetWorkflowRunLogs(repoName string, runID int) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("GetWorkflowRunLogs"); err != nil {
+		return "", err
+	}
+	run, err := f.run(repoName, runID)
+	if err != nil {
+		return "", err
+	}
+	return run.logs, nil
+}
+
+// RerunFailedJobs queues a failed run, and the failed checks of its
+// branch's PR, again
+func (f *Forge) RerunFailedJobs(repoName string, runID int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("RerunFailedJobs"); err != nil {
+		return err
+	}
+	run, err := f.run(repoName, runID)
+	if err != nil {
+		return err
+	}
+	run.reruns++
+	run.Status = "queued"
+	run.Conclusion = ""
+
+	// The failed checks of the branch's PR run again
+	if pr := f.repo(repoName).openPRForBranch(run.branch); pr != nil {
+		for i := range pr.checks {
+			if pr.checks[i].Conclusion == "failure" {
+				pr.checks[i].Status = "queued"
+				pr.checks[i].Conclusion = ""
+			}
+		}
+	}
+	return nil
+}
+
+// run returns a workflow run. Callers hold the lock.
+func (f *Forge) run(repoName string, runID int) (*workflowRun, error) {
+	for _, run := range f.repo(repoName).runs {
+		if run.ID == runID {
+			return run, nil
+		}
+	}
+	return nil, fmt.Errorf("workflow run %d of %s: %w", runID, repoName, ErrNotFound)
+}
+
+// FetchRepoSnapshot returns the open issues with any of the labels, with
+// their comments, and the open PRs, all in one call
+func (f *Forge) FetchRepoSnapshot(repoName string, labels []string) (*github.RepoSnapshot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("FetchRepoSnapshot"); err != nil {
+		return nil, err
+	}
+
+	snapshot := &github.RepoSnapshot{
+		Repo:           repoName,
+		Labels:         labels,
+		IssuesComplete: true,
+		PRsComplete:    true,
+	}
+	r := f.repo(repoName)
+	for _, listed := range f.listIssues(repoName, labels) {
+		snapshot.Issues = append(snapshot.Issues, github.IssueSnapshot{
+			Issue:            copyIssue(r.issues[listed.Number], true),
+			CommentsComplete: true,
+		})
+	}
+	for _, pr := range r.prs {
+		if pr.State != "OPEN" {
+			continue
+		}
+		snapshot.PRs = append(snapshot.PRs, github.PRSnapshot{
+			PullRequest:      pr.PullRequest,
+			Comments:         append([]github.PRComment{}, pr.comments...),
+			Reviews:          append([]github.PRReview{}, pr.reviews...),
+			Checks:           append([]github.PRCheck(nil), pr.checks...),
+			CommentsComplete: true,
+			ReviewsComplete:  true,
+		})
+	}
+	sort.Slice(snapshot.PRs, func(i, j int) bool { return snapshot.PRs[i].Number > snapshot.PRs[j].Number })
+	return snapshot, nil
+}
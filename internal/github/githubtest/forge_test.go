@@ -0,0 +1,171 @@
+package githubtest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/github"
+)
+
+const testRepo = "owner/repo"
+
+func TestForgeIssues(t *testing.T) {
+	f := NewForge()
+	plan := f.CreateIssue(testRepo, "Dark mode", "", "user:ready-to-plan")
+	f.CreateIssue(testRepo, "Unlabeled", "")
+
+	issues, err := f.ListIssuesWithLabels(testRepo, []string{"user:ready-to-plan", "user:plan-review"})
+	if err != nil {
+		t.Fatalf("ListIssuesWithLabels() error = %v", err)
+	}
+	if len(issues) != 1 || issues[0].Number != plan {
+		t.Fatalf("ListIssuesWithLabels() = %+v, want only #%d", issues, plan)
+	}
+	if issues[0].Comments != nil {
+		t.Errorf("listed issue has comments, want them left to GetIssue")
+	}
+
+	f.Advance(time.Minute)
+	if err := f.UpdateIssueLabels(testRepo, plan, []string{"user:ready-to-plan"}, []string{"ai:planning"}); err != nil {
+		t.Fatalf("UpdateIssueLabels() error = %v", err)
+	}
+	if err := f.AddIssueComment(testRepo, plan, "Planning"); err != nil {
+		t.Fatalf("AddIssueComment() error = %v", err)
+	}
+
+	issue, err := f.GetIssue(testRepo, plan)
+	if err != nil {
+		t.Fatalf("GetIssue() error = %v", err)
+	}
+	if got := issue.GetCurrentLabel(); got != "ai:planning" {
+		t.Errorf("GetCurrentLabel() = %q, want ai:planning", got)
+	}
+	if len(issue.Comments) != 1 || issue.Comments[0].Author.Login != "dev-swarm" {
+		t.Errorf("Comments = %+v, want one from the authenticated user", issue.Comments)
+	}
+	if !issue.UpdatedAt.Equal(f.Now()) || issue.CreatedAt.Equal(f.Now()) {
+		t.Errorf("CreatedAt, UpdatedAt = %v, %v, want the update stamped with the advanced clock", issue.CreatedAt, issue.UpdatedAt)
+	}
+	if exists, _ := f.LabelExists(testRepo, "ai:planning"); !exists {
+		t.Errorf("LabelExists(ai:planning) = false, want labels created when added")
+	}
+
+	// Callers get copies
+	issue.Labels[0].Name = "changed"
+	if got := f.Labels(testRepo, plan); got[0] != "ai:planning" {
+		t.Errorf("Labels() = %v, want the forge unaffected by changes to a returned issue", got)
+	}
+}
+
+func TestForgePullRequests(t *testing.T) {
+	f := NewForge()
+	branch := "dev-swarm/issue-1"
+	head := f.PushBranch(testRepo, branch)
+
+	pr, err := f.CreatePR(testRepo, "Dark mode", "", branch, "main")
+	if err != nil {
+		t.Fatalf("CreatePR() error = %v", err)
+	}
+	if pr.HeadOid != head {
+		t.Errorf("HeadOid = %q, want %q", pr.HeadOid, head)
+	}
+	if ahead, _ := f.CommitsAhead(testRepo, "main", branch); ahead != 1 {
+		t.Errorf("CommitsAhead() = %d, want 1", ahead)
+	}
+
+	f.SetChecks(testRepo, pr.Number, github.PRCheck{Name: "test", Status: "completed", Conclusion: "failure"})
+	if failed, _ := f.PRChecksFailed(testRepo, pr.Number); !failed {
+		t.Errorf("PRChecksFailed() = false, want true")
+	}
+	// A new commit clears the checks of the old head
+	f.PushBranch(testRepo, branch)
+	if failed, _ := f.PRChecksFailed(testRepo, pr.Number); failed {
+		t.Errorf("PRChecksFailed() = true after a push, want false")
+	}
+
+	f.SetMergeStatus(testRepo, pr.Number, "CONFLICTING", "DIRTY")
+	if err := f.MergePR(testRepo, pr.Number, "squash", true); err == nil {
+		t.Errorf("MergePR() error = nil, want a conflicting PR refused")
+	}
+	f.SetMergeStatus(testRepo, pr.Number, "MERGEABLE", "CLEAN")
+	if err := f.MergePR(testRepo, pr.Number, "squash", true); err != nil {
+		t.Fatalf("MergePR() error = %v", err)
+	}
+
+	if open, _ := f.GetPRForBranch(testRepo, branch); open != nil {
+		t.Errorf("GetPRForBranch() = %+v, want no open PR after the merge", open)
+	}
+	if head, _ := f.GetBranchHead(testRepo, branch); head != "" {
+		t.Errorf("GetBranchHead() = %q, want the branch deleted", head)
+	}
+	merged, _ := f.GetMergedPRsSince(testRepo, f.Now())
+	if len(merged) != 1 || merged[0].Number != pr.Number {
+		t.Errorf("GetMergedPRsSince() = %+v, want #%d", merged, pr.Number)
+	}
+	f.Advance(time.Minute)
+	if merged, _ := f.GetMergedPRsSince(testRepo, f.Now()); len(merged) != 0 {
+		t.Errorf("GetMergedPRsSince() = %+v, want none merged after the watermark", merged)
+	}
+	if resolved, _ := f.DependencyResolved(github.Dependency{Repo: testRepo, Number: pr.Number}); !resolved {
+		t.Errorf("DependencyResolved() = false, want a merged PR resolved")
+	}
+}
+
+func TestForgeFail(t *testing.T) {
+	f := NewForge()
+	boom := errors.New("boom")
+
+	f.Fail("ListIssuesWithLabels", boom)
+	if _, err := f.ListIssuesWithLabels(testRepo, []string{"user:ready-to-plan"}); !errors.Is(err, boom) {
+		t.Errorf("ListIssuesWithLabels() error = %v, want the injected error", err)
+	}
+	f.Fail("ListIssuesWithLabels", nil)
+	if _, err := f.ListIssuesWithLabels(testRepo, []string{"user:ready-to-plan"}); err != nil {
+		t.Errorf("ListIssuesWithLabels() error = %v, want nil once cleared", err)
+	}
+	if got := f.Calls("ListIssuesWithLabels"); got != 2 {
+		t.Errorf("Calls() = %d, want 2", got)
+	}
+
+	if _, err := f.GetIssue(testRepo, 7); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetIssue() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestForgeRateLimit(t *testing.T) {
+	f := NewForge()
+	f.SetRateLimit(github.RateLimit{Resource: "core", Limit: 5000, Remaining: 0, HeldUntil: f.Now().Add(time.Minute)})
+
+	if _, err := f.ListLabels(testRepo); !github.IsRateLimited(err) {
+		t.Errorf("ListLabels() error = %v, want a rate limit error", err)
+	}
+	if got := f.RateLimit(); got.HeldUntil.IsZero() {
+		t.Errorf("RateLimit().HeldUntil = zero, want the hold reported")
+	}
+
+	f.Advance(time.Minute)
+	if _, err := f.ListLabels(testRepo); err != nil {
+		t.Errorf("ListLabels() error = %v, want nil once the hold passed", err)
+	}
+	if got := f.RateLimit(); !got.HeldUntil.IsZero() {
+		t.Errorf("RateLimit().HeldUntil = %v, want zero once it passed", got.HeldUntil)
+	}
+}
+
+func TestForgeRateLimits(t *testing.T) {
+	f := NewForge()
+	f.SetRateLimit(github.RateLimit{Resource: "core", Limit: 5000, Remaining: 5000})
+	f.SetRateLimit(github.RateLimit{Resource: "graphql", Limit: 100, Remaining: 100})
+
+	f.ListLabels(testRepo)
+	f.FetchRepoSnapshot(testRepo, nil)
+	f.FetchRepoSnapshot(testRepo, nil)
+	limits := f.RateLimits()
+	if limits["core"].Remaining != 4999 || limits["graphql"].Remaining != 98 {
+		t.Errorf("RateLimits() = %+v, want each call spent from its own quota", limits)
+	}
+	if got := f.RateLimit(); got.Resource != "graphql" {
+		t.Errorf("RateLimit() = %+v, want the graphql quota closest to running out", got)
+	}
+}
@@ -0,0 +1,353 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// snapshotQuery fetches a page of a repository's open issues with any of the
+// labels and a page of its open PRs, with everything the orchestrator reads
+// about them. Later pages fetch only the lists that have more.
+const snapshotQuery = `query($owner: String!, $name: String!, $labels: [String!], $first: Int!,
+    $withIssues: Boolean!, $issuesAfter: String, $withPRs: Boolean!, $prsAfter: String) {
+  repository(owner: $owner, name: $name) {
+    issues(states: OPEN, labels: $labels, first: $first, after: $issuesAfter, orderBy: {field: CREATED_AT, direction: DESC}) @include(if: $withIssues) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        number title body state url createdAt updatedAt
+        labels(first: 50) { nodes { name color description } }
+        comments(last: 100) { totalCount nodes { databaseId author { login } body createdAt } }
+      }
+    }
+    pullRequests(states: OPEN, first: $first, after: $prsAfter, orderBy: {field: CREATED_AT, direction: DESC}) @include(if: $withPRs) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        number title body state url headRefName baseRefName headRefOid isDraft createdAt
+        comments(last: 100) { totalCount nodes { databaseId author { login } body createdAt } }
+        reviews(last: 100) {
+          totalCount
+          nodes { id author { login } authorAssociation body state submittedAt commit { oid } }
+        }
+        commits(last: 1) {
+          nodes {
+            commit {
+              statusCheckRollup {
+                contexts(first: 100) {
+                  nodes {
+                    __typename
+                    ... on CheckRun { name status conclusion }
+                    ... on StatusContext { context state }
+                  }
+                }
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// snapshotMaxPages bounds the queries of one snapshot. A repository with
+// more open issues or PRs gets an incomplete snapshot.
+const snapshotMaxPages = 10
+
+// graphqlRequest is the body of a GraphQL API request
+type graphqlRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// gqlActor is a comment or review author; nil for deleted users
+type gqlActor struct {
+	Login string `json:"login"`
+}
+
+// login returns the actor's login, or "" for a deleted user
+func (a *gqlActor) login() string {
+	if a == nil {
+		return ""
+	}
+	return a.Login
+}
+
+// gqlComments is a page of issue or PR conversation comments
+type gqlComments struct {
+	TotalCount int `json:"totalCount"`
+	Nodes      []struct {
+		DatabaseID int       `json:"databaseId"`
+		Author     *gqlActor `json:"author"`
+		Body       string    `json:"body"`
+		CreatedAt  time.Time `json:"createdAt"`
+	} `json:"nodes"`
+}
+
+// complete checks if every comment was fetched
+func (c gqlComments) complete() bool {
+	return len(c.Nodes) >= c.TotalCount
+}
+
+// gqlIssue is an issue as the snapshot query reports it
+type gqlIssue struct {
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	State     string    `json:"state"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Labels    struct {
+		Nodes []Label `json:"nodes"`
+	} `json:"labels"`
+	Comments gqlComments `json:"comments"`
+}
+
+// gqlPull is a PR as the snapshot query reports it
+type gqlPull struct {
+	Number      int         `json:"number"`
+	Title       string      `json:"title"`
+	Body        string      `json:"body"`
+	State       string      `json:"state"`
+	URL         string      `json:"url"`
+	HeadRefName string      `json:"headRefName"`
+	BaseRefName string      `json:"baseRefName"`
+	HeadRefOid  string      `json:"headRefOid"`
+	IsDraft     bool        `json:"isDraft"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	Comments    gqlComments `json:"comments"`
+	Reviews     struct {
+		TotalCount int `json:"totalCount"`
+		Nodes      []struct {
+			ID                string    `json:"id"`
+			Author            *gqlActor `json:"author"`
+			AuthorAssociation string    `json:"authorAssociation"`
+			Body              string    `json:"body"`
+			State             string    `json:"state"`
+			SubmittedAt       time.Time `json:"submittedAt"`
+			Commit            *struct {
+				Oid string `json:"oid"`
+			} `json:"commit"`
+		} `json:"nodes"`
+	} `json:"reviews"`
+	Commits struct {
+		Nodes []struct {
+			Commit struct {
+				StatusCheckRollup *struct {
+					Contexts struct {
+						Nodes []gqlCheckContext `json:"nodes"`
+					} `json:"contexts"`
+				} `json:"statusCheckRollup"`
+			} `json:"commit"`
+		} `json:"nodes"`
+	} `json:"commits"`
+}
+
+// gqlCheckContext is a check run or a commit status
+type gqlCheckContext struct {
+	Typename   string `json:"__typename"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	Context    string `json:"context"`
+	State      string `json:"state"`
+}
+
+// toCheck converts the context to a check as the REST API reports it
+func (c gqlCheckContext) toCheck() PRCheck {
+	if c.Typename == "StatusContext" {
+		check := PRCheck{Name: c.Context, Status: "completed", Conclusion: strings.ToLower(c.State)}
+		switch c.State {
+		case "PENDING", "EXPECTED":
+			check.Status, check.Conclusion = "in_progress", ""
+		case "ERROR":
+			check.Conclusion = "failure"
+		}
+		return check
+	}
+	return PRCheck{Name: c.Name, Status: strings.ToLower(c.Status), Conclusion: strings.ToLower(c.Conclusion)}
+}
+
+// gqlPageInfo locates a page of a connection
+type gqlPageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// snapshotRepository is a page of the snapshot query. A list is empty if
+// the page did not include it.
+type snapshotRepository struct {
+	Issues struct {
+		PageInfo gqlPageInfo `json:"pageInfo"`
+		Nodes    []gqlIssue  `json:"nodes"`
+	} `json:"issues"`
+	PullRequests struct {
+		PageInfo gqlPageInfo `json:"pageInfo"`
+		Nodes    []gqlPull   `json:"nodes"`
+	} `json:"pullRequests"`
+}
+
+// snapshotResponse is the response to the snapshot query
+type snapshotResponse struct {
+	Data *struct {
+		Repository *snapshotRepository `json:"repository"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// graphqlClient sends GraphQL requests. Client and HTTPClient both implement it.
+type graphqlClient interface {
+	graphql(body []byte) ([]byte, error)
+}
+
+// fetchRepoSnapshot pages through a repository's open issues with any of the
+// labels and its open PRs, first at a time
+func fetchRepoSnapshot(c graphqlClient, repo string, labels []string, first int) (*RepoSnapshot, error) {
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid repository %q, must be owner/repo", repo)
+	}
+	variables := map[string]interface{}{
+		"owner": owner, "name": name, "labels": labels, "first": first,
+		"withIssues": true, "withPRs": true,
+	}
+
+	snapshot := &RepoSnapshot{Repo: repo, Labels: labels}
+	for page := 0; page < snapshotMaxPages; page++ {
+		body, err := json.Marshal(graphqlRequest{Query: snapshotQuery, Variables: variables})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		data, err := c.graphql(body)
+		if err != nil {
+			return nil, err
+		}
+		found, err := parseSnapshotPage(repo, data)
+		if err != nil {
+			return nil, err
+		}
+
+		if variables["withIssues"] == true {
+			for _, issue := range found.Issues.Nodes {
+				snapshot.Issues = append(snapshot.Issues, issue.toSnapshot())
+			}
+			variables["issuesAfter"] = found.Issues.PageInfo.EndCursor
+			variables["withIssues"] = found.Issues.PageInfo.HasNextPage
+			snapshot.IssuesComplete = !found.Issues.PageInfo.HasNextPage
+		}
+		if variables["withPRs"] == true {
+			for _, pr := range found.PullRequests.Nodes {
+				snapshot.PRs = append(snapshot.PRs, pr.toSnapshot())
+			}
+			variables["prsAfter"] = found.PullRequests.PageInfo.EndCursor
+			variables["withPRs"] = found.PullRequests.PageInfo.HasNextPage
+			snapshot.PRsComplete = !found.PullRequests.PageInfo.HasNextPage
+		}
+		if snapshot.IssuesComplete && snapshot.PRsComplete {
+			break
+		}
+	}
+	return snapshot, nil
+}
+
+// parseSnapshotPage decodes a response to the snapshot query
+func parseSnapshotPage(repo string, data []byte) (*snapshotRepository, error) {
+	var resp snapshotResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode GraphQL response: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		messages := make([]string, 0, len(resp.Errors))
+		for _, e := range resp.Errors {
+			messages = append(messages, e.Message)
+		}
+		return nil, fmt.Errorf("GraphQL query failed: %s", strings.Join(messages, "; "))
+	}
+	if resp.Data == nil || resp.Data.Repository == nil {
+		return nil, fmt.Errorf("repository %s not found", repo)
+	}
+	return resp.Data.Repository, nil
+}
+
+// toSnapshot converts the issue
+func (i gqlIssue) toSnapshot() IssueSnapshot {
+	comments := make([]Comment, 0, len(i.Comments.Nodes))
+	for _, c := range i.Comments.Nodes {
+		comments = append(comments, Comment{ID: c.DatabaseID, Author: Author{Login: c.Author.login()}, Body: c.Body, CreatedAt: c.CreatedAt})
+	}
+	return IssueSnapshot{
+		Issue: Issue{
+			Number:    i.Number,
+			Title:     i.Title,
+			Body:      i.Body,
+			State:     i.State,
+			URL:       i.URL,
+			Labels:    i.Labels.Nodes,
+			Comments:  comments,
+			CreatedAt: i.CreatedAt,
+			UpdatedAt: i.UpdatedAt,
+		},
+		CommentsComplete: i.Comments.complete(),
+	}
+}
+
+// toSnapshot converts the PR
+func (p gqlPull) toSnapshot() PRSnapshot {
+	snapshot := PRSnapshot{
+		PullRequest: PullRequest{
+			Number:    p.Number,
+			Title:     p.Title,
+			Body:      p.Body,
+			State:     p.State,
+			URL:       p.URL,
+			HeadRef:   p.HeadRefName,
+			BaseRef:   p.BaseRefName,
+			CreatedAt: p.CreatedAt,
+			HeadOid:   p.HeadRefOid,
+			IsDraft:   p.IsDraft,
+		},
+		CommentsComplete: p.Comments.complete(),
+		ReviewsComplete:  len(p.Reviews.Nodes) >= p.Reviews.TotalCount,
+	}
+
+	for _, c := range p.Comments.Nodes {
+		snapshot.Comments = append(snapshot.Comments, PRComment{ID: c.DatabaseID, Author: Author{Login: c.Author.login()}, Body: c.Body, CreatedAt: c.CreatedAt})
+	}
+	for _, r := range p.Reviews.Nodes {
+		review := PRReview{
+			ID:                r.ID,
+			Author:            Author{Login: r.Author.login()},
+			AuthorAssociation: r.AuthorAssociation,
+			Body:              r.Body,
+			State:             r.State,
+			CreatedAt:         r.SubmittedAt,
+		}
+		if r.Commit != nil {
+			review.Commit.Oid = r.Commit.Oid
+		}
+		snapshot.Reviews = append(snapshot.Reviews, review)
+	}
+	for _, commit := range p.Commits.Nodes {
+		if rollup := commit.Commit.StatusCheckRollup; rollup != nil {
+			for _, c := range rollup.Contexts.Nodes {
+				snapshot.Checks = append(snapshot.Checks, c.toCheck())
+			}
+		}
+	}
+	return snapshot
+}
+
+// FetchRepoSnapshot returns a repository's open issues with any of the
+// labels and its open PRs, in one GraphQL query unless they span pages
+func (c *Client) FetchRepoSnapshot(repo string, labels []string) (*RepoSnapshot, error) {
+	return fetchRepoSnapshot(c, repo, labels, c.pageSize)
+}
+
+// FetchRepoSnapshot returns a repository's open issues with any of the
+// labels and its open PRs, in one GraphQL query unless they span pages
+func (c *HTTPClient) FetchRepoSnapshot(repo string, labels []string) (*RepoSnapshot, error) {
+	return fetchRepoSnapshot(c, repo, labels, c.pageSize)
+}
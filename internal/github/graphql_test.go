@@ -0,0 +1,133 @@
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestHTTPFetchRepoSnapshot(t *testing.T) {
+	client, requests := newTestHTTPClient(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"POST /graphql": func(w http.ResponseWriter, r *http.Request) {
+			var req graphqlRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.Variables["owner"] != "owner" || req.Variables["name"] != "repo" {
+				t.Errorf("variables = %v, want owner and name split from the repo", req.Variables)
+			}
+			if req.Variables["prsAfter"] == "PR_CURSOR" {
+				// The second page, of PRs only
+				if req.Variables["withIssues"] != false {
+					t.Errorf("withIssues = %v, want false once every issue was fetched", req.Variables["withIssues"])
+				}
+				respond(`{"data": {"repository": {
+					"pullRequests": {"pageInfo": {"hasNextPage": false, "endCursor": "PR_END"}, "nodes": [
+						{"number": 3, "state": "OPEN", "headRefName": "claude/issue-5", "headRefOid": "def",
+						 "comments": {"totalCount": 0, "nodes": []}, "reviews": {"totalCount": 0, "nodes": []},
+						 "commits": {"nodes": []}}
+					]}
+				}}}`)(w, r)
+				return
+			}
+			respond(`{"data": {"repository": {
+				"issues": {"pageInfo": {"hasNextPage": false, "endCursor": "ISSUE_END"}, "nodes": [
+					{"number": 1, "title": "Dark mode", "state": "OPEN", "url": "https://github.com/owner/repo/issues/1",
+					 "labels": {"nodes": [{"name": "user:code-review"}]},
+					 "comments": {"totalCount": 2, "nodes": [
+						{"databaseId": 10, "author": {"login": "alice"}, "body": "Looks good", "createdAt": "2024-06-14T12:00:00Z"},
+						{"databaseId": 11, "author": null, "body": "From a deleted user", "createdAt": "2024-06-14T12:01:00Z"}
+					 ]}}
+				]},
+				"pullRequests": {"pageInfo": {"hasNextPage": true, "endCursor": "PR_CURSOR"}, "nodes": [
+					{"number": 2, "state": "OPEN", "headRefName": "claude/issue-1", "headRefOid": "abc",
+					 "comments": {"totalCount": 150, "nodes": []},
+					 "reviews": {"totalCount": 1, "nodes": [
+						{"id": "PRR_1", "author": {"login": "alice"}, "state": "APPROVED", "submittedAt": "2024-06-14T12:02:00Z", "commit": {"oid": "abc"}}
+					 ]},
+					 "commits": {"nodes": [{"commit": {"statusCheckRollup": {"contexts": {"nodes": [
+						{"__typename": "CheckRun", "name": "test", "status": "COMPLETED", "conclusion": "SUCCESS"},
+						{"__typename": "StatusContext", "context": "ci/legacy", "state": "ERROR"}
+					 ]}}}}]}}
+				]}
+			}}}`)(w, r)
+		},
+	})
+
+	snapshot, err := client.FetchRepoSnapshot("owner/repo", []string{"user:code-review"})
+	if err != nil {
+		t.Fatalf("FetchRepoSnapshot() error = %v", err)
+	}
+	if !snapshot.IssuesComplete || !snapshot.PRsComplete {
+		t.Errorf("IssuesComplete, PRsComplete = %v, %v, want true after the second page", snapshot.IssuesComplete, snapshot.PRsComplete)
+	}
+	if len(*requests) != 2 || len(snapshot.PRs) != 2 || snapshot.PRForBranch("claude/issue-5") == nil {
+		t.Errorf("requests = %v, PRs = %d, want both pages of PRs", *requests, len(snapshot.PRs))
+	}
+
+	issue := snapshot.GetIssue(1)
+	if issue == nil || !issue.CommentsComplete || len(issue.Comments) != 2 || !issue.HasLabel("user:code-review") {
+		t.Fatalf("GetIssue(1) = %+v, want the issue with its label and comments", issue)
+	}
+	if issue.Comments[1].Author.Login != "" {
+		t.Errorf("deleted user login = %q, want empty", issue.Comments[1].Author.Login)
+	}
+
+	pr := snapshot.PRForBranch("claude/issue-1")
+	if pr == nil || pr.Number != 2 || pr.HeadOid != "abc" {
+		t.Fatalf("PRForBranch() = %+v, want #2", pr)
+	}
+	if pr.CommentsComplete || !pr.ReviewsComplete {
+		t.Errorf("CommentsComplete, ReviewsComplete = %v, %v, want false, true", pr.CommentsComplete, pr.ReviewsComplete)
+	}
+	if len(pr.Reviews) != 1 || pr.Reviews[0].Commit.Oid != "abc" || pr.Reviews[0].State != ReviewApproved {
+		t.Errorf("Reviews = %+v, want the approval on abc", pr.Reviews)
+	}
+	if len(pr.Checks) != 2 || !pr.ChecksFailed() {
+		t.Errorf("Checks = %+v, want the errored status to fail them", pr.Checks)
+	}
+}
+
+func TestHTTPFetchRepoSnapshotErrors(t *testing.T) {
+	client, _ := newTestHTTPClient(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"POST /graphql": respond(`{"data": null, "errors": [{"message": "Something went wrong"}]}`),
+	})
+
+	if _, err := client.FetchRepoSnapshot("owner/repo", nil); err == nil {
+		t.Errorf("FetchRepoSnapshot() error = nil, want the GraphQL error")
+	}
+}
+
+func TestHTTPFetchRepoSnapshotMaxPages(t *testing.T) {
+	client, requests := newTestHTTPClient(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"POST /graphql": respond(`{"data": {"repository": {
+			"issues": {"pageInfo": {"hasNextPage": true, "endCursor": "MORE"}, "nodes": []},
+			"pullRequests": {"pageInfo": {"hasNextPage": false}, "nodes": []}
+		}}}`),
+	})
+
+	snapshot, err := client.FetchRepoSnapshot("owner/repo", nil)
+	if err != nil {
+		t.Fatalf("FetchRepoSnapshot() error = %v", err)
+	}
+	if snapshot.IssuesComplete || !snapshot.PRsComplete {
+		t.Errorf("IssuesComplete, PRsComplete = %v, %v, want false, true", snapshot.IssuesComplete, snapshot.PRsComplete)
+	}
+	if len(*requests) != snapshotMaxPages {
+		t.Errorf("got %d requests, want %d", len(*requests), snapshotMaxPages)
+	}
+}
+
+func TestGraphQLURL(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		want    string
+	}{
+		{"https://api.github.com", "https://api.github.com/graphql"},
+		{"https://github.example.com/api/v3", "https://github.example.com/api/graphql"},
+	}
+
+	for _, tt := range tests {
+		if got := graphqlURL(tt.baseURL); got != tt.want {
+			t.Errorf("graphqlURL(%q) = %q, want %q", tt.baseURL, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+	"github.com/nathanbarrett/dev-swarm-go/pkg/version"
+)
+
+// DefaultAPIURL is the root of the public GitHub REST API
+const DefaultAPIURL = "https://api.github.com"
+
+// requestTimeout bounds a single API request
+const requestTimeout = 30 * time.Second
+
+// HTTPClient talks to the GitHub REST API directly. It reports the same
+// data as the gh CLI client, so the two can be swapped.
+type HTTPClient struct {
+	baseURL    string
+	graphqlURL string
+	token      string
+	pageSize   int
+	limits     *rateLimiter
+	http       *http.Client
+}
+
+// NewHTTPClient creates a client for the REST API at baseURL (DefaultAPIURL
+// if empty) that authenticates with token
+func NewHTTPClient(baseURL, token string) *HTTPClient {
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return &HTTPClient{
+		baseURL:    baseURL,
+		graphqlURL: graphqlURL(baseURL),
+		token:      token,
+		pageSize:   config.DefaultGitHubPageSize,
+		limits:     newRateLimiter(),
+		http:       &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// SetPageSize sets the number of items list requests fetch per page
+func (c *HTTPClient) SetPageSize(n int) {
+	c.pageSize = n
+}
+
+// graphqlURL returns the GraphQL endpoint that belongs to a REST API root.
+// GitHub Enterprise serves REST under /api/v3 and GraphQL under /api/graphql.
+func graphqlURL(baseURL string) string {
+	if root, ok := strings.CutSuffix(baseURL, "/api/v3"); ok {
+		return root + "/api/graphql"
+	}
+	return baseURL + "/graphql"
+}
+
+// ResolveToken returns the token for the REST API: the configured one, else
+// GH_TOKEN or GITHUB_TOKEN, else the token the gh CLI is logged in with
+func ResolveToken(configured string) (string, error) {
+	if configured != "" {
+		return configured, nil
+	}
+	for _, env := range []string{"GH_TOKEN", "GITHUB_TOKEN"} {
+		if token := os.Getenv(env); token != "" {
+			return token, nil
+		}
+	}
+
+	output, err := exec.Command("gh", "auth", "token").Output()
+	if token := strings.TrimSpace(string(output)); err == nil && token != "" {
+		return token, nil
+	}
+	return "", errors.New("no GitHub token: set settings.github.token or GH_TOKEN, or log in with gh auth login")
+}
+
+// APIError is a request the REST API refused
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string // GitHub's explanation, if it gave one
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("GitHub API %s %s failed: %s (HTTP %d)", e.Method, e.Path, e.Message, e.StatusCode)
+}
+
+// isUnprocessable checks if an error is a REST API validation failure, such
+// as creating something that already exists
+func isUnprocessable(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnprocessableEntity
+}
+
+// request sends a request with an optional JSON body and returns the response body
+func (c *HTTPClient) request(method, path string, body interface{}) ([]byte, error) {
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+	data, _, err := c.send(method, c.baseURL+"/"+path, path, data)
+	return data, err
+}
+
+// send sends a request with an optional encoded JSON body to url and returns
+// the response body and headers. path names the request in errors and the
+// quota it spends. While a rate limit holds that quota back, nothing is sent.
+func (c *HTTPClient) send(method, url, path string, body []byte) ([]byte, http.Header, error) {
+	resource := resourceOf(path)
+	if err := c.limits.check(resource); err != nil {
+		return nil, nil, err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("User-Agent", "dev-swarm-go/"+version.Info())
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("GitHub API %s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	c.limits.observe(resp.Header)
+	if resp.StatusCode >= 300 {
+		apiErr := &APIError{Method: method, Path: path, StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+		var result struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &result) == nil && result.Message != "" {
+			apiErr.Message = result.Message
+		}
+		if err := c.limits.refused(resource, resp.StatusCode, resp.Header, apiErr.Message); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, apiErr
+	}
+	c.limits.succeeded()
+	return data, resp.Header, nil
+}
+
+// do sends a request and decodes the JSON response into result, if given
+func (c *HTTPClient) do(method, path string, body, result interface{}) error {
+	data, err := c.request(method, path, body)
+	if err != nil {
+		return err
+	}
+	if result == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", path, err)
+	}
+	return nil
+}
+
+// page fetches a page of a list
+func (c *HTTPClient) page(target string) ([]byte, string, error) {
+	path, url := target, target
+	if strings.Contains(target, "://") {
+		path = strings.TrimPrefix(target, c.baseURL+"/")
+	} else {
+		url = c.baseURL + "/" + target
+	}
+	data, header, err := c.send(http.MethodGet, url, path, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, nextPageURL(header.Get("Link")), nil
+}
+
+// perPage returns the number of items to request per page
+func (c *HTTPClient) perPage() int {
+	return c.pageSize
+}
+
+// graphql sends a GraphQL request and returns the response body
+func (c *HTTPClient) graphql(body []byte) ([]byte, error) {
+	data, _, err := c.send(http.MethodPost, c.graphqlURL, "graphql", body)
+	return data, err
+}
+
+// get fetches a path and decodes the JSON response into result
+func (c *HTTPClient) get(path string, result interface{}) error {
+	return c.do(http.MethodGet, path, nil, result)
+}
+
+// RateLimit returns the quota closest to running out, as the last responses reported it
+func (c *HTTPClient) RateLimit() RateLimit {
+	return c.limits.status()
+}
+
+// RateLimits returns the quota of every resource, by resource, as the last
+// responses reported them
+func (c *HTTPClient) RateLimits() map[string]RateLimit {
+	return c.limits.all()
+}
+
+// IsAuthenticated checks if the token is accepted
+func (c *HTTPClient) IsAuthenticated() bool {
+	_, err := c.GetAuthenticatedUser()
+	return err == nil
+}
+
+// GetAuthenticatedUser returns the login the token belongs to
+func (c *HTTPClient) GetAuthenticatedUser() (string, error) {
+	var user restUser
+	if err := c.get("user", &user); err != nil {
+		return "", err
+	}
+	return user.Login, nil
+}
+
+// RepoExists checks if a repository exists and is accessible
+func (c *HTTPClient) RepoExists(repo string) bool {
+	return c.get("repos/"+repo, nil) == nil
+}
+
+// GetRepoPermission returns a user's role on a repository, as Client does
+func (c *HTTPClient) GetRepoPermission(repo, login string) (string, error) {
+	var result struct {
+		Permission string `json:"permission"`
+		RoleName   string `json:"role_name"`
+	}
+	err := c.get(fmt.Sprintf("repos/%s/collaborators/%s/permission", repo, login), &result)
+	if err != nil {
+		if isNotFound(err) {
+			return "none", nil
+		}
+		return "", err
+	}
+
+	switch result.RoleName {
+	case "admin", "maintain", "write", "triage", "read":
+		return result.RoleName, nil
+	}
+	return result.Permission, nil
+}
+
+// IsTeamMember checks if a user is an active member of an organization team
+func (c *HTTPClient) IsTeamMember(org, team, login string) (bool, error) {
+	var result struct {
+		State string `json:"state"`
+	}
+	err := c.get(fmt.Sprintf("orgs/%s/teams/%s/memberships/%s", org, team, login), &result)
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return result.State == "active", nil
+}
+
+// GetBranchHead returns the commit SHA at the tip of a remote branch, or ""
+// if the branch does not exist
+func (c *HTTPClient) GetBranchHead(repo, branch string) (string, error) {
+	var result struct {
+		Object struct {
+			SHA string `json:"sha"`
+		} `json:"object"`
+	}
+	err := c.get(fmt.Sprintf("repos/%s/git/ref/heads/%s", repo, branch), &result)
+	if err != nil {
+		if isNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return result.Object.SHA, nil
+}
+
+// CommitsAhead returns how many commits head has that base does not
+func (c *HTTPClient) CommitsAhead(repo, base, head string) (int, error) {
+	var result struct {
+		AheadBy int `json:"ahead_by"`
+	}
+	if err := c.get(fmt.Sprintf("repos/%s/compare/%s...%s", repo, base, head), &result); err != nil {
+		return 0, err
+	}
+	return result.AheadBy, nil
+}
+
+// DependencyResolved checks if a dependency is done: a closed issue or a merged PR
+func (c *HTTPClient) DependencyResolved(dep Dependency) (bool, error) {
+	var issue restIssue
+	if err := c.get(fmt.Sprintf("repos/%s/issues/%d", dep.Repo, dep.Number), &issue); err != nil {
+		return false, err
+	}
+
+	if issue.PullRequest != nil {
+		return issue.PullRequest.MergedAt != nil, nil
+	}
+	return issue.State == "closed", nil
+}
@@ -0,0 +1,99 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ListIssuesWithLabel returns all open issues with a specific label
+func (c *HTTPClient) ListIssuesWithLabel(repo, label string) ([]Issue, error) {
+	return listIssues(c, repo, label)
+}
+
+// ListIssuesWithLabels returns all open issues with any of the specified labels
+func (c *HTTPClient) ListIssuesWithLabels(repo string, labels []string) ([]Issue, error) {
+	return listIssuesWithLabels(c, repo, labels)
+}
+
+// GetIssue returns full issue details including comments
+func (c *HTTPClient) GetIssue(repo string, number int) (*Issue, error) {
+	var found restIssue
+	if err := c.get(fmt.Sprintf("repos/%s/issues/%d", repo, number), &found); err != nil {
+		return nil, err
+	}
+	comments, err := c.GetIssueComments(repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	issue := found.toIssue()
+	issue.Comments = comments
+	return &issue, nil
+}
+
+// GetIssueComments returns comments for an issue
+func (c *HTTPClient) GetIssueComments(repo string, number int) ([]Comment, error) {
+	return listIssueComments(c, repo, number)
+}
+
+// UpdateIssueLabels changes labels on an issue. Removing a label the issue
+// does not have is not an error.
+func (c *HTTPClient) UpdateIssueLabels(repo string, number int, removeLabels, addLabels []string) error {
+	for _, label := range removeLabels {
+		path := fmt.Sprintf("repos/%s/issues/%d/labels/%s", repo, number, url.PathEscape(label))
+		if err := c.do(http.MethodDelete, path, nil, nil); err != nil && !isNotFound(err) {
+			return err
+		}
+	}
+	if len(addLabels) == 0 {
+		return nil
+	}
+	body := map[string][]string{"labels": addLabels}
+	return c.do(http.MethodPost, fmt.Sprintf("repos/%s/issues/%d/labels", repo, number), body, nil)
+}
+
+// AddIssueComment adds a comment to an issue
+func (c *HTTPClient) AddIssueComment(repo string, number int, body string) error {
+	return c.do(http.MethodPost, fmt.Sprintf("repos/%s/issues/%d/comments", repo, number), map[string]string{"body": body}, nil)
+}
+
+// CloseIssue closes an issue
+func (c *HTTPClient) CloseIssue(repo string, number int) error {
+	return c.do(http.MethodPatch, fmt.Sprintf("repos/%s/issues/%d", repo, number), map[string]string{"state": "closed"}, nil)
+}
+
+// ListLabels returns all labels in a repo
+func (c *HTTPClient) ListLabels(repo string) ([]LabelInfo, error) {
+	return listLabels(c, repo)
+}
+
+// CreateLabel creates a label, or updates it if it already exists
+func (c *HTTPClient) CreateLabel(repo, name, color, description string) error {
+	body := map[string]string{"name": name, "color": color, "description": description}
+	err := c.do(http.MethodPost, fmt.Sprintf("repos/%s/labels", repo), body, nil)
+	if !isUnprocessable(err) {
+		return err
+	}
+	// The label exists
+	return c.do(http.MethodPatch, fmt.Sprintf("repos/%s/labels/%s", repo, url.PathEscape(name)), body, nil)
+}
+
+// LabelExists checks if a label exists
+func (c *HTTPClient) LabelExists(repo, name string) (bool, error) {
+	labels, err := c.ListLabels(repo)
+	if err != nil {
+		return false, err
+	}
+	return labelExists(labels, name), nil
+}
+
+// DeleteLabel deletes a label
+func (c *HTTPClient) DeleteLabel(repo, name string) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("repos/%s/labels/%s", repo, url.PathEscape(name)), nil, nil)
+}
+
+// SyncLabels ensures all required labels exist in the repo
+func (c *HTTPClient) SyncLabels(repo string, labels []LabelInfo) error {
+	return syncLabels(c, repo, labels)
+}
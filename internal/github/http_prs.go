@@ -0,0 +1,214 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GetPRForBranch finds an open PR for a specific branch
+func (c *HTTPClient) GetPRForBranch(repo, branch string) (*PullRequest, error) {
+	owner := strings.SplitN(repo, "/", 2)[0]
+	query := url.Values{"head": {owner + ":" + branch}, "state": {"open"}}
+	var pulls []restPull
+	if err := c.get(fmt.Sprintf("repos/%s/pulls?%s", repo, query.Encode()), &pulls); err != nil {
+		return nil, err
+	}
+
+	if len(pulls) == 0 {
+		return nil, nil
+	}
+	pr := pulls[0].toPullRequest()
+	return &pr, nil
+}
+
+// GetPR returns a specific PR by number
+func (c *HTTPClient) GetPR(repo string, number int) (*PullRequest, error) {
+	var pull restPull
+	if err := c.get(fmt.Sprintf("repos/%s/pulls/%d", repo, number), &pull); err != nil {
+		return nil, err
+	}
+	pr := pull.toPullRequest()
+	return &pr, nil
+}
+
+// GetPRMergeStatus returns a PR with its draft, mergeable and merge state fields filled
+func (c *HTTPClient) GetPRMergeStatus(repo string, number int) (*PullRequest, error) {
+	return c.GetPR(repo, number)
+}
+
+// CreatePR creates a new pull request
+func (c *HTTPClient) CreatePR(repo, title, body, head, base string) (*PullRequest, error) {
+	request := map[string]string{"title": title, "body": body, "head": head, "base": base}
+	var pull restPull
+	if err := c.do(http.MethodPost, fmt.Sprintf("repos/%s/pulls", repo), request, &pull); err != nil {
+		return nil, err
+	}
+	pr := pull.toPullRequest()
+	return &pr, nil
+}
+
+// MergePR merges a pull request using strategy ("merge", "squash" or "rebase")
+func (c *HTTPClient) MergePR(repo string, number int, strategy string, deleteRemoteBranch bool) error {
+	pr, err := c.GetPR(repo, number)
+	if err != nil {
+		return err
+	}
+
+	request := map[string]string{"merge_method": strategy, "sha": pr.HeadOid}
+	if err := c.do(http.MethodPut, fmt.Sprintf("repos/%s/pulls/%d/merge", repo, number), request, nil); err != nil {
+		return err
+	}
+
+	if deleteRemoteBranch {
+		// The repo may already delete head branches on merge
+		err := c.do(http.MethodDelete, fmt.Sprintf("repos/%s/git/refs/heads/%s", repo, pr.HeadRef), nil, nil)
+		if err != nil && !isNotFound(err) && !isUnprocessable(err) {
+			return fmt.Errorf("merged, but failed to delete branch %s: %w", pr.HeadRef, err)
+		}
+	}
+	return nil
+}
+
+// GetPRReviews returns reviews on a PR
+func (c *HTTPClient) GetPRReviews(repo string, number int) ([]PRReview, error) {
+	return listPRReviews(c, repo, number)
+}
+
+// GetPRComments returns the conversation comments on a PR, as gh pr view does
+func (c *HTTPClient) GetPRComments(repo string, number int) ([]PRComment, error) {
+	return listPRComments(c, repo, number)
+}
+
+// GetMergedPRsSince returns the PRs merged at or after since
+func (c *HTTPClient) GetMergedPRsSince(repo string, since time.Time) ([]PullRequest, error) {
+	return mergedPRsSince(c, repo, since)
+}
+
+// ListPRs returns all open PRs
+func (c *HTTPClient) ListPRs(repo string) ([]PullRequest, error) {
+	return listOpenPRs(c, repo)
+}
+
+// AddPRComment adds a comment to a PR
+func (c *HTTPClient) AddPRComment(repo string, number int, body string) error {
+	return c.AddIssueComment(repo, number, body)
+}
+
+// GetPRChecks returns the check runs and commit statuses on the head of a PR
+func (c *HTTPClient) GetPRChecks(repo string, number int) ([]PRCheck, error) {
+	pr, err := c.GetPR(repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	checks, err := listAllIn[PRCheck](c, fmt.Sprintf("repos/%s/commits/%s/check-runs", repo, pr.HeadOid), "check_runs")
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := listAllIn[restStatus](c, fmt.Sprintf("repos/%s/commits/%s/status", repo, pr.HeadOid), "statuses")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, status := range statuses {
+		check := PRCheck{Name: status.Context, Status: "completed", Conclusion: status.State}
+		switch status.State {
+		case "pending":
+			check.Status, check.Conclusion = "in_progress", ""
+		case "error":
+			check.Conclusion = "failure"
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// PRChecksPassing returns true if all checks have passed
+func (c *HTTPClient) PRChecksPassing(repo string, number int) (bool, error) {
+	checks, err := c.GetPRChecks(repo, number)
+	if err != nil {
+		return false, err
+	}
+	return checksPassing(checks), nil
+}
+
+// PRChecksRunning returns true if any checks are still running
+func (c *HTTPClient) PRChecksRunning(repo string, number int) (bool, error) {
+	checks, err := c.GetPRChecks(repo, number)
+	if err != nil {
+		return false, err
+	}
+	return checksRunning(checks), nil
+}
+
+// PRChecksFailed returns true if any checks have failed
+func (c *HTTPClient) PRChecksFailed(repo string, number int) (bool, error) {
+	checks, err := c.GetPRChecks(repo, number)
+	if err != nil {
+		return false, err
+	}
+	return checksFailed(checks), nil
+}
+
+// GetLatestWorkflowRun returns the latest workflow run for a branch
+func (c *HTTPClient) GetLatestWorkflowRun(repo, branch string) (*WorkflowRun, error) {
+	query := url.Values{"branch": {branch}, "per_page": {"1"}}
+	var result struct {
+		WorkflowRuns []struct {
+			ID         int       `json:"id"`
+			Name       string    `json:"name"`
+			Status     string    `json:"status"`
+			Conclusion string    `json:"conclusion"`
+			HTMLURL    string    `json:"html_url"`
+			CreatedAt  time.Time `json:"created_at"`
+		} `json:"workflow_runs"`
+	}
+	if err := c.get(fmt.Sprintf("repos/%s/actions/runs?%s", repo, query.Encode()), &result); err != nil {
+		return nil, err
+	}
+
+	if len(result.WorkflowRuns) == 0 {
+		return nil, nil
+	}
+	run := result.WorkflowRuns[0]
+	return &WorkflowRun{
+		ID:         run.ID,
+		Name:       run.Name,
+		Status:     run.Status,
+		Conclusion: run.Conclusion,
+		URL:        run.HTMLURL,
+		CreatedAt:  run.CreatedAt,
+	}, nil
+}
+
+// GetWorkflowRunLogs returns the logs of the failed jobs of a workflow run,
+// each line prefixed with its job name as gh run view --log-failed does
+func (c *HTTPClient) GetWorkflowRunLogs(repo string, runID int) (string, error) {
+	jobs, err := listAllIn[restJob](c, fmt.Sprintf("repos/%s/actions/runs/%d/jobs?filter=latest", repo, runID), "jobs")
+	if err != nil {
+		return "", err
+	}
+
+	var logs strings.Builder
+	for _, job := range jobs {
+		if job.Conclusion != "failure" {
+			continue
+		}
+		data, err := c.request(http.MethodGet, fmt.Sprintf("repos/%s/actions/jobs/%d/logs", repo, job.ID), nil)
+		if err != nil {
+			return "", err
+		}
+		for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+			fmt.Fprintf(&logs, "%s\t%s\n", job.Name, line)
+		}
+	}
+	return strings.TrimSpace(logs.String()), nil
+}
+
+// RerunFailedJobs re-runs the failed jobs of a workflow run
+func (c *HTTPClient) RerunFailedJobs(repo string, runID int) error {
+	return c.do(http.MethodPost, fmt.Sprintf("repos/%s/actions/runs/%d/rerun-failed-jobs", repo, runID), nil, nil)
+}
@@ -0,0 +1,224 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestHTTPClient serves the routes, keyed by "METHOD path", and records
+// the requests it gets
+func newTestHTTPClient(t *testing.T, routes map[string]func(w http.ResponseWriter, r *http.Request)) (*HTTPClient, *[]string) {
+	t.Helper()
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer test-token" {
+			t.Errorf("Authorization = %q, want the token", got)
+		}
+		route := r.Method + " " + r.URL.Path
+		requests = append(requests, route)
+		handler, ok := routes[route]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"message": "Not Found"}`)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return NewHTTPClient(server.URL+"/", "test-token"), &requests
+}
+
+// respond writes a JSON document
+func respond(body string) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}
+}
+
+func TestHTTPListIssuesWithLabel(t *testing.T) {
+	client, _ := newTestHTTPClient(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"GET /repos/owner/repo/issues": func(w http.ResponseWriter, r *http.Request) {
+			if got := r.URL.Query().Get("labels"); got != "user:ready-to-plan" {
+				t.Errorf("labels = %q, want user:ready-to-plan", got)
+			}
+			respond(`[
+				{"number": 1, "title": "Dark mode", "state": "open", "html_url": "https://github.com/owner/repo/issues/1",
+				 "labels": [{"name": "user:ready-to-plan"}]},
+				{"number": 2, "title": "A PR", "state": "open", "pull_request": {"merged_at": null}}
+			]`)(w, r)
+		},
+	})
+
+	issues, err := client.ListIssuesWithLabel("owner/repo", "user:ready-to-plan")
+	if err != nil {
+		t.Fatalf("ListIssuesWithLabel() error = %v", err)
+	}
+	if len(issues) != 1 {
+		t.Fatalf("got %d issues, want 1 without the PR", len(issues))
+	}
+	if issues[0].State != "OPEN" || issues[0].URL != "https://github.com/owner/repo/issues/1" || !issues[0].HasLabel("user:ready-to-plan") {
+		t.Errorf("issue = %+v, want it as gh reports it", issues[0])
+	}
+}
+
+func TestHTTPGetPRMergeStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		wantMergeable string
+		wantState     string
+		wantReady     bool
+	}{
+		{
+			name:          "clean",
+			body:          `{"number": 5, "state": "open", "mergeable": true, "mergeable_state": "clean", "head": {"ref": "dev-swarm/issue-1", "sha": "abc"}}`,
+			wantMergeable: "MERGEABLE",
+			wantState:     "OPEN",
+			wantReady:     true,
+		},
+		{
+			name:          "conflicting",
+			body:          `{"number": 5, "state": "open", "mergeable": false, "mergeable_state": "dirty"}`,
+			wantMergeable: "CONFLICTING",
+			wantState:     "OPEN",
+		},
+		{
+			name:          "still computing",
+			body:          `{"number": 5, "state": "open", "mergeable": null, "mergeable_state": "unknown"}`,
+			wantMergeable: "UNKNOWN",
+			wantState:     "OPEN",
+		},
+		{
+			name:          "merged",
+			body:          `{"number": 5, "state": "closed", "merged_at": "2024-06-14T12:00:00Z"}`,
+			wantMergeable: "UNKNOWN",
+			wantState:     "MERGED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestHTTPClient(t, map[string]func(w http.ResponseWriter, r *http.Request){
+				"GET /repos/owner/repo/pulls/5": respond(tt.body),
+			})
+
+			pr, err := client.GetPRMergeStatus("owner/repo", 5)
+			if err != nil {
+				t.Fatalf("GetPRMergeStatus() error = %v", err)
+			}
+			if pr.Mergeable != tt.wantMergeable || pr.State != tt.wantState {
+				t.Errorf("Mergeable, State = %q, %q, want %q, %q", pr.Mergeable, pr.State, tt.wantMergeable, tt.wantState)
+			}
+			if got := pr.ReadyToMerge(); got != tt.wantReady {
+				t.Errorf("ReadyToMerge() = %v, want %v", got, tt.wantReady)
+			}
+		})
+	}
+}
+
+func TestHTTPErrors(t *testing.T) {
+	client, _ := newTestHTTPClient(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"GET /repos/owner/repo/issues/1": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, `{"message": "Resource not accessible by integration"}`)
+		},
+	})
+
+	head, err := client.GetBranchHead("owner/repo", "missing")
+	if err != nil || head != "" {
+		t.Errorf("GetBranchHead() = %q, %v, want no head and no error for a missing branch", head, err)
+	}
+
+	_, err = client.GetIssue("owner/repo", 1)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("GetIssue() error = %v, want an APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusForbidden || apiErr.Message != "Resource not accessible by integration" {
+		t.Errorf("APIError = %+v, want the status and GitHub's message", apiErr)
+	}
+}
+
+func TestHTTPUpdateIssueLabels(t *testing.T) {
+	var added []string
+	client, requests := newTestHTTPClient(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"DELETE /repos/owner/repo/issues/3/labels/ai:planning": respond(`[]`),
+		"POST /repos/owner/repo/issues/3/labels": func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Labels []string `json:"labels"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			added = body.Labels
+			respond(`[]`)(w, r)
+		},
+	})
+
+	// The issue does not have user:blocked, which must not fail the update
+	err := client.UpdateIssueLabels("owner/repo", 3, []string{"ai:planning", "user:blocked"}, []string{"user:plan-review"})
+	if err != nil {
+		t.Fatalf("UpdateIssueLabels() error = %v", err)
+	}
+	if len(added) != 1 || added[0] != "user:plan-review" {
+		t.Errorf("added labels = %v, want [user:plan-review]", added)
+	}
+	if len(*requests) != 3 {
+		t.Errorf("requests = %v, want two removals and one addition", *requests)
+	}
+}
+
+func TestHTTPCreateLabelUpdatesExisting(t *testing.T) {
+	client, requests := newTestHTTPClient(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"POST /repos/owner/repo/labels": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			io.WriteString(w, `{"message": "Validation Failed"}`)
+		},
+		"PATCH /repos/owner/repo/labels/ai:planning": respond(`{}`),
+	})
+
+	if err := client.CreateLabel("owner/repo", "ai:planning", "1d76db", "AI is planning"); err != nil {
+		t.Fatalf("CreateLabel() error = %v", err)
+	}
+	if got := strings.Join(*requests, ", "); got != "POST /repos/owner/repo/labels, PATCH /repos/owner/repo/labels/ai:planning" {
+		t.Errorf("requests = %s, want a create then an update", got)
+	}
+}
+
+func TestHTTPGetPRChecks(t *testing.T) {
+	client, _ := newTestHTTPClient(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"GET /repos/owner/repo/pulls/5": respond(`{"number": 5, "state": "open", "head": {"sha": "abc"}}`),
+		"GET /repos/owner/repo/commits/abc/check-runs": respond(`{"check_runs": [
+			{"name": "test", "status": "completed", "conclusion": "success"},
+			{"name": "lint", "status": "completed", "conclusion": "skipped"}
+		]}`),
+		"GET /repos/owner/repo/commits/abc/status": respond(`{"statuses": [{"context": "ci/legacy", "state": "error"}]}`),
+	})
+
+	checks, err := client.GetPRChecks("owner/repo", 5)
+	if err != nil {
+		t.Fatalf("GetPRChecks() error = %v", err)
+	}
+	if len(checks) != 3 {
+		t.Fatalf("got %d checks, want check runs and statuses", len(checks))
+	}
+	if !checksFailed(checks) || checksRunning(checks) || checksPassing(checks) {
+		t.Errorf("checks = %+v, want the errored status to fail them", checks)
+	}
+}
+
+func TestResolveToken(t *testing.T) {
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "from-env")
+
+	if got, _ := ResolveToken("configured"); got != "configured" {
+		t.Errorf("ResolveToken() = %q, want the configured token", got)
+	}
+	if got, _ := ResolveToken(""); got != "from-env" {
+		t.Errorf("ResolveToken() = %q, want GITHUB_TOKEN", got)
+	}
+}
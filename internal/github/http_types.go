@@ -0,0 +1,166 @@
+package github
+
+import (
+	"strings"
+	"time"
+)
+
+// REST API documents, converted to the types the gh CLI reports so both
+// clients return the same data
+
+// restUser is a user as the REST API reports it
+type restUser struct {
+	Login string `json:"login"`
+}
+
+// restIssue is an issue, or a PR seen through the issues API
+type restIssue struct {
+	Number      int       `json:"number"`
+	Title       string    `json:"title"`
+	Body        string    `json:"body"`
+	State       string    `json:"state"`
+	HTMLURL     string    `json:"html_url"`
+	Labels      []Label   `json:"labels"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	PullRequest *struct {
+		MergedAt *time.Time `json:"merged_at"`
+	} `json:"pull_request"`
+}
+
+// toIssue converts the issue, with gh's upper case state
+func (i restIssue) toIssue() Issue {
+	return Issue{
+		Number:    i.Number,
+		Title:     i.Title,
+		Body:      i.Body,
+		State:     strings.ToUpper(i.State),
+		URL:       i.HTMLURL,
+		Labels:    i.Labels,
+		CreatedAt: i.CreatedAt,
+		UpdatedAt: i.UpdatedAt,
+	}
+}
+
+// restComment is an issue or PR conversation comment
+type restComment struct {
+	ID        int       `json:"id"`
+	User      restUser  `json:"user"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// toComment converts the comment
+func (c restComment) toComment() Comment {
+	return Comment{ID: c.ID, Author: Author{Login: c.User.Login}, Body: c.Body, CreatedAt: c.CreatedAt}
+}
+
+// toComments converts a list of comments
+func toComments(comments []restComment) []Comment {
+	result := make([]Comment, 0, len(comments))
+	for _, comment := range comments {
+		result = append(result, comment.toComment())
+	}
+	return result
+}
+
+// restPull is a pull request
+type restPull struct {
+	Number    int        `json:"number"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	State     string     `json:"state"`
+	HTMLURL   string     `json:"html_url"`
+	MergedAt  *time.Time `json:"merged_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Head      struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+
+	// Only reported for a single PR
+	Draft          bool   `json:"draft"`
+	Mergeable      *bool  `json:"mergeable"`       // null while GitHub computes it
+	MergeableState string `json:"mergeable_state"` // clean, blocked, behind, dirty, unstable, ...
+}
+
+// toPullRequest converts the PR, with gh's state and merge status values
+func (p restPull) toPullRequest() PullRequest {
+	pr := PullRequest{
+		Number:           p.Number,
+		Title:            p.Title,
+		Body:             p.Body,
+		State:            strings.ToUpper(p.State),
+		URL:              p.HTMLURL,
+		HeadRef:          p.Head.Ref,
+		BaseRef:          p.Base.Ref,
+		Merged:           p.MergedAt != nil,
+		CreatedAt:        p.CreatedAt,
+		HeadOid:          p.Head.SHA,
+		IsDraft:          p.Draft,
+		Mergeable:        "UNKNOWN",
+		MergeStateStatus: strings.ToUpper(p.MergeableState),
+	}
+	if pr.Merged {
+		pr.State = "MERGED"
+		pr.MergedAt = *p.MergedAt
+	}
+	if p.Mergeable != nil {
+		pr.Mergeable = "CONFLICTING"
+		if *p.Mergeable {
+			pr.Mergeable = "MERGEABLE"
+		}
+	}
+	return pr
+}
+
+// toPullRequests converts a list of PRs
+func toPullRequests(pulls []restPull) []PullRequest {
+	result := make([]PullRequest, 0, len(pulls))
+	for _, pull := range pulls {
+		result = append(result, pull.toPullRequest())
+	}
+	return result
+}
+
+// restReview is a PR review
+type restReview struct {
+	NodeID            string    `json:"node_id"`
+	User              restUser  `json:"user"`
+	AuthorAssociation string    `json:"author_association"`
+	Body              string    `json:"body"`
+	State             string    `json:"state"`
+	SubmittedAt       time.Time `json:"submitted_at"`
+	CommitID          string    `json:"commit_id"`
+}
+
+// toReview converts the review. Its ID is the node ID, as gh reports it.
+func (r restReview) toReview() PRReview {
+	review := PRReview{
+		ID:                r.NodeID,
+		Author:            Author{Login: r.User.Login},
+		AuthorAssociation: r.AuthorAssociation,
+		Body:              r.Body,
+		State:             r.State,
+		CreatedAt:         r.SubmittedAt,
+	}
+	review.Commit.Oid = r.CommitID
+	return review
+}
+
+// restStatus is a commit status
+type restStatus struct {
+	Context string `json:"context"`
+	State   string `json:"state"` // error, failure, pending or success
+}
+
+// restJob is a job of a workflow run
+type restJob struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Conclusion string `json:"conclusion"`
+}
@@ -6,38 +6,12 @@ import (
 
 // ListIssuesWithLabel returns all open issues with a specific label
 func (c *Client) ListIssuesWithLabel(repo, label string) ([]Issue, error) {
-	var issues []Issue
-	err := c.RunJSON(&issues,
-		"issue", "list",
-		"--repo", repo,
-		"--label", label,
-		"--state", "open",
-		"--json", "number,title,body,state,url,labels,createdAt,updatedAt",
-		"--limit", "100",
-	)
-	return issues, err
+	return listIssues(c, repo, label)
 }
 
 // ListIssuesWithLabels returns all open issues with any of the specified labels
 func (c *Client) ListIssuesWithLabels(repo string, labels []string) ([]Issue, error) {
-	var allIssues []Issue
-	seen := make(map[int]bool)
-
-	for _, label := range labels {
-		issues, err := c.ListIssuesWithLabel(repo, label)
-		if err != nil {
-			return nil, err
-		}
-
-		for _, issue := range issues {
-			if !seen[issue.Number] {
-				seen[issue.Number] = true
-				allIssues = append(allIssues, issue)
-			}
-		}
-	}
-
-	return allIssues, nil
+	return listIssuesWithLabels(c, repo, labels)
 }
 
 // GetIssue returns full issue details including comments
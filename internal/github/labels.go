@@ -2,14 +2,7 @@ package github
 
 // ListLabels returns all labels in a repo
 func (c *Client) ListLabels(repo string) ([]LabelInfo, error) {
-	var labels []LabelInfo
-	err := c.RunJSON(&labels,
-		"label", "list",
-		"--repo", repo,
-		"--json", "name,color,description",
-		"--limit", "100",
-	)
-	return labels, err
+	return listLabels(c, repo)
 }
 
 // CreateLabel creates a new label
@@ -30,13 +23,7 @@ func (c *Client) LabelExists(repo, name string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-
-	for _, l := range labels {
-		if l.Name == name {
-			return true, nil
-		}
-	}
-	return false, nil
+	return labelExists(labels, name), nil
 }
 
 // DeleteLabel deletes a label
@@ -51,11 +38,5 @@ func (c *Client) DeleteLabel(repo, name string) error {
 
 // SyncLabels ensures all required labels exist in the repo
 func (c *Client) SyncLabels(repo string, labels []LabelInfo) error {
-	for _, label := range labels {
-		err := c.CreateLabel(repo, label.Name, label.Color, label.Description)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return syncLabels(c, repo, labels)
 }
@@ -0,0 +1,196 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pager fetches the pages of REST API lists. Client and HTTPClient both
+// implement it, so lists are paged the same way whichever backend runs.
+type pager interface {
+	// page fetches a path relative to the API root, or the URL of a next
+	// page, and returns the URL of the page after it, or "" after the last
+	page(target string) (data []byte, next string, err error)
+
+	// perPage returns the number of items to request per page
+	perPage() int
+}
+
+// eachPage calls fn with each page of a REST API list, following the Link
+// headers GitHub pages lists with, until fn returns false or the list ends
+func eachPage(p pager, path string, fn func(data []byte) (bool, error)) error {
+	target := withPerPage(path, p.perPage())
+	for target != "" {
+		data, next, err := p.page(target)
+		if err != nil {
+			return err
+		}
+		more, err := fn(data)
+		if err != nil || !more {
+			return err
+		}
+		target = next
+	}
+	return nil
+}
+
+// listAll decodes every page of a REST API list
+func listAll[T any](p pager, path string) ([]T, error) {
+	var items []T
+	err := eachPage(p, path, func(data []byte) (bool, error) {
+		var page []T
+		if err := json.Unmarshal(data, &page); err != nil {
+			return false, fmt.Errorf("failed to decode %s response: %w", path, err)
+		}
+		items = append(items, page...)
+		return true, nil
+	})
+	return items, err
+}
+
+// listAllIn decodes every page of a REST API list that GitHub wraps in an
+// object, such as {"total_count": 2, "jobs": [...]}
+func listAllIn[T any](p pager, path, key string) ([]T, error) {
+	var items []T
+	err := eachPage(p, path, func(data []byte) (bool, error) {
+		var page map[string]json.RawMessage
+		if err := json.Unmarshal(data, &page); err != nil {
+			return false, fmt.Errorf("failed to decode %s response: %w", path, err)
+		}
+		var list []T
+		if raw, ok := page[key]; ok {
+			if err := json.Unmarshal(raw, &list); err != nil {
+				return false, fmt.Errorf("failed to decode %s response: %w", path, err)
+			}
+		}
+		items = append(items, list...)
+		return true, nil
+	})
+	return items, err
+}
+
+// withPerPage adds the page size to a path's query
+func withPerPage(path string, perPage int) string {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + "per_page=" + strconv.Itoa(perPage)
+}
+
+// nextPageURL returns the rel="next" target of a Link header, or ""
+func nextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		target, params, ok := strings.Cut(part, ";")
+		if ok && strings.Contains(params, `rel="next"`) {
+			return strings.Trim(strings.TrimSpace(target), "<>")
+		}
+	}
+	return ""
+}
+
+// listIssues returns the open issues with a label
+func listIssues(p pager, repo, label string) ([]Issue, error) {
+	query := url.Values{"labels": {label}, "state": {"open"}}
+	found, err := listAll[restIssue](p, fmt.Sprintf("repos/%s/issues?%s", repo, query.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]Issue, 0, len(found))
+	for _, issue := range found {
+		if issue.PullRequest == nil { // The issues API lists PRs too
+			issues = append(issues, issue.toIssue())
+		}
+	}
+	return issues, nil
+}
+
+// listIssueComments returns the comments on an issue or the conversation comments on a PR
+func listIssueComments(p pager, repo string, number int) ([]Comment, error) {
+	comments, err := listAll[restComment](p, fmt.Sprintf("repos/%s/issues/%d/comments", repo, number))
+	if err != nil {
+		return nil, err
+	}
+	return toComments(comments), nil
+}
+
+// listPRComments returns the conversation comments on a PR
+func listPRComments(p pager, repo string, number int) ([]PRComment, error) {
+	found, err := listAll[restComment](p, fmt.Sprintf("repos/%s/issues/%d/comments", repo, number))
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]PRComment, 0, len(found))
+	for _, comment := range found {
+		comments = append(comments, PRComment{
+			ID:        comment.ID,
+			Author:    Author{Login: comment.User.Login},
+			Body:      comment.Body,
+			CreatedAt: comment.CreatedAt,
+		})
+	}
+	return comments, nil
+}
+
+// listLabels returns the labels of a repo
+func listLabels(p pager, repo string) ([]LabelInfo, error) {
+	return listAll[LabelInfo](p, fmt.Sprintf("repos/%s/labels", repo))
+}
+
+// listPRReviews returns the reviews on a PR
+func listPRReviews(p pager, repo string, number int) ([]PRReview, error) {
+	found, err := listAll[restReview](p, fmt.Sprintf("repos/%s/pulls/%d/reviews", repo, number))
+	if err != nil {
+		return nil, err
+	}
+
+	reviews := make([]PRReview, 0, len(found))
+	for _, review := range found {
+		reviews = append(reviews, review.toReview())
+	}
+	return reviews, nil
+}
+
+// listOpenPRs returns the open PRs of a repo
+func listOpenPRs(p pager, repo string) ([]PullRequest, error) {
+	pulls, err := listAll[restPull](p, fmt.Sprintf("repos/%s/pulls?state=open", repo))
+	if err != nil {
+		return nil, err
+	}
+	return toPullRequests(pulls), nil
+}
+
+// mergedPRsSince returns the PRs merged at or after since, most recently
+// updated first. Closed PRs are read from the most recently updated, and
+// reading stops at the first one last updated before since.
+func mergedPRsSince(p pager, repo string, since time.Time) ([]PullRequest, error) {
+	query := url.Values{"state": {"closed"}, "sort": {"updated"}, "direction": {"desc"}}
+	path := fmt.Sprintf("repos/%s/pulls?%s", repo, query.Encode())
+
+	var merged []restPull
+	err := eachPage(p, path, func(data []byte) (bool, error) {
+		var pulls []restPull
+		if err := json.Unmarshal(data, &pulls); err != nil {
+			return false, fmt.Errorf("failed to decode %s response: %w", path, err)
+		}
+		for _, pull := range pulls {
+			if pull.UpdatedAt.Before(since) {
+				return false, nil
+			}
+			if pull.MergedAt != nil && !pull.MergedAt.Before(since) {
+				merged = append(merged, pull)
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toPullRequests(merged), nil
+}
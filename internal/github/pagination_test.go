@@ -0,0 +1,125 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHTTPListLabelsPages(t *testing.T) {
+	var serverURL string
+	client, requests := newTestHTTPClient(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"GET /repos/owner/repo/labels": func(w http.ResponseWriter, r *http.Request) {
+			if got := r.URL.Query().Get("per_page"); got != "2" {
+				t.Errorf("per_page = %q, want 2", got)
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repositories/1/labels?per_page=2&page=2>; rel="next", <%s/repositories/1/labels?per_page=2&page=2>; rel="last"`, serverURL, serverURL))
+			respond(`[{"name": "bug"}, {"name": "docs"}]`)(w, r)
+		},
+		"GET /repositories/1/labels": func(w http.ResponseWriter, r *http.Request) {
+			if got := r.URL.Query().Get("page"); got != "2" {
+				t.Errorf("page = %q, want 2", got)
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repositories/1/labels?per_page=2&page=1>; rel="prev"`, serverURL))
+			respond(`[{"name": "user:ready-to-plan"}]`)(w, r)
+		},
+	})
+	serverURL = client.baseURL
+	client.SetPageSize(2)
+
+	labels, err := client.ListLabels("owner/repo")
+	if err != nil {
+		t.Fatalf("ListLabels() error = %v", err)
+	}
+	if len(labels) != 3 || !labelExists(labels, "user:ready-to-plan") {
+		t.Errorf("ListLabels() = %+v, want the labels of both pages", labels)
+	}
+	if len(*requests) != 2 {
+		t.Errorf("requests = %v, want 2", *requests)
+	}
+}
+
+func TestHTTPGetMergedPRsSince(t *testing.T) {
+	var serverURL string
+	client, requests := newTestHTTPClient(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"GET /repos/owner/repo/pulls": func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("sort") != "updated" || r.URL.Query().Get("direction") != "desc" {
+				t.Errorf("query = %v, want the most recently updated first", r.URL.Query())
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repositories/1/pulls?page=2>; rel="next"`, serverURL))
+			respond(`[
+				{"number": 4, "state": "closed", "merged_at": "2024-06-14T12:00:00Z", "updated_at": "2024-06-14T12:05:00Z", "head": {"ref": "claude/issue-3"}},
+				{"number": 6, "state": "closed", "merged_at": null, "updated_at": "2024-06-14T11:00:00Z"},
+				{"number": 2, "state": "closed", "merged_at": "2024-06-13T09:00:00Z", "updated_at": "2024-06-14T10:00:00Z"},
+				{"number": 1, "state": "closed", "merged_at": "2024-06-01T09:00:00Z", "updated_at": "2024-06-01T09:00:00Z"}
+			]`)(w, r)
+		},
+	})
+	serverURL = client.baseURL
+
+	since := time.Date(2024, 6, 14, 0, 0, 0, 0, time.UTC)
+	prs, err := client.GetMergedPRsSince("owner/repo", since)
+	if err != nil {
+		t.Fatalf("GetMergedPRsSince() error = %v", err)
+	}
+	if len(prs) != 1 || prs[0].Number != 4 || prs[0].State != "MERGED" || !prs[0].MergedAt.Equal(since.Add(12*time.Hour)) {
+		t.Errorf("GetMergedPRsSince() = %+v, want only #4", prs)
+	}
+	if len(*requests) != 1 {
+		t.Errorf("requests = %v, want the next page skipped once PRs predate since", *requests)
+	}
+}
+
+func TestNextPageURL(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"", ""},
+		{`<https://api.github.com/repositories/1/issues?page=2>; rel="next", <https://api.github.com/repositories/1/issues?page=5>; rel="last"`, "https://api.github.com/repositories/1/issues?page=2"},
+		{`<https://api.github.com/repositories/1/issues?page=4>; rel="prev", <https://api.github.com/repositories/1/issues?page=1>; rel="first"`, ""},
+	}
+
+	for _, tt := range tests {
+		if got := nextPageURL(tt.link); got != tt.want {
+			t.Errorf("nextPageURL(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestAPIPath(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"repos/owner/repo/labels?per_page=100", "repos/owner/repo/labels?per_page=100"},
+		{"https://api.github.com/repositories/1/labels?per_page=100&page=2", "repositories/1/labels?per_page=100&page=2"},
+		{"https://github.example.com/api/v3/repositories/1/labels?page=2", "repositories/1/labels?page=2"},
+	}
+
+	for _, tt := range tests {
+		if got := apiPath(tt.target); got != tt.want {
+			t.Errorf("apiPath(%q) = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestSplitIncluded(t *testing.T) {
+	output := "HTTP/2.0 200 OK\r\n" +
+		"Content-Type: application/json; charset=utf-8\r\n" +
+		"Link: <https://api.github.com/repositories/1/labels?page=2>; rel=\"next\"\r\n" +
+		"\r\n" +
+		`[{"name": "bug"}]`
+
+	header, body, err := splitIncluded(output)
+	if err != nil {
+		t.Fatalf("splitIncluded() error = %v", err)
+	}
+	if got := nextPageURL(header.Get("Link")); got != "https://api.github.com/repositories/1/labels?page=2" {
+		t.Errorf("next page = %q, want page 2", got)
+	}
+	if string(body) != `[{"name": "bug"}]` {
+		t.Errorf("body = %q, want the JSON after the headers", body)
+	}
+}
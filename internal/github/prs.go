@@ -2,6 +2,7 @@ package github
 
 import (
 	"fmt"
+	"time"
 )
 
 // GetPRForBranch finds a PR for a specific branch
@@ -12,7 +13,7 @@ func (c *Client) GetPRForBranch(repo, branch string) (*PullRequest, error) {
 		"--repo", repo,
 		"--head", branch,
 		"--state", "open",
-		"--json", "number,title,body,state,url,headRefName,baseRefName,merged,createdAt",
+		"--json", "number,title,body,state,url,headRefName,baseRefName,merged,createdAt,headRefOid",
 	)
 	if err != nil {
 		return nil, err
@@ -30,7 +31,21 @@ func (c *Client) GetPR(repo string, number int) (*PullRequest, error) {
 	err := c.RunJSON(&pr,
 		"pr", "view", fmt.Sprintf("%d", number),
 		"--repo", repo,
-		"--json", "number,title,body,state,url,headRefName,baseRefName,merged,createdAt",
+		"--json", "number,title,body,state,url,headRefName,baseRefName,merged,createdAt,headRefOid",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// GetPRMergeStatus returns a PR with its draft, mergeable and merge state fields filled
+func (c *Client) GetPRMergeStatus(repo string, number int) (*PullRequest, error) {
+	var pr PullRequest
+	err := c.RunJSON(&pr,
+		"pr", "view", fmt.Sprintf("%d", number),
+		"--repo", repo,
+		"--json", "number,state,headRefName,headRefOid,isDraft,mergeable,mergeStateStatus",
 	)
 	if err != nil {
 		return nil, err
@@ -56,12 +71,12 @@ func (c *Client) CreatePR(repo, title, body, head, base string) (*PullRequest, e
 	return c.GetPRForBranch(repo, head)
 }
 
-// MergePR merges a pull request
-func (c *Client) MergePR(repo string, number int, deleteRemoteBranch bool) error {
+// MergePR merges a pull request using strategy ("merge", "squash" or "rebase")
+func (c *Client) MergePR(repo string, number int, strategy string, deleteRemoteBranch bool) error {
 	args := []string{
 		"pr", "merge", fmt.Sprintf("%d", number),
 		"--repo", repo,
-		"--merge",
+		"--" + strategy,
 	}
 
 	if deleteRemoteBranch {
@@ -74,8 +89,10 @@ func (c *Client) MergePR(repo string, number int, deleteRemoteBranch bool) error
 
 // GetPRReviews returns reviews on a PR
 func (c *Client) GetPRReviews(repo string, number int) ([]PRReview, error) {
-	var reviews []PRReview
-	err := c.RunJSON(&reviews,
+	var result struct {
+		Reviews []PRReview `json:"reviews"`
+	}
+	err := c.RunJSON(&result,
 		"pr", "view", fmt.Sprintf("%d", number),
 		"--repo", repo,
 		"--json", "reviews",
@@ -83,7 +100,7 @@ func (c *Client) GetPRReviews(repo string, number int) ([]PRReview, error) {
 	if err != nil {
 		return nil, err
 	}
-	return reviews, nil
+	return result.Reviews, nil
 }
 
 // GetPRComments returns review comments on a PR
@@ -102,29 +119,14 @@ func (c *Client) GetPRComments(repo string, number int) ([]PRComment, error) {
 	return result.Comments, nil
 }
 
-// GetMergedPRs returns recently merged PRs
-func (c *Client) GetMergedPRs(repo string) ([]PullRequest, error) {
-	var prs []PullRequest
-	err := c.RunJSON(&prs,
-		"pr", "list",
-		"--repo", repo,
-		"--state", "merged",
-		"--json", "number,title,headRefName,merged",
-		"--limit", "50",
-	)
-	return prs, err
+// GetMergedPRsSince returns the PRs merged at or after since
+func (c *Client) GetMergedPRsSince(repo string, since time.Time) ([]PullRequest, error) {
+	return mergedPRsSince(c, repo, since)
 }
 
 // ListPRs returns all open PRs
 func (c *Client) ListPRs(repo string) ([]PullRequest, error) {
-	var prs []PullRequest
-	err := c.RunJSON(&prs,
-		"pr", "list",
-		"--repo", repo,
-		"--state", "open",
-		"--json", "number,title,body,state,url,headRefName,baseRefName,createdAt",
-	)
-	return prs, err
+	return listOpenPRs(c, repo)
 }
 
 // AddPRComment adds a comment to a PR
@@ -0,0 +1,274 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backoff after a secondary rate limit that did not say how long to wait.
+// GitHub asks for at least a minute, longer if the limit is hit again.
+const (
+	secondaryBackoff    = time.Minute
+	maxSecondaryBackoff = 15 * time.Minute
+)
+
+// RateLimit is a GitHub API quota as the last response that reported it
+// left it
+type RateLimit struct {
+	Resource  string // "core", "graphql", ...
+	Limit     int    // Requests allowed per window; zero if no response reported it yet
+	Remaining int
+	Reset     time.Time // When the window ends and Remaining goes back to Limit
+	Observed  time.Time // When a response reported it
+
+	// Requests that spend it are held back until then after it ran out or
+	// GitHub refused one for a rate limit; zero if they are not
+	HeldUntil time.Time
+}
+
+// Known checks if a response has reported the quota
+func (r RateLimit) Known() bool {
+	return r.Limit > 0
+}
+
+// RateLimitError is a request GitHub refused, or that was held back,
+// because a rate limit was hit
+type RateLimitError struct {
+	Until     time.Time // When requests are sent again
+	Secondary bool      // A secondary rate limit rather than the hourly quota
+}
+
+func (e *RateLimitError) Error() string {
+	kind := "rate limit"
+	if e.Secondary {
+		kind = "secondary rate limit"
+	}
+	return fmt.Sprintf("GitHub %s hit, requests held until %s", kind, e.Until.Format("15:04:05"))
+}
+
+// IsRateLimited checks if an error comes from a GitHub rate limit
+func IsRateLimited(err error) bool {
+	var rateErr *RateLimitError
+	return errors.As(err, &rateErr)
+}
+
+// rateLimiter tracks the quotas a client's responses report and holds back
+// its requests while GitHub is refusing them for a rate limit. A quota that
+// runs out only holds back requests that spend it; a secondary limit holds
+// back every request.
+type rateLimiter struct {
+	mu      sync.Mutex
+	limits  map[string]RateLimit // By resource
+	holds   map[string]time.Time // Requests are held back until then, by resource; allResources for all
+	strikes int                  // Secondary limits in a row, for the backoff
+
+	now    func() time.Time
+	jitter func(d time.Duration) time.Duration
+}
+
+// newRateLimiter creates a limiter on the system clock
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		limits: make(map[string]RateLimit),
+		holds:  make(map[string]time.Time),
+		now:    time.Now,
+		jitter: jitter,
+	}
+}
+
+// allResources is the key of holds that apply to every resource
+const allResources = ""
+
+// jitter returns a random delay of up to a fifth of d plus a second, so
+// clients that were refused together do not retry together
+func jitter(d time.Duration) time.Duration {
+	return rand.N(d/5 + time.Second)
+}
+
+// resourceOf returns the quota a request to an API path spends
+func resourceOf(path string) string {
+	switch {
+	case path == "graphql":
+		return "graphql"
+	case strings.HasPrefix(path, "search/"):
+		return "search"
+	default:
+		return "core"
+	}
+}
+
+// check returns an error instead of letting a request that spends resource go
+// while a rate limit holds it back
+func (l *rateLimiter) check(resource string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := l.heldUntil(resource); l.now().Before(until) {
+		return &RateLimitError{Until: until, Secondary: l.strikes > 0}
+	}
+	return nil
+}
+
+// heldUntil returns when requests that spend resource are sent again.
+// Callers hold l.mu.
+func (l *rateLimiter) heldUntil(resource string) time.Time {
+	until := l.holds[allResources]
+	if held := l.holds[resource]; held.After(until) {
+		until = held
+	}
+	return until
+}
+
+// hold holds back requests that spend resource, or every request for
+// allResources, until the given time. Callers hold l.mu.
+func (l *rateLimiter) hold(resource string, until time.Time) {
+	if until.After(l.holds[resource]) {
+		l.holds[resource] = until
+	}
+}
+
+// observe records the quota a response reported. Spending the last of it
+// holds requests back until it resets.
+func (l *rateLimiter) observe(header http.Header) {
+	limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return
+	}
+	remaining, _ := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	reset, _ := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	resource := header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = "core"
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rl := RateLimit{
+		Resource:  resource,
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Unix(reset, 0),
+		Observed:  l.now(),
+	}
+	l.limits[resource] = rl
+	if remaining == 0 {
+		l.hold(resource, rl.Reset.Add(l.jitter(time.Second)))
+	}
+}
+
+// succeeded notes a request GitHub accepted
+func (l *rateLimiter) succeeded() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.strikes = 0
+}
+
+// refused checks if GitHub refused a request that spends resource for a rate
+// limit, going by the response status, headers and message, and if so holds
+// back requests until the limit passes: those that spend the same quota if it
+// ran out, all of them for a secondary limit. Without a status or headers, as
+// when gh failed, only the message is looked at. Returns the error to report,
+// or nil if the request was refused for another reason.
+func (l *rateLimiter) refused(resource string, status int, header http.Header, message string) error {
+	if status != 0 && status != http.StatusForbidden && status != http.StatusTooManyRequests {
+		return nil
+	}
+	message = strings.ToLower(message)
+	secondary := strings.Contains(message, "secondary rate limit")
+	primary := strings.Contains(message, "api rate limit exceeded") || header.Get("X-RateLimit-Remaining") == "0"
+	retryAfter, hasRetryAfter := parseRetryAfter(header.Get("Retry-After"))
+	if !secondary && !primary && !hasRetryAfter {
+		return nil
+	}
+
+	if r := header.Get("X-RateLimit-Resource"); r != "" {
+		resource = r
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	var wait time.Duration
+	switch {
+	case hasRetryAfter:
+		wait = retryAfter
+	case primary && !secondary:
+		if rl, ok := l.limits[resource]; ok && rl.Reset.After(now) {
+			wait = rl.Reset.Sub(now)
+		} else {
+			wait = secondaryBackoff
+		}
+	default:
+		wait = secondaryBackoff << l.strikes
+		if wait > maxSecondaryBackoff || wait <= 0 {
+			wait = maxSecondaryBackoff
+		}
+	}
+	if secondary || !primary {
+		l.strikes++
+		resource = allResources
+	}
+
+	l.hold(resource, now.Add(wait+l.jitter(wait)))
+	return &RateLimitError{Until: l.heldUntil(resource), Secondary: secondary || !primary}
+}
+
+// parseRetryAfter parses a Retry-After header in seconds
+func parseRetryAfter(value string) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// quotas returns the quotas responses reported, by resource, with those
+// whose window ended back at their limit and when requests that spend them
+// are held back until. Callers hold l.mu.
+func (l *rateLimiter) quotas() map[string]RateLimit {
+	now := l.now()
+	quotas := make(map[string]RateLimit, len(l.limits))
+	for resource, rl := range l.limits {
+		if !rl.Reset.After(now) {
+			rl.Remaining = rl.Limit // A new window started
+		}
+		if until := l.heldUntil(resource); now.Before(until) {
+			rl.HeldUntil = until
+		}
+		quotas[resource] = rl
+	}
+	return quotas
+}
+
+// all returns the quota of every resource responses reported, by resource
+func (l *rateLimiter) all() map[string]RateLimit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.quotas()
+}
+
+// status returns the quota closest to running out, with the latest time any
+// requests are held back until
+func (l *rateLimiter) status() RateLimit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var tightest RateLimit
+	for _, rl := range l.quotas() {
+		if !tightest.Known() || rl.Remaining*tightest.Limit < tightest.Remaining*rl.Limit {
+			tightest = rl
+		}
+	}
+	tightest.HeldUntil = time.Time{}
+	for _, until := range l.holds {
+		if l.now().Before(until) && until.After(tightest.HeldUntil) {
+			tightest.HeldUntil = until
+		}
+	}
+	return tightest
+}
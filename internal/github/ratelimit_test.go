@@ -0,0 +1,248 @@
+package github
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newTestRateLimiter creates a limiter on a fixed clock without jitter
+func newTestRateLimiter(now *time.Time) *rateLimiter {
+	l := newRateLimiter()
+	l.now = func() time.Time { return *now }
+	l.jitter = func(time.Duration) time.Duration { return 0 }
+	return l
+}
+
+// quotaHeader builds the rate limit headers of a response
+func quotaHeader(resource string, limit, remaining int, reset time.Time) http.Header {
+	header := http.Header{}
+	header.Set("X-RateLimit-Resource", resource)
+	header.Set("X-RateLimit-Limit", strconv.Itoa(limit))
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	return header
+}
+
+func TestRateLimiterStatus(t *testing.T) {
+	now := time.Date(2024, 6, 14, 12, 0, 0, 0, time.UTC)
+	l := newTestRateLimiter(&now)
+
+	if l.status().Known() {
+		t.Errorf("status() = %+v, want unknown before any response", l.status())
+	}
+
+	l.observe(quotaHeader("core", 5000, 4000, now.Add(30*time.Minute)))
+	l.observe(quotaHeader("graphql", 5000, 1000, now.Add(10*time.Minute)))
+	if got := l.status(); got.Resource != "graphql" || got.Remaining != 1000 {
+		t.Errorf("status() = %+v, want the graphql quota closest to running out", got)
+	}
+
+	now = now.Add(20 * time.Minute)
+	if got := l.status(); got.Resource != "core" || got.Remaining != 4000 {
+		t.Errorf("status() = %+v, want core once the graphql window reset", got)
+	}
+	if got := l.all(); len(got) != 2 || got["core"].Remaining != 4000 || got["graphql"].Remaining != 5000 {
+		t.Errorf("all() = %+v, want both quotas with graphql back at its limit", got)
+	}
+}
+
+func TestRateLimiterRefused(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		header   http.Header
+		message  string
+		strikes  int
+		wantWait time.Duration // Zero if the refusal is not a rate limit
+	}{
+		{
+			name:     "retry after",
+			status:   http.StatusTooManyRequests,
+			header:   http.Header{"Retry-After": {"30"}},
+			wantWait: 30 * time.Second,
+		},
+		{
+			name:     "secondary without retry after",
+			status:   http.StatusForbidden,
+			message:  "You have exceeded a secondary rate limit",
+			wantWait: time.Minute,
+		},
+		{
+			name:     "repeated secondary backs off",
+			status:   http.StatusForbidden,
+			message:  "You have exceeded a secondary rate limit",
+			strikes:  2,
+			wantWait: 4 * time.Minute,
+		},
+		{
+			name:     "secondary backoff is capped",
+			status:   http.StatusForbidden,
+			message:  "You have exceeded a secondary rate limit",
+			strikes:  10,
+			wantWait: 15 * time.Minute,
+		},
+		{
+			name:     "quota spent waits for the reset",
+			status:   http.StatusForbidden,
+			header:   quotaHeader("core", 5000, 0, time.Date(2024, 6, 14, 12, 40, 0, 0, time.UTC)),
+			message:  "API rate limit exceeded for user ID 1.",
+			wantWait: 40 * time.Minute,
+		},
+		{
+			name:    "gh failure",
+			message: "gh: You have exceeded a secondary rate limit. (HTTP 403)",
+			// Status 0: only the message is known
+			wantWait: time.Minute,
+		},
+		{
+			name:    "permission denied",
+			status:  http.StatusForbidden,
+			message: "Resource not accessible by integration",
+		},
+		{
+			name:    "not found",
+			status:  http.StatusNotFound,
+			message: "Not Found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 6, 14, 12, 0, 0, 0, time.UTC)
+			l := newTestRateLimiter(&now)
+			l.strikes = tt.strikes
+			if tt.header != nil {
+				l.observe(tt.header)
+			}
+
+			err := l.refused("core", tt.status, tt.header, tt.message)
+			if tt.wantWait == 0 {
+				if err != nil {
+					t.Errorf("refused() = %v, want nil", err)
+				}
+				if l.check("core") != nil {
+					t.Errorf("check() = %v, want requests let through", l.check("core"))
+				}
+				return
+			}
+
+			rateErr, ok := err.(*RateLimitError)
+			if !ok {
+				t.Fatalf("refused() = %v, want a RateLimitError", err)
+			}
+			if want := now.Add(tt.wantWait); !rateErr.Until.Equal(want) {
+				t.Errorf("Until = %v, want %v", rateErr.Until, want)
+			}
+			if !IsRateLimited(l.check("core")) {
+				t.Errorf("check() = nil, want requests held back")
+			}
+			now = rateErr.Until
+			if err := l.check("core"); err != nil {
+				t.Errorf("check() = %v, want requests let through once the limit passed", err)
+			}
+		})
+	}
+}
+
+func TestRateLimiterHoldsResource(t *testing.T) {
+	now := time.Date(2024, 6, 14, 12, 0, 0, 0, time.UTC)
+	l := newTestRateLimiter(&now)
+
+	// Spending the search quota only holds back searches
+	l.observe(quotaHeader("core", 5000, 4000, now.Add(30*time.Minute)))
+	l.observe(quotaHeader("search", 30, 0, now.Add(time.Minute)))
+	if !IsRateLimited(l.check("search")) {
+		t.Errorf("check(search) = nil, want searches held back")
+	}
+	for _, resource := range []string{"core", "graphql"} {
+		if err := l.check(resource); err != nil {
+			t.Errorf("check(%s) = %v, want requests let through", resource, err)
+		}
+	}
+	if got := l.all(); !got["search"].HeldUntil.Equal(now.Add(time.Minute)) || !got["core"].HeldUntil.IsZero() {
+		t.Errorf("all() = %+v, want only search held back", got)
+	}
+
+	// A refused GraphQL query holds back GraphQL, not REST
+	err := l.refused("graphql", http.StatusForbidden, nil, "API rate limit exceeded for user ID 1.")
+	if !IsRateLimited(err) || !IsRateLimited(l.check("graphql")) {
+		t.Errorf("refused(graphql) = %v, want GraphQL held back", err)
+	}
+	if err := l.check("core"); err != nil {
+		t.Errorf("check(core) = %v, want REST let through", err)
+	}
+
+	// A secondary limit holds back everything
+	l.refused("core", http.StatusForbidden, nil, "You have exceeded a secondary rate limit")
+	if !IsRateLimited(l.check("core")) {
+		t.Errorf("check(core) = nil, want requests held back after a secondary limit")
+	}
+	if got := l.status().HeldUntil; !got.Equal(now.Add(time.Minute)) {
+		t.Errorf("status().HeldUntil = %v, want the latest hold", got)
+	}
+}
+
+func TestCommandResource(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"api", "graphql", "--include", "--input", "-"}, "graphql"},
+		{[]string{"api", "--include", "repos/owner/repo/issues?per_page=100"}, "core"},
+		{[]string{"api", "search/issues?q=repo:owner/repo"}, "search"},
+		{[]string{"issue", "edit", "1", "--add-label", "ai:planning"}, "graphql"},
+		{[]string{"run", "rerun", "42", "--failed"}, "core"},
+	}
+	for _, tt := range tests {
+		if got := commandResource(tt.args); got != tt.want {
+			t.Errorf("commandResource(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimiterJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if got := jitter(time.Minute); got < 0 || got > 13*time.Second {
+			t.Fatalf("jitter(1m) = %v, want between 0 and 13s", got)
+		}
+	}
+}
+
+func TestHTTPRateLimited(t *testing.T) {
+	client, requests := newTestHTTPClient(t, map[string]func(w http.ResponseWriter, r *http.Request){
+		"GET /repos/owner/repo/labels": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, `{"message": "You have exceeded a secondary rate limit."}`)
+		},
+		"GET /user": func(w http.ResponseWriter, r *http.Request) {
+			for k, v := range quotaHeader("core", 5000, 4999, time.Now().Add(time.Hour)) {
+				w.Header()[k] = v
+			}
+			respond(`{"login": "dev-swarm"}`)(w, r)
+		},
+	})
+
+	if _, err := client.GetAuthenticatedUser(); err != nil {
+		t.Fatalf("GetAuthenticatedUser() error = %v", err)
+	}
+	if got := client.RateLimit(); got.Remaining != 4999 || got.Limit != 5000 || !got.HeldUntil.IsZero() {
+		t.Errorf("RateLimit() = %+v, want 4999 of 5000 left", got)
+	}
+
+	if _, err := client.ListLabels("owner/repo"); !IsRateLimited(err) {
+		t.Fatalf("ListLabels() error = %v, want a rate limit error", err)
+	}
+	if _, err := client.GetAuthenticatedUser(); !IsRateLimited(err) {
+		t.Errorf("GetAuthenticatedUser() error = %v, want the request held back", err)
+	}
+	if len(*requests) != 2 {
+		t.Errorf("requests = %v, want none sent while held back", *requests)
+	}
+	if got := client.RateLimit().HeldUntil; time.Until(got) < 59*time.Second {
+		t.Errorf("RateLimit().HeldUntil = %v, want at least a minute away", got)
+	}
+}
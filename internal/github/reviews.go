@@ -0,0 +1,55 @@
+package github
+
+// Review states reported by GitHub
+const (
+	ReviewApproved         = "APPROVED"
+	ReviewChangesRequested = "CHANGES_REQUESTED"
+	ReviewCommented        = "COMMENTED"
+	ReviewDismissed        = "DISMISSED"
+)
+
+// ReviewDecision returns the review that decides a PR. Only each reviewer's
+// latest approval or change request of the head commit counts, and only for
+// reviewers accepted by accept. A change request wins over approvals, unless
+// it is the handled one and a reviewer approved after it. Returns nil if no
+// review decides the PR.
+func ReviewDecision(reviews []PRReview, headOid, handled string, accept func(PRReview) bool) *PRReview {
+	latest := make(map[string]PRReview)
+	for _, review := range reviews {
+		switch review.State {
+		case ReviewApproved, ReviewChangesRequested, ReviewDismissed:
+		default:
+			continue // Comments do not change a reviewer's decision
+		}
+		if review.Commit.Oid != headOid || !accept(review) {
+			continue
+		}
+		if prev, ok := latest[review.Author.Login]; ok && prev.CreatedAt.After(review.CreatedAt) {
+			continue
+		}
+		latest[review.Author.Login] = review
+	}
+
+	var changes, approval *PRReview
+	for _, review := range latest {
+		review := review
+		switch review.State {
+		case ReviewChangesRequested:
+			if changes == nil || review.CreatedAt.After(changes.CreatedAt) {
+				changes = &review
+			}
+		case ReviewApproved:
+			if approval == nil || review.CreatedAt.After(approval.CreatedAt) {
+				approval = &review
+			}
+		}
+	}
+
+	if changes == nil {
+		return approval
+	}
+	if changes.ID == handled && approval != nil && approval.CreatedAt.After(changes.CreatedAt) {
+		return approval
+	}
+	return changes
+}
@@ -0,0 +1,136 @@
+package github
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReviewDecision(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	review := func(id, login, state, oid string, minutes int) PRReview {
+		r := PRReview{
+			ID:                id,
+			Author:            Author{Login: login},
+			AuthorAssociation: "COLLABORATOR",
+			State:             state,
+			CreatedAt:         base.Add(time.Duration(minutes) * time.Minute),
+		}
+		r.Commit.Oid = oid
+		return r
+	}
+	acceptAll := func(PRReview) bool { return true }
+
+	tests := []struct {
+		name    string
+		reviews []PRReview
+		handled string
+		accept  func(PRReview) bool
+		want    string
+	}{
+		{
+			name:    "no reviews",
+			reviews: nil,
+			accept:  acceptAll,
+			want:    "",
+		},
+		{
+			name:    "approval of head",
+			reviews: []PRReview{review("r1", "alice", ReviewApproved, "head", 1)},
+			accept:  acceptAll,
+			want:    "r1",
+		},
+		{
+			name:    "approval of an older commit",
+			reviews: []PRReview{review("r1", "alice", ReviewApproved, "old", 1)},
+			accept:  acceptAll,
+			want:    "",
+		},
+		{
+			name: "comment does not replace approval",
+			reviews: []PRReview{
+				review("r1", "alice", ReviewApproved, "head", 1),
+				review("r2", "alice", ReviewCommented, "head", 2),
+			},
+			accept: acceptAll,
+			want:   "r1",
+		},
+		{
+			name: "change request wins over approval",
+			reviews: []PRReview{
+				review("r1", "alice", ReviewApproved, "head", 2),
+				review("r2", "bob", ReviewChangesRequested, "head", 1),
+			},
+			accept: acceptAll,
+			want:   "r2",
+		},
+		{
+			name: "later approval replaces own change request",
+			reviews: []PRReview{
+				review("r1", "alice", ReviewChangesRequested, "old", 1),
+				review("r2", "alice", ReviewApproved, "head", 2),
+			},
+			accept: acceptAll,
+			want:   "r2",
+		},
+		{
+			name: "change request of an older commit",
+			reviews: []PRReview{
+				review("r1", "bob", ReviewChangesRequested, "old", 1),
+				review("r2", "alice", ReviewApproved, "head", 2),
+			},
+			accept: acceptAll,
+			want:   "r2",
+		},
+		{
+			name: "handled change request yields to a later approval",
+			reviews: []PRReview{
+				review("r1", "bob", ReviewChangesRequested, "head", 1),
+				review("r2", "alice", ReviewApproved, "head", 2),
+			},
+			handled: "r1",
+			accept:  acceptAll,
+			want:    "r2",
+		},
+		{
+			name: "handled change request still wins over an earlier approval",
+			reviews: []PRReview{
+				review("r1", "alice", ReviewApproved, "head", 1),
+				review("r2", "bob", ReviewChangesRequested, "head", 2),
+			},
+			handled: "r2",
+			accept:  acceptAll,
+			want:    "r2",
+		},
+		{
+			name: "dismissed approval",
+			reviews: []PRReview{
+				review("r1", "alice", ReviewApproved, "head", 1),
+				review("r2", "alice", ReviewDismissed, "head", 2),
+			},
+			accept: acceptAll,
+			want:   "",
+		},
+		{
+			name: "unaccepted reviewer is ignored",
+			reviews: []PRReview{
+				review("r1", "alice", ReviewApproved, "head", 1),
+				review("r2", "mallory", ReviewChangesRequested, "head", 2),
+			},
+			accept: func(r PRReview) bool { return r.Author.Login != "mallory" },
+			want:   "r1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ReviewDecision(tt.reviews, "head", tt.handled, tt.accept)
+			gotID := ""
+			if got != nil {
+				gotID = got.ID
+			}
+			if gotID != tt.want {
+				t.Errorf("ReviewDecision() = %q, want %q", gotID, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package github
+
+// RepoSnapshot is what one bulk fetch reports about a repository: its open
+// issues with any of the requested labels, with their comments, and its open
+// PRs with their comments, reviews and checks
+type RepoSnapshot struct {
+	Repo   string
+	Labels []string // The labels issues were fetched for
+	Issues []IssueSnapshot
+	PRs    []PRSnapshot
+
+	// False if the repository had more open issues or PRs than the fetch returned
+	IssuesComplete bool
+	PRsComplete    bool
+}
+
+// IssueSnapshot is an issue with its comments
+type IssueSnapshot struct {
+	Issue
+	CommentsComplete bool // False if the issue has more comments than were fetched
+}
+
+// PRSnapshot is an open PR with its conversation comments, reviews and the
+// checks on its head commit
+type PRSnapshot struct {
+	PullRequest
+	Comments []PRComment
+	Reviews  []PRReview
+	Checks   []PRCheck
+
+	// False if the PR has more comments or reviews than were fetched
+	CommentsComplete bool
+	ReviewsComplete  bool
+}
+
+// ChecksFailed returns true if any of the PR's checks have failed
+func (p *PRSnapshot) ChecksFailed() bool {
+	return checksFailed(p.Checks)
+}
+
+// GetIssue returns an issue of the snapshot, or nil
+func (s *RepoSnapshot) GetIssue(number int) *IssueSnapshot {
+	for i := range s.Issues {
+		if s.Issues[i].Number == number {
+			return &s.Issues[i]
+		}
+	}
+	return nil
+}
+
+// GetPR returns a PR of the snapshot, or nil
+func (s *RepoSnapshot) GetPR(number int) *PRSnapshot {
+	for i := range s.PRs {
+		if s.PRs[i].Number == number {
+			return &s.PRs[i]
+		}
+	}
+	return nil
+}
+
+// PRForBranch returns the open PR whose head is branch, or nil
+func (s *RepoSnapshot) PRForBranch(branch string) *PRSnapshot {
+	for i := range s.PRs {
+		if s.PRs[i].HeadRef == branch {
+			return &s.PRs[i]
+		}
+	}
+	return nil
+}
@@ -74,7 +74,34 @@ type PullRequest struct {
 	HeadRef   string    `json:"headRefName"`
 	BaseRef   string    `json:"baseRefName"`
 	Merged    bool      `json:"merged"`
+	MergedAt  time.Time `json:"mergedAt"` // Zero unless merged
 	CreatedAt time.Time `json:"createdAt"`
+	HeadOid   string    `json:"headRefOid"`
+
+	// Merge status, only filled by GetPRMergeStatus
+	IsDraft          bool   `json:"isDraft"`
+	Mergeable        string `json:"mergeable"`        // MERGEABLE, CONFLICTING or UNKNOWN
+	MergeStateStatus string `json:"mergeStateStatus"` // CLEAN, BLOCKED, BEHIND, DIRTY, UNSTABLE, ...
+}
+
+// ReadyToMerge checks if the PR can be merged now: it has no conflicts and
+// every check and review rule is satisfied. PRs with failing optional checks
+// (UNSTABLE) are not ready; they are handled as CI failures instead.
+func (pr *PullRequest) ReadyToMerge() bool {
+	if pr.IsDraft || pr.Mergeable != "MERGEABLE" {
+		return false
+	}
+	switch pr.MergeStateStatus {
+	case "CLEAN", "HAS_HOOKS":
+		return true
+	}
+	return false
+}
+
+// MergeBlocked checks if the PR cannot be merged without new commits, as
+// opposed to waiting for checks to finish
+func (pr *PullRequest) MergeBlocked() bool {
+	return pr.Mergeable == "CONFLICTING" || pr.MergeStateStatus == "DIRTY" || pr.MergeStateStatus == "BEHIND"
 }
 
 // PRCheck represents a CI check on a PR
@@ -86,11 +113,15 @@ type PRCheck struct {
 
 // PRReview represents a PR review
 type PRReview struct {
-	ID        int       `json:"id"`
-	Author    Author    `json:"author"`
-	Body      string    `json:"body"`
-	State     string    `json:"state"` // APPROVED, CHANGES_REQUESTED, COMMENTED, etc.
-	CreatedAt time.Time `json:"submittedAt"`
+	ID                string    `json:"id"`
+	Author            Author    `json:"author"`
+	AuthorAssociation string    `json:"authorAssociation"` // OWNER, MEMBER, COLLABORATOR, CONTRIBUTOR, ...
+	Body              string    `json:"body"`
+	State             string    `json:"state"` // APPROVED, CHANGES_REQUESTED, COMMENTED, etc.
+	CreatedAt         time.Time `json:"submittedAt"`
+	Commit            struct {
+		Oid string `json:"oid"`
+	} `json:"commit"` // Commit the review was submitted on
 }
 
 // PRComment represents a PR review comment (inline comment)
@@ -190,6 +190,34 @@ func TestPullRequestFields(t *testing.T) {
 	}
 }
 
+func TestPullRequestReadyToMerge(t *testing.T) {
+	tests := []struct {
+		name        string
+		pr          PullRequest
+		wantReady   bool
+		wantBlocked bool
+	}{
+		{"clean", PullRequest{Mergeable: "MERGEABLE", MergeStateStatus: "CLEAN"}, true, false},
+		{"optional check failing", PullRequest{Mergeable: "MERGEABLE", MergeStateStatus: "UNSTABLE"}, false, false},
+		{"required check pending", PullRequest{Mergeable: "MERGEABLE", MergeStateStatus: "BLOCKED"}, false, false},
+		{"draft", PullRequest{IsDraft: true, Mergeable: "MERGEABLE", MergeStateStatus: "CLEAN"}, false, false},
+		{"still computing", PullRequest{Mergeable: "UNKNOWN", MergeStateStatus: "UNKNOWN"}, false, false},
+		{"conflicts", PullRequest{Mergeable: "CONFLICTING", MergeStateStatus: "DIRTY"}, false, true},
+		{"behind base", PullRequest{Mergeable: "MERGEABLE", MergeStateStatus: "BEHIND"}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pr.ReadyToMerge(); got != tt.wantReady {
+				t.Errorf("ReadyToMerge() = %v, want %v", got, tt.wantReady)
+			}
+			if got := tt.pr.MergeBlocked(); got != tt.wantBlocked {
+				t.Errorf("MergeBlocked() = %v, want %v", got, tt.wantBlocked)
+			}
+		})
+	}
+}
+
 func TestPRCheckFields(t *testing.T) {
 	check := PRCheck{
 		Name:       "tests",
@@ -0,0 +1,88 @@
+package hooks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/state"
+)
+
+// Event is the JSON document a hook receives on stdin. Payload holds one of
+// the payload types below, depending on the event.
+type Event struct {
+	Event    string      `json:"event"`
+	Time     time.Time   `json:"time"`
+	Codebase string      `json:"codebase"`
+	Repo     string      `json:"repo"`
+	Issue    int         `json:"issue,omitempty"`
+	Payload  interface{} `json:"payload,omitempty"`
+}
+
+// NewEvent creates an event about an issue of a codebase
+func NewEvent(name, codebase, repo string, issue int, payload interface{}) Event {
+	return Event{
+		Event:    name,
+		Time:     time.Now(),
+		Codebase: codebase,
+		Repo:     repo,
+		Issue:    issue,
+		Payload:  payload,
+	}
+}
+
+// Subject returns what the event is about, for logs
+func (e Event) Subject() string {
+	if e.Issue == 0 {
+		return e.Repo
+	}
+	return fmt.Sprintf("%s#%d", e.Repo, e.Issue)
+}
+
+// IssuePickup is the payload of issue.pickup, sent before a session starts
+type IssuePickup struct {
+	Title string `json:"title"`
+	Label string `json:"label"`
+	Phase string `json:"phase,omitempty"`
+	Agent string `json:"agent"`
+}
+
+// SessionStarted is the payload of session.started
+type SessionStarted struct {
+	SessionID string `json:"session_id"`
+	Label     string `json:"label"`
+	Agent     string `json:"agent"`
+	Worktree  string `json:"worktree"`
+	Branch    string `json:"branch"`
+}
+
+// SessionEnded is the payload of session.ended
+type SessionEnded struct {
+	SessionID       string       `json:"session_id"`
+	Label           string       `json:"label"`
+	Agent           string       `json:"agent"`
+	Status          string       `json:"status"`
+	ExitCode        *int         `json:"exit_code,omitempty"`
+	Error           string       `json:"error,omitempty"`
+	DurationSeconds float64      `json:"duration_seconds"`
+	Usage           *state.Usage `json:"usage,omitempty"`
+}
+
+// LabelChanged is the payload of label.changed. An empty label means the
+// issue has no workflow label.
+type LabelChanged struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// PRMerged is the payload of pr.merged, sent when dev-swarm merges a PR
+type PRMerged struct {
+	PR         int    `json:"pr"`
+	Strategy   string `json:"strategy"`
+	ApprovedBy string `json:"approved_by"`
+}
+
+// CIFailed is the payload of ci.failed
+type CIFailed struct {
+	PR    int    `json:"pr"`
+	Label string `json:"label"` // State the issue was moved from
+}
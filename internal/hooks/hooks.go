@@ -0,0 +1,206 @@
+// Package hooks runs user-defined commands when orchestrator events happen,
+// passing each event to the command as a JSON document on stdin.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+)
+
+// defaultTimeout is how long a hook may run when its config sets no timeout
+const defaultTimeout = 30 * time.Second
+
+// killDelay is how long a killed hook's output is waited for
+const killDelay = time.Second
+
+// Runner runs the hooks configured for events
+type Runner struct {
+	global    []config.HookConfig
+	codebases map[string]codebaseHooks // By codebase name
+	logPath   string
+	logf      func(format string, args ...interface{})
+
+	wg    sync.WaitGroup
+	logMu sync.Mutex
+}
+
+// codebaseHooks are the hooks of a codebase and the directory they run in
+type codebaseHooks struct {
+	dir   string
+	hooks []config.HookConfig
+}
+
+// NewRunner creates a runner for the global and per-codebase hooks in cfg.
+// Hook output goes to the file at logPath; failures are also reported to logf.
+func NewRunner(cfg *config.Config, logPath string, logf func(format string, args ...interface{})) *Runner {
+	r := &Runner{
+		global:    cfg.Settings.Hooks,
+		codebases: make(map[string]codebaseHooks),
+		logPath:   logPath,
+		logf:      logf,
+	}
+	for _, cb := range cfg.Codebases {
+		hooks := append(append([]config.HookConfig(nil), cfg.Settings.Hooks...), cb.Hooks...)
+		r.codebases[cb.Name] = codebaseHooks{dir: cb.LocalPath, hooks: hooks}
+	}
+	return r
+}
+
+// Fire runs the hooks for an event in the background
+func (r *Runner) Fire(event Event) {
+	hooks, dir := r.hooksFor(event)
+	if len(hooks) == 0 {
+		return
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		r.logf("Error encoding %s hook event: %v", event.Event, err)
+		return
+	}
+
+	for _, hook := range hooks {
+		hook := hook
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			r.run(hook, event, dir, data)
+		}()
+	}
+}
+
+// Check runs the hooks for an event that may veto it and waits for the
+// vetoing ones. Returns an error naming the hook if one of them failed.
+// Hooks without veto run in the background as with Fire.
+func (r *Runner) Check(event Event) error {
+	hooks, dir := r.hooksFor(event)
+	if len(hooks) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s hook event: %w", event.Event, err)
+	}
+
+	var vetoes []config.HookConfig
+	for _, hook := range hooks {
+		hook := hook
+		if hook.Veto {
+			vetoes = append(vetoes, hook)
+			continue
+		}
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			r.run(hook, event, dir, data)
+		}()
+	}
+
+	for _, hook := range vetoes {
+		if err := r.run(hook, event, dir, data); err != nil {
+			return fmt.Errorf("hook %q vetoed %s: %w", hook.Command, event.Event, err)
+		}
+	}
+	return nil
+}
+
+// Wait waits for hooks running in the background to finish
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+// hooksFor returns the hooks for an event and the directory they run in
+func (r *Runner) hooksFor(event Event) ([]config.HookConfig, string) {
+	all, dir := r.global, ""
+	if cb, ok := r.codebases[event.Codebase]; ok {
+		all, dir = cb.hooks, cb.dir
+	}
+
+	var hooks []config.HookConfig
+	for _, hook := range all {
+		if hook.Event == event.Event || hook.Event == config.HookAnyEvent {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks, dir
+}
+
+// run runs a hook with the event on stdin and logs its output
+func (r *Runner) run(hook config.HookConfig, event Event, dir string, data []byte) error {
+	timeout := defaultTimeout
+	if hook.Timeout > 0 {
+		timeout = time.Duration(hook.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", hook.Command)
+	cmd.Dir = dir
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(),
+		"DEV_SWARM_EVENT="+event.Event,
+		"DEV_SWARM_REPO="+event.Repo,
+		"DEV_SWARM_ISSUE="+strconv.Itoa(event.Issue),
+	)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	// Kill everything the hook started, not just the shell
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = killDelay
+
+	start := time.Now()
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	r.writeLog(hook, event, err, time.Since(start), output.String())
+
+	if err != nil {
+		r.logf("Hook %q for %s failed: %v", hook.Command, event.Event, err)
+	}
+	return err
+}
+
+// writeLog appends a hook run and its output to the hook log
+func (r *Runner) writeLog(hook config.HookConfig, event Event, runErr error, elapsed time.Duration, output string) {
+	result := "ok"
+	if runErr != nil {
+		result = runErr.Error()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s: %q %s after %s\n", time.Now().Format("2006/01/02 15:04:05"),
+		event.Event, event.Subject(), hook.Command, result, elapsed.Round(time.Millisecond))
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if line != "" {
+			fmt.Fprintf(&b, "    %s\n", line)
+		}
+	}
+
+	r.logMu.Lock()
+	defer r.logMu.Unlock()
+	file, err := os.OpenFile(r.logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		r.logf("Error opening hook log: %v", err)
+		return
+	}
+	defer file.Close()
+	if _, err := file.WriteString(b.String()); err != nil {
+		r.logf("Error writing hook log: %v", err)
+	}
+}
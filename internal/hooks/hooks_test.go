@@ -0,0 +1,109 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+)
+
+func requireShell(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+}
+
+func newTestRunner(t *testing.T, global, codebase []config.HookConfig) (*Runner, string) {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Settings:  config.Settings{Hooks: global},
+		Codebases: []config.Codebase{{Name: "example", Repo: "owner/repo", LocalPath: dir, Hooks: codebase}},
+	}
+	logPath := filepath.Join(dir, "hooks.log")
+	return NewRunner(cfg, logPath, func(string, ...interface{}) {}), dir
+}
+
+func TestFirePassesEventOnStdin(t *testing.T) {
+	requireShell(t)
+	runner, dir := newTestRunner(t, nil, []config.HookConfig{
+		{Event: config.HookLabelChanged, Command: "cat > event.json"},
+	})
+
+	runner.Fire(NewEvent(config.HookLabelChanged, "example", "owner/repo", 42, LabelChanged{From: "user:ready-to-plan", To: "ai:planning"}))
+	runner.Wait()
+
+	data, err := os.ReadFile(filepath.Join(dir, "event.json"))
+	if err != nil {
+		t.Fatalf("hook did not run in the codebase directory: %v", err)
+	}
+	var event struct {
+		Event   string       `json:"event"`
+		Issue   int          `json:"issue"`
+		Payload LabelChanged `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		t.Fatalf("hook input is not JSON: %v", err)
+	}
+	if event.Event != config.HookLabelChanged || event.Issue != 42 || event.Payload.To != "ai:planning" {
+		t.Errorf("hook input = %+v, want the label change of #42", event)
+	}
+}
+
+func TestFireMatchesEvents(t *testing.T) {
+	requireShell(t)
+	runs := filepath.Join(t.TempDir(), "runs")
+	runner, _ := newTestRunner(t,
+		[]config.HookConfig{{Event: config.HookAnyEvent, Command: "echo any >> " + runs}},
+		[]config.HookConfig{{Event: config.HookPRMerged, Command: "echo merged >> " + runs}},
+	)
+
+	runner.Fire(NewEvent(config.HookSessionStarted, "example", "owner/repo", 1, SessionStarted{}))
+	runner.Wait()
+	runner.Fire(NewEvent(config.HookPRMerged, "other", "owner/other", 1, PRMerged{}))
+	runner.Wait()
+
+	data, _ := os.ReadFile(runs)
+	if got := strings.Fields(string(data)); len(got) != 2 || got[0] != "any" || got[1] != "any" {
+		t.Errorf("hook runs = %q, want the wildcard hook twice and no hooks of other codebases", got)
+	}
+}
+
+func TestCheckVeto(t *testing.T) {
+	requireShell(t)
+	tests := []struct {
+		name    string
+		hook    config.HookConfig
+		wantErr bool
+	}{
+		{"allowed", config.HookConfig{Event: config.HookIssuePickup, Command: "exit 0", Veto: true}, false},
+		{"vetoed", config.HookConfig{Event: config.HookIssuePickup, Command: "echo busy; exit 1", Veto: true}, true},
+		{"timed out", config.HookConfig{Event: config.HookIssuePickup, Command: "sleep 10", Timeout: 1, Veto: true}, true},
+		{"failure without veto", config.HookConfig{Event: config.HookIssuePickup, Command: "exit 1"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, dir := newTestRunner(t, []config.HookConfig{tt.hook}, nil)
+			err := runner.Check(NewEvent(config.HookIssuePickup, "example", "owner/repo", 7, IssuePickup{}))
+			runner.Wait()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			log, err := os.ReadFile(filepath.Join(dir, "hooks.log"))
+			if err != nil {
+				t.Fatalf("hook log not written: %v", err)
+			}
+			if !strings.Contains(string(log), fmt.Sprintf("issue.pickup owner/repo#7: %q", tt.hook.Command)) {
+				t.Errorf("hook log = %q, want an entry for the hook", log)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+// Package notify tells people when an issue waits for them, through sinks
+// such as desktop notifications or chat webhooks. Notifications can be
+// collected into digests so a burst of transitions sends one message.
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Notification says that an issue moved to a state that waits for a user
+type Notification struct {
+	Codebase string    `json:"codebase"`
+	Repo     string    `json:"repo"`
+	Issue    int       `json:"issue"`
+	Title    string    `json:"title"`
+	From     string    `json:"from,omitempty"`
+	State    string    `json:"state"`
+	URL      string    `json:"url"`
+	Time     time.Time `json:"time"`
+}
+
+// New creates a notification for an issue that moved from one label to another
+func New(codebase, repo string, issue int, title, from, to string) Notification {
+	return Notification{
+		Codebase: codebase,
+		Repo:     repo,
+		Issue:    issue,
+		Title:    title,
+		From:     from,
+		State:    to,
+		URL:      fmt.Sprintf("https://github.com/%s/issues/%d", repo, issue),
+		Time:     time.Now(),
+	}
+}
+
+// String describes the notification in one line
+func (n Notification) String() string {
+	if n.Title == "" {
+		return fmt.Sprintf("%s#%d is waiting in %s", n.Repo, n.Issue, n.State)
+	}
+	return fmt.Sprintf("%s#%d %s is waiting in %s", n.Repo, n.Issue, n.Title, n.State)
+}
+
+// Summary describes a batch of notifications in one line
+func Summary(batch []Notification) string {
+	if len(batch) == 1 {
+		return batch[0].String()
+	}
+	return fmt.Sprintf("%d issues are waiting for you", len(batch))
+}
+
+// Sink delivers notifications
+type Sink interface {
+	Name() string
+	Send(batch []Notification) error
+}
+
+// Filter limits the notifications a sink receives. Empty lists match everything.
+type Filter struct {
+	Codebases []string
+	States    []string
+}
+
+// Matches checks if a notification passes the filter
+func (f Filter) Matches(n Notification) bool {
+	return matchesAny(f.Codebases, n.Codebase) && matchesAny(f.States, n.State)
+}
+
+// matchesAny checks if value is in list, ignoring case; an empty list matches anything
+func matchesAny(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// route is a sink with its filter and the notifications waiting for its digest
+type route struct {
+	sink    Sink
+	filter  Filter
+	pending []Notification
+}
+
+// Notifier routes notifications to sinks, sending them right away or
+// collected into digests
+type Notifier struct {
+	routes []*route
+	digest time.Duration
+	timer  *time.Timer
+	logf   func(format string, args ...interface{})
+
+	mu sync.Mutex
+	wg sync.WaitGroup
+}
+
+// NewNotifier creates a notifier that collects notifications for digest
+// before sending them, or sends each at once if digest is zero. Delivery
+// errors are reported to logf.
+func NewNotifier(digest time.Duration, logf func(format string, args ...interface{})) *Notifier {
+	return &Notifier{digest: digest, logf: logf}
+}
+
+// AddSink routes the notifications that pass filter to sink
+func (n *Notifier) AddSink(sink Sink, filter Filter) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.routes = append(n.routes, &route{sink: sink, filter: filter})
+}
+
+// Notify queues a notification for every sink whose filter it passes
+func (n *Notifier) Notify(note Notification) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, r := range n.routes {
+		if !r.filter.Matches(note) {
+			continue
+		}
+		if n.digest == 0 {
+			n.send(r.sink, []Notification{note})
+			continue
+		}
+		r.pending = addToDigest(r.pending, note)
+	}
+
+	if n.digest > 0 && n.timer == nil && n.hasPending() {
+		n.timer = time.AfterFunc(n.digest, n.Flush)
+	}
+}
+
+// Flush sends the notifications collected so far
+func (n *Notifier) Flush() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.timer != nil {
+		n.timer.Stop()
+		n.timer = nil
+	}
+	for _, r := range n.routes {
+		if len(r.pending) > 0 {
+			n.send(r.sink, r.pending)
+			r.pending = nil
+		}
+	}
+}
+
+// Wait waits for notifications being sent to be delivered
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+// hasPending checks if any sink has notifications waiting. Callers must hold n.mu.
+func (n *Notifier) hasPending() bool {
+	for _, r := range n.routes {
+		if len(r.pending) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// send delivers a batch to a sink in the background. Callers must hold n.mu.
+func (n *Notifier) send(sink Sink, batch []Notification) {
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		if err := sink.Send(batch); err != nil {
+			n.logf("Error sending %s notification: %v", sink.Name(), err)
+		}
+	}()
+}
+
+// addToDigest adds a notification to a digest, replacing an earlier one for
+// the same issue so the digest only holds where each issue ended up
+func addToDigest(pending []Notification, note Notification) []Notification {
+	for i := range pending {
+		if pending[i].Repo == note.Repo && pending[i].Issue == note.Issue {
+			if pending[i].From != "" {
+				note.From = pending[i].From
+			}
+			pending[i] = note
+			return pending
+		}
+	}
+	return append(pending, note)
+}
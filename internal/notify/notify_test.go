@@ -0,0 +1,133 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSink collects the batches it is sent
+type recordingSink struct {
+	mu      sync.Mutex
+	batches [][]Notification
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Send(batch []Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, batch)
+	return nil
+}
+
+func noLog(string, ...interface{}) {}
+
+func TestNotifierSendsAtOnceWithoutDigest(t *testing.T) {
+	sink := &recordingSink{}
+	n := NewNotifier(0, noLog)
+	n.AddSink(sink, Filter{})
+
+	n.Notify(New("web", "owner/web", 1, "Dark mode", "ai:planning", "user:plan-review"))
+	n.Notify(New("web", "owner/web", 2, "Login bug", "ai:implementing", "user:blocked"))
+	n.Wait()
+
+	if len(sink.batches) != 2 {
+		t.Errorf("got %d batches, want 2", len(sink.batches))
+	}
+}
+
+func TestNotifierDigest(t *testing.T) {
+	sink := &recordingSink{}
+	n := NewNotifier(time.Hour, noLog)
+	n.AddSink(sink, Filter{})
+
+	n.Notify(New("web", "owner/web", 1, "Dark mode", "ai:planning", "user:plan-review"))
+	n.Notify(New("web", "owner/web", 2, "Login bug", "ai:implementing", "user:blocked"))
+	n.Notify(New("web", "owner/web", 1, "Dark mode", "ai:implementing", "user:code-review"))
+	n.Wait()
+	if len(sink.batches) != 0 {
+		t.Fatalf("got %d batches before the digest was due, want 0", len(sink.batches))
+	}
+
+	n.Flush()
+	n.Wait()
+	if len(sink.batches) != 1 {
+		t.Fatalf("got %d batches, want 1", len(sink.batches))
+	}
+	batch := sink.batches[0]
+	if len(batch) != 2 {
+		t.Fatalf("digest has %d notifications, want 2", len(batch))
+	}
+	if batch[0].State != "user:code-review" || batch[0].From != "ai:planning" {
+		t.Errorf("digest entry = %+v, want the latest state of #1 since its first move", batch[0])
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	note := New("web", "owner/web", 1, "", "", "user:blocked")
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty filter", Filter{}, true},
+		{"matching codebase", Filter{Codebases: []string{"api", "web"}}, true},
+		{"other codebase", Filter{Codebases: []string{"api"}}, false},
+		{"matching state", Filter{States: []string{"User:Blocked"}}, true},
+		{"other state", Filter{States: []string{"user:code-review"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(note); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var payload webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("body is not JSON: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL)
+	batch := []Notification{
+		New("web", "owner/web", 1, "Dark mode", "", "user:plan-review"),
+		New("web", "owner/web", 2, "Login bug", "", "user:blocked"),
+	}
+	if err := sink.Send(batch); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if !strings.HasPrefix(payload.Text, "2 issues are waiting for you") {
+		t.Errorf("text = %q, want a summary of the digest", payload.Text)
+	}
+	if len(payload.Notifications) != 2 || payload.Notifications[1].URL != "https://github.com/owner/web/issues/2" {
+		t.Errorf("notifications = %+v, want both issues with their URLs", payload.Notifications)
+	}
+}
+
+func TestWebhookSinkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	if err := NewWebhookSink(server.URL).Send([]Notification{New("web", "owner/web", 1, "", "", "user:blocked")}); err == nil {
+		t.Error("Send() should fail when the webhook rejects the message")
+	}
+}
@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// webhookTimeout bounds a webhook delivery
+const webhookTimeout = 10 * time.Second
+
+// DesktopSink shows desktop notifications with notify-send
+type DesktopSink struct{}
+
+// Name returns the sink name
+func (DesktopSink) Name() string {
+	return "desktop"
+}
+
+// Send shows one notification for the batch
+func (DesktopSink) Send(batch []Notification) error {
+	lines := make([]string, 0, len(batch))
+	for _, n := range batch {
+		lines = append(lines, n.String())
+	}
+	if err := exec.Command("notify-send", "--app-name=dev-swarm", "dev-swarm: "+Summary(batch), strings.Join(lines, "\n")).Run(); err != nil {
+		return fmt.Errorf("notify-send failed: %w", err)
+	}
+	return nil
+}
+
+// WebhookSink posts notifications as JSON. The text field makes the payload
+// work as a Slack or Microsoft Teams incoming webhook message; the
+// notifications field carries the details for other receivers.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// webhookPayload is the JSON document posted to the webhook
+type webhookPayload struct {
+	Text          string         `json:"text"`
+	Notifications []Notification `json:"notifications"`
+}
+
+// NewWebhookSink creates a sink that posts to url
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{URL: url, Client: &http.Client{Timeout: webhookTimeout}}
+}
+
+// Name returns the sink name
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+// Send posts the batch as one message
+func (s *WebhookSink) Send(batch []Notification) error {
+	var text strings.Builder
+	text.WriteString(Summary(batch))
+	if len(batch) > 1 {
+		for _, n := range batch {
+			fmt.Fprintf(&text, "\n• <%s|%s#%d> %s: %s", n.URL, n.Repo, n.Issue, n.Title, n.State)
+		}
+	} else {
+		fmt.Fprintf(&text, "\n%s", batch[0].URL)
+	}
+
+	body, err := json.Marshal(webhookPayload{Text: text.String(), Notifications: batch})
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+	resp, err := s.Client.Post(s.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post notification: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// FuncSink hands notifications to a function, such as one that shows them in the TUI
+type FuncSink struct {
+	SinkName string
+	Fn       func(batch []Notification)
+}
+
+// Name returns the sink name
+func (s FuncSink) Name() string {
+	return s.SinkName
+}
+
+// Send passes the batch to the function
+func (s FuncSink) Send(batch []Notification) error {
+	s.Fn(batch)
+	return nil
+}
@@ -0,0 +1,85 @@
+package orchestrator
+
+import (
+	"strings"
+	"time"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+)
+
+// accessTTL is how long the result of an authorization lookup is reused
+const accessTTL = 10 * time.Minute
+
+// accessEntry is a cached authorization lookup
+type accessEntry struct {
+	allowed   bool
+	checkedAt time.Time
+}
+
+// isAuthorized checks if a user's comments, reviews and commands may trigger
+// AI work on a codebase. Team and permission lookups are cached; users who
+// are turned away are logged whenever the lookup is made.
+func (o *Orchestrator) isAuthorized(codebase *config.Codebase, issueNum int, login string) bool {
+	access := &codebase.Access
+	for _, user := range access.Users {
+		if strings.EqualFold(user, login) {
+			return true
+		}
+	}
+	if login == "" || (len(access.Teams) == 0 && access.MinPermission() == config.PermissionNone) {
+		return false
+	}
+
+	key := codebase.Repo + "|" + strings.ToLower(login)
+	o.mu.RLock()
+	entry, ok := o.accessCache[key]
+	o.mu.RUnlock()
+	if ok && o.now().Sub(entry.checkedAt) < accessTTL {
+		return entry.allowed
+	}
+
+	allowed, err := o.lookupAccess(codebase, login)
+	if err != nil {
+		// Not cached, so the lookup is retried
+		o.log("Error checking access for %s on %s: %v", login, codebase.Repo, err)
+		return false
+	}
+	if !allowed {
+		o.log("Ignoring comments from %s on %s#%d: not an authorized user", login, codebase.Repo, issueNum)
+	}
+
+	o.mu.Lock()
+	o.accessCache[key] = accessEntry{allowed: allowed, checkedAt: o.now()}
+	o.mu.Unlock()
+	return allowed
+}
+
+// lookupAccess checks a user's team memberships and repository permission
+// against the codebase's access rules
+func (o *Orchestrator) lookupAccess(codebase *config.Codebase, login string) (bool, error) {
+	access := &codebase.Access
+
+	var lookupErr error
+	for _, team := range access.Teams {
+		org, slug, _ := strings.Cut(team, "/")
+		member, err := o.ghClient.IsTeamMember(org, slug, login)
+		if err != nil {
+			lookupErr = err
+			continue
+		}
+		if member {
+			return true, nil
+		}
+	}
+
+	if access.MinPermission() != config.PermissionNone {
+		permission, err := o.ghClient.GetRepoPermission(codebase.Repo, login)
+		if err != nil {
+			return false, err
+		}
+		if access.PermissionAllows(permission) {
+			return true, nil
+		}
+	}
+	return false, lookupErr
+}
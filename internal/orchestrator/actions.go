@@ -0,0 +1,104 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+	"github.com/nathanbarrett/dev-swarm-go/internal/state"
+)
+
+// dryRunSkip reports a side effect when dry-run mode suppresses it. It returns
+// true if the caller must skip the side effect.
+func (o *Orchestrator) dryRunSkip(codebase *config.Codebase, issueNum int, format string, args ...interface{}) bool {
+	if !o.dryRun {
+		return false
+	}
+
+	action := DryRunAction{
+		Codebase: codebase.Name,
+		IssueNum: issueNum,
+		Action:   fmt.Sprintf(format, args...),
+		Time:     o.now(),
+	}
+	o.log("[dry run] %s#%d: would %s", codebase.Repo, issueNum, action.Action)
+	o.sendUpdate(StateUpdate{
+		Type:      UpdateDryRun,
+		Codebase:  codebase.Name,
+		IssueNum:  issueNum,
+		Data:      action,
+		Timestamp: action.Time,
+	})
+	return true
+}
+
+// transitionLabel replaces an issue's workflow label and records the change
+func (o *Orchestrator) transitionLabel(codebase *config.Codebase, issueNum int, from, to string) error {
+	if o.dryRunSkip(codebase, issueNum, "move label %s to %s", from, to) {
+		return nil
+	}
+
+	var remove []string
+	if from != "" && from != to {
+		remove = []string{from}
+	}
+
+	if err := o.ghClient.UpdateIssueLabels(codebase.Repo, issueNum, remove, []string{to}); err != nil {
+		return err
+	}
+
+	o.noteLabelChange(codebase, issueNum, to)
+	return nil
+}
+
+// noteLabelChange updates tracked state after an issue's label changed
+func (o *Orchestrator) noteLabelChange(codebase *config.Codebase, issueNum int, label string) {
+	o.mu.Lock()
+	if cb, ok := o.codebases[codebase.Name]; ok {
+		if issue, ok := cb.Issues[issueNum]; ok {
+			issue.Label = label
+		}
+	}
+	o.mu.Unlock()
+
+	var from, title string
+	o.store.UpdateIssue(codebase.Name, issueNum, func(rec *state.IssueRecord) {
+		from, title = rec.Label, rec.Title
+		if rec.Label != label {
+			rec.Label = label
+			rec.LabelChangedAt = o.now()
+		}
+	})
+	if from != label {
+		o.labelChanged(codebase, issueNum, title, from, label)
+	}
+
+	o.sendUpdate(StateUpdate{
+		Type:      UpdateLabelChanged,
+		Codebase:  codebase.Name,
+		IssueNum:  issueNum,
+		Data:      label,
+		Timestamp: o.now(),
+	})
+
+	if label != "" && codebase.GetWorkflow().HasRole(label, config.RoleDone) {
+		o.reportCost(codebase, issueNum)
+	}
+}
+
+// firstLine returns the first line of a comment for log messages
+func firstLine(body string) string {
+	if i := strings.IndexByte(body, '\n'); i >= 0 {
+		return body[:i]
+	}
+	return body
+}
+
+// postNote adds an orchestrator note to an issue. Notes do not count as
+// replies, so they never hide a user comment from pickup.
+func (o *Orchestrator) postNote(codebase *config.Codebase, issueNum int, body string) error {
+	if o.dryRunSkip(codebase, issueNum, "note: %s", firstLine(body)) {
+		return nil
+	}
+	return o.ghClient.AddIssueComment(codebase.Repo, issueNum, o.identity.WrapNote(codebase.Repo, issueNum, body))
+}
@@ -0,0 +1,62 @@
+package orchestrator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+	"github.com/nathanbarrett/dev-swarm-go/internal/state"
+)
+
+// budgetExhausted returns which spend budget keeps new sessions from
+// starting at now, or "" if there is budget left
+func (o *Orchestrator) budgetExhausted(now time.Time) string {
+	budget := o.config.Settings.Budget
+	if budget.Daily > 0 {
+		if spent := o.store.DayUsage(now).CostUSD; spent >= budget.Daily {
+			return fmt.Sprintf("daily budget of $%.2f is spent ($%.2f)", budget.Daily, spent)
+		}
+	}
+	if budget.Monthly > 0 {
+		if spent := o.store.MonthUsage(now).CostUSD; spent >= budget.Monthly {
+			return fmt.Sprintf("monthly budget of $%.2f is spent ($%.2f)", budget.Monthly, spent)
+		}
+	}
+	return ""
+}
+
+// noteBudget logs when an exhausted budget starts or stops holding back new
+// sessions
+func (o *Orchestrator) noteBudget(exhausted string) {
+	o.mu.Lock()
+	previous := o.budgetHeld
+	o.budgetHeld = exhausted
+	o.mu.Unlock()
+
+	switch {
+	case exhausted != "" && previous == "":
+		o.log("Not starting new sessions: the %s", exhausted)
+	case exhausted == "" && previous != "":
+		o.log("Spend is within budget again, starting new sessions")
+	}
+}
+
+// reportCost notes on an issue that reached its done state what its
+// sessions cost. Each issue is annotated once.
+func (o *Orchestrator) reportCost(codebase *config.Codebase, issueNum int) {
+	rec, ok := o.store.GetIssue(codebase.Name, issueNum)
+	if !ok || rec.CostReported || rec.Usage.IsZero() {
+		return
+	}
+
+	u := rec.Usage
+	note := fmt.Sprintf("Sessions on this issue cost $%.2f across %d session(s): %d input, %d output, %d cache read and %d cache write tokens.",
+		u.CostUSD, u.Sessions, u.InputTokens, u.OutputTokens, u.CacheReadTokens, u.CacheWriteTokens)
+	if err := o.postNote(codebase, issueNum, note); err != nil {
+		o.log("Error commenting on %s#%d: %v", codebase.Repo, issueNum, err)
+		return
+	}
+	o.store.UpdateIssue(codebase.Name, issueNum, func(rec *state.IssueRecord) {
+		rec.CostReported = true
+	})
+}
@@ -0,0 +1,344 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/command"
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+	"github.com/nathanbarrett/dev-swarm-go/internal/github"
+	"github.com/nathanbarrett/dev-swarm-go/internal/state"
+)
+
+// withComments returns the issue with its comments, fetching them if the
+// issue came from a list
+func (o *Orchestrator) withComments(codebase *config.Codebase, issue *github.Issue) (*github.Issue, error) {
+	if issue.Comments != nil {
+		return issue, nil
+	}
+	return o.ghClient.GetIssue(codebase.Repo, issue.Number)
+}
+
+// processCommands runs the slash commands in comments posted since the issue
+// was last scanned and acknowledges each one on the issue. It returns the
+// issue, with comments if they were fetched, and true if a command ran.
+func (o *Orchestrator) processCommands(codebase *config.Codebase, issue *github.Issue) (*github.Issue, bool) {
+	rec, _ := o.store.GetIssue(codebase.Name, issue.Number)
+	checkedAt := rec.CommandsCheckedAt
+	if checkedAt.IsZero() {
+		// Commands posted before dev-swarm first saw the issue are not replayed
+		o.store.UpdateIssue(codebase.Name, issue.Number, func(rec *state.IssueRecord) {
+			rec.CommandsCheckedAt = issue.UpdatedAt
+		})
+		return issue, false
+	}
+	if !issue.UpdatedAt.After(checkedAt) {
+		return issue, false
+	}
+
+	full, err := o.withComments(codebase, issue)
+	if err != nil {
+		o.log("Error fetching issue details for %s#%d: %v", codebase.Repo, issue.Number, err)
+		return issue, false
+	}
+
+	label := o.getCurrentLabel(codebase, full)
+	ran := false
+	for _, comment := range full.Comments {
+		if !comment.CreatedAt.After(checkedAt) || o.isNote(codebase, issue.Number, comment.Author.Login, comment.Body, comment.CreatedAt) ||
+			o.isAIComment(codebase, issue.Number, comment.Author.Login, comment.Body, comment.CreatedAt) {
+			continue
+		}
+
+		cmd, err := command.Parse(comment.Body)
+		if cmd == nil && err == nil {
+			continue
+		}
+		user := comment.Author.Login
+		if !o.isAuthorized(codebase, issue.Number, user) {
+			continue
+		}
+
+		var note string
+		if err != nil {
+			o.log("Could not parse command on %s#%d from %s: %v", codebase.Repo, issue.Number, user, err)
+			note = fmt.Sprintf("Could not read the command from @%s: %s.\n\nAvailable commands: %s", user, err, command.Usage())
+		} else if newLabel, ack, err := o.runCommand(codebase, full, label, cmd, user); err != nil {
+			o.log("Command %s on %s#%d from %s failed: %v", cmd, codebase.Repo, issue.Number, user, err)
+			note = fmt.Sprintf("`%s` from @%s was not run: %s.", cmd, user, err)
+		} else {
+			o.log("Command %s on %s#%d from %s: %s", cmd, codebase.Repo, issue.Number, user, ack)
+			note = fmt.Sprintf("`%s` from @%s: %s.", cmd, user, ack)
+			label = newLabel
+			ran = true
+		}
+
+		if err := o.postNote(codebase, issue.Number, note); err != nil {
+			o.log("Error commenting on %s#%d: %v", codebase.Repo, issue.Number, err)
+		}
+	}
+
+	o.store.UpdateIssue(codebase.Name, issue.Number, func(rec *state.IssueRecord) {
+		rec.CommandsCheckedAt = full.UpdatedAt
+	})
+	return full, ran
+}
+
+// runCommand applies a slash command to an issue in label. It returns the
+// issue's label afterwards and a summary of what was done.
+func (o *Orchestrator) runCommand(codebase *config.Codebase, issue *github.Issue, label string, cmd *command.Command, user string) (string, string, error) {
+	workflow := codebase.GetWorkflow()
+	current := workflow.GetByName(label)
+	if current == nil {
+		return "", "", fmt.Errorf("the issue has no workflow label")
+	}
+	if current.Terminal {
+		return "", "", fmt.Errorf("the issue is already in `%s`", label)
+	}
+	role := current.Role
+
+	switch cmd.Name {
+	case command.Approve:
+		switch role {
+		case config.RolePlanReview:
+			return o.commandMove(codebase, issue.Number, label, config.RoleReadyToImplement)
+		case config.RoleCodeReview:
+			return o.commandMerge(codebase, issue.Number, label, user)
+		}
+
+	case command.Revise:
+		switch role {
+		case config.RolePlanReview:
+			return o.commandRevise(codebase, issue.Number, label, config.RoleReadyToPlan, cmd.Args)
+		case config.RoleCodeReview:
+			return o.commandRevise(codebase, issue.Number, label, config.RoleReadyToImplement, cmd.Args)
+		}
+
+	case command.SkipPlan:
+		switch role {
+		case config.RoleReadyToPlan, config.RolePlanReview:
+			return o.commandMove(codebase, issue.Number, label, config.RoleReadyToImplement)
+		}
+
+	case command.Block:
+		if role == config.RoleBlocked {
+			return "", "", fmt.Errorf("the issue is already blocked")
+		}
+		o.stopIssueSession(codebase, issue.Number)
+		return o.commandMove(codebase, issue.Number, label, config.RoleBlocked)
+
+	case command.Cancel:
+		return o.commandCancel(codebase, issue.Number, label)
+
+	case command.Retry:
+		return o.commandRetry(codebase, issue.Number, label, role)
+
+	case command.RerunCI:
+		switch role {
+		case config.RoleCIFailed, config.RoleCodeReview:
+			return o.commandRerunCI(codebase, issue.Number, label, role)
+		}
+	}
+
+	return "", "", fmt.Errorf("`%s` does not apply to issues in `%s`", cmd, label)
+}
+
+// commandMove moves an issue to the state bound to a role
+func (o *Orchestrator) commandMove(codebase *config.Codebase, issueNum int, from, role string) (string, string, error) {
+	to := o.roleLabel(codebase, role)
+	if to == "" {
+		return "", "", fmt.Errorf("the workflow has no %s state", role)
+	}
+	if err := o.transitionLabel(codebase, issueNum, from, to); err != nil {
+		return "", "", fmt.Errorf("failed to move the issue to `%s`: %w", to, err)
+	}
+	return to, fmt.Sprintf("moved the issue to `%s`", to), nil
+}
+
+// commandRevise moves an issue back to the state bound to a role and keeps
+// the notes for the sessions that pick it up from there
+func (o *Orchestrator) commandRevise(codebase *config.Codebase, issueNum int, from, role, notes string) (string, string, error) {
+	to, ack, err := o.commandMove(codebase, issueNum, from, role)
+	if err != nil {
+		return "", "", err
+	}
+	o.store.UpdateIssue(codebase.Name, issueNum, func(rec *state.IssueRecord) {
+		rec.RevisionNotes = notes
+	})
+	return to, ack + " with the notes for the next session", nil
+}
+
+// commandMerge merges the issue's PR if GitHub allows it
+func (o *Orchestrator) commandMerge(codebase *config.Codebase, issueNum int, label, user string) (string, string, error) {
+	branch := o.getBranchName(issueNum)
+	pr, err := o.ghClient.GetPRForBranch(codebase.Repo, branch)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find the pull request: %w", err)
+	}
+	if pr == nil {
+		return "", "", fmt.Errorf("there is no open pull request for `%s`", branch)
+	}
+
+	status, err := o.ghClient.GetPRMergeStatus(codebase.Repo, pr.Number)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read the merge status of #%d: %w", pr.Number, err)
+	}
+	if !status.ReadyToMerge() {
+		return "", "", fmt.Errorf("#%d cannot be merged yet (%s)", pr.Number, strings.ToLower(status.MergeStateStatus))
+	}
+
+	if err := o.mergePR(codebase, issueNum, label, pr.Number, user); err != nil {
+		return "", "", fmt.Errorf("failed to merge #%d: %w", pr.Number, err)
+	}
+	newLabel := o.roleLabel(codebase, config.RoleDone)
+	if newLabel == "" {
+		newLabel = label
+	}
+	return newLabel, fmt.Sprintf("merged #%d (%s)", pr.Number, codebase.Merge.MergeStrategy()), nil
+}
+
+// commandCancel stops work on an issue and takes it out of the workflow
+func (o *Orchestrator) commandCancel(codebase *config.Codebase, issueNum int, label string) (string, string, error) {
+	stopped := o.stopIssueSession(codebase, issueNum)
+
+	if !o.dryRunSkip(codebase, issueNum, "remove label %s", label) {
+		if err := o.ghClient.UpdateIssueLabels(codebase.Repo, issueNum, []string{label}, nil); err != nil {
+			return "", "", fmt.Errorf("failed to remove `%s`: %w", label, err)
+		}
+		o.noteLabelChange(codebase, issueNum, "")
+	}
+
+	ack := fmt.Sprintf("removed `%s`; add a workflow label to start again", label)
+	if stopped {
+		ack = "stopped the running session and " + ack
+	}
+	return "", ack, nil
+}
+
+// commandRetry moves a blocked issue back to where its last session picked
+// it up, or ends the backoff of an issue waiting to be retried
+func (o *Orchestrator) commandRetry(codebase *config.Codebase, issueNum int, label, role string) (string, string, error) {
+	rec, _ := o.store.GetIssue(codebase.Name, issueNum)
+	reset := func() {
+		o.store.UpdateIssue(codebase.Name, issueNum, func(rec *state.IssueRecord) {
+			rec.Attempts = 0
+			rec.NextAttemptAt = time.Time{}
+		})
+	}
+
+	if role != config.RoleBlocked {
+		if !o.now().Before(rec.NextAttemptAt) {
+			return "", "", fmt.Errorf("the issue is not blocked or waiting to be retried")
+		}
+		reset()
+		return label, "retrying without waiting for the backoff", nil
+	}
+
+	workflow := codebase.GetWorkflow()
+	target := ""
+	if last := rec.LastSession(); last != nil && workflow.GetByName(last.Label) != nil {
+		target = last.Label
+	} else {
+		for _, s := range workflow.States {
+			if s.Initial {
+				target = s.Name
+				break
+			}
+		}
+	}
+	if target == "" {
+		return "", "", fmt.Errorf("there is no state to retry from")
+	}
+
+	if err := o.transitionLabel(codebase, issueNum, label, target); err != nil {
+		return "", "", fmt.Errorf("failed to move the issue to `%s`: %w", target, err)
+	}
+	reset()
+	return target, fmt.Sprintf("moved the issue back to `%s` with a fresh retry budget", target), nil
+}
+
+// commandRerunCI re-runs the failed jobs of the latest workflow run on the
+// issue's branch and returns a ci_failed issue to code review
+func (o *Orchestrator) commandRerunCI(codebase *config.Codebase, issueNum int, label, role string) (string, string, error) {
+	branch := o.getBranchName(issueNum)
+	run, err := o.ghClient.GetLatestWorkflowRun(codebase.Repo, branch)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find workflow runs: %w", err)
+	}
+	if run == nil || run.Conclusion != "failure" {
+		return "", "", fmt.Errorf("the latest workflow run on `%s` did not fail", branch)
+	}
+
+	if !o.dryRunSkip(codebase, issueNum, "re-run failed jobs of workflow run %d", run.ID) {
+		if err := o.ghClient.RerunFailedJobs(codebase.Repo, run.ID); err != nil {
+			return "", "", fmt.Errorf("failed to re-run %s: %w", run.Name, err)
+		}
+	}
+	ack := fmt.Sprintf("re-running the failed jobs of %s", run.Name)
+
+	if role == config.RoleCIFailed {
+		newLabel, moved, err := o.commandMove(codebase, issueNum, label, config.RoleCodeReview)
+		if err != nil {
+			return "", "", err
+		}
+		return newLabel, ack + " and " + moved, nil
+	}
+	return label, ack, nil
+}
+
+// stopIssueSession stops the running session for an issue, if any. The
+// stopped session is not verified or counted as a failed attempt.
+func (o *Orchestrator) stopIssueSession(codebase *config.Codebase, issueNum int) bool {
+	sessionID := fmt.Sprintf("%s#%d", codebase.Repo, issueNum)
+	if !o.sessionManager.HasSession(sessionID) {
+		return false
+	}
+	if o.dryRunSkip(codebase, issueNum, "stop session %s", sessionID) {
+		return true
+	}
+
+	o.mu.Lock()
+	alreadyStopped := o.stopped[sessionID]
+	o.stopped[sessionID] = true
+	o.mu.Unlock()
+
+	if !alreadyStopped {
+		o.sessionManager.StopSession(sessionID)
+	}
+	return true
+}
+
+// takeStopped reports whether a command stopped a session and forgets it
+func (o *Orchestrator) takeStopped(sessionID string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	stopped := o.stopped[sessionID]
+	delete(o.stopped, sessionID)
+	return stopped
+}
+
+// pollCommands scans issues in states that are not picked up, such as
+// blocked and working states, for slash commands. It returns the issues no
+// command ran on and false if they could not be listed.
+func (o *Orchestrator) pollCommands(codebase *config.Codebase) ([]github.Issue, bool) {
+	labels := o.getCommandLabels(codebase)
+	if len(labels) == 0 {
+		return nil, true
+	}
+
+	issues, err := o.ghClient.ListIssuesWithLabels(codebase.Repo, labels)
+	if err != nil {
+		o.log("Error fetching issues for %s: %v", codebase.Repo, err)
+		return nil, false
+	}
+
+	var parked []github.Issue
+	for i := range issues {
+		if _, ran := o.processCommands(codebase, &issues[i]); !ran {
+			parked = append(parked, issues[i])
+		}
+	}
+	return parked, true
+}
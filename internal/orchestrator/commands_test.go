@@ -0,0 +1,50 @@
+package orchestrator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+	"github.com/nathanbarrett/dev-swarm-go/internal/git"
+)
+
+func TestCommandReviseKeepsNotes(t *testing.T) {
+	h := newHarness(t, "ok")
+	issue := h.forge.CreateIssue(testRepo, "Dark mode", "", "user:plan-review")
+	h.pastSession(issue, "ai:planning")
+	h.forge.Comment(testRepo, issue, "dev-swarm", h.signed(issue, "Plan: add a theme toggle"))
+	h.poll()
+
+	h.forge.Advance(time.Minute)
+	h.forge.Comment(testRepo, issue, "alice", "/revise follow the system theme\nand keep the toggle")
+	h.poll()
+	h.wantLabel(issue, "user:ready-to-plan")
+	if got := h.lastComment(issue); !strings.Contains(got, "with the notes for the next session") {
+		t.Errorf("last comment = %q, want the notes acknowledged", got)
+	}
+
+	// The next session is given the notes
+	h.forge.Advance(time.Minute)
+	h.poll()
+	h.wantLabel(issue, "ai:planning")
+	prompt, err := os.ReadFile(filepath.Join(git.GetWorktreePath(config.WorktreesDir(), "app", issue), ".dev-swarm-prompt.md"))
+	if err != nil {
+		t.Fatalf("failed to read the session prompt: %v", err)
+	}
+	if !strings.Contains(string(prompt), "follow the system theme\nand keep the toggle") {
+		t.Errorf("prompt does not hold the revision notes:\n%s", prompt)
+	}
+
+	// They are dropped once a session has addressed them
+	h.waitForSessions()
+	h.forge.Comment(testRepo, issue, "dev-swarm", h.signed(issue, "Plan: follow the system theme"))
+	h.forge.SetLabels(testRepo, issue, "user:plan-review")
+	h.poll()
+	h.wantLabel(issue, "user:plan-review")
+	if rec, _ := h.o.store.GetIssue("app", issue); rec.RevisionNotes != "" {
+		t.Errorf("RevisionNotes = %q, want them cleared after the session", rec.RevisionNotes)
+	}
+}
@@ -0,0 +1,107 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+	"github.com/nathanbarrett/dev-swarm-go/internal/github"
+	"github.com/nathanbarrett/dev-swarm-go/internal/state"
+)
+
+// dependenciesResolved checks that every dependency referenced by an issue is
+// closed or merged. Held issues are noted on GitHub once per set of open
+// dependencies.
+func (o *Orchestrator) dependenciesResolved(codebase *config.Codebase, issue *github.Issue) bool {
+	open := o.unresolvedDependencies(codebase, issue)
+	refs := make([]string, 0, len(open))
+	for _, dep := range open {
+		refs = append(refs, dep.Ref(codebase.Repo))
+	}
+	o.setWaitingOn(codebase.Name, issue.Number, refs)
+
+	rec, _ := o.store.GetIssue(codebase.Name, issue.Number)
+	if len(refs) == 0 {
+		if len(rec.WaitingOn) > 0 {
+			o.log("Dependencies of %s#%d resolved", codebase.Repo, issue.Number)
+			o.store.UpdateIssue(codebase.Name, issue.Number, func(rec *state.IssueRecord) {
+				rec.WaitingOn = nil
+			})
+		}
+		return true
+	}
+
+	if strings.Join(rec.WaitingOn, ",") != strings.Join(refs, ",") {
+		o.log("Holding %s#%d until %s are resolved", codebase.Repo, issue.Number, strings.Join(refs, ", "))
+		body := fmt.Sprintf("dev-swarm will pick this issue up once %s %s closed or merged.",
+			strings.Join(refs, ", "), pluralVerb(len(refs)))
+		if err := o.postNote(codebase, issue.Number, body); err != nil {
+			o.log("Error commenting on %s#%d: %v", codebase.Repo, issue.Number, err)
+			return false
+		}
+		o.store.UpdateIssue(codebase.Name, issue.Number, func(rec *state.IssueRecord) {
+			rec.WaitingOn = refs
+		})
+	}
+	return false
+}
+
+// unresolvedDependencies returns the dependencies of an issue that are still
+// open. Dependencies are read from the body and authorized user comments;
+// resolved ones are cached for the lifetime of the orchestrator.
+func (o *Orchestrator) unresolvedDependencies(codebase *config.Codebase, issue *github.Issue) []github.Dependency {
+	texts := []string{issue.Body}
+	for _, comment := range issue.Comments {
+		if !o.isNote(codebase, issue.Number, comment.Author.Login, comment.Body, comment.CreatedAt) && !o.isAIComment(codebase, issue.Number, comment.Author.Login, comment.Body, comment.CreatedAt) &&
+			o.isAuthorized(codebase, issue.Number, comment.Author.Login) {
+			texts = append(texts, comment.Body)
+		}
+	}
+
+	var open []github.Dependency
+	for _, dep := range github.ParseDependencies(codebase.Repo, strings.Join(texts, "\n")) {
+		if dep.Repo == codebase.Repo && dep.Number == issue.Number {
+			continue
+		}
+
+		o.mu.RLock()
+		resolved := o.resolvedDeps[dep.String()]
+		o.mu.RUnlock()
+		if resolved {
+			continue
+		}
+
+		resolved, err := o.ghClient.DependencyResolved(dep)
+		if err != nil {
+			o.log("Error checking dependency %s of %s#%d: %v", dep, codebase.Repo, issue.Number, err)
+		}
+		if resolved {
+			o.mu.Lock()
+			o.resolvedDeps[dep.String()] = true
+			o.mu.Unlock()
+			continue
+		}
+		open = append(open, dep)
+	}
+	return open
+}
+
+// setWaitingOn records the open dependencies of a tracked issue for display
+func (o *Orchestrator) setWaitingOn(codebaseName string, issueNum int, refs []string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if cb, ok := o.codebases[codebaseName]; ok {
+		if issue, ok := cb.Issues[issueNum]; ok {
+			issue.WaitingOn = refs
+		}
+	}
+}
+
+// pluralVerb returns "is" or "are" for a count
+func pluralVerb(n int) string {
+	if n == 1 {
+		return "is"
+	}
+	return "are"
+}
@@ -0,0 +1,26 @@
+package orchestrator
+
+import (
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+	"github.com/nathanbarrett/dev-swarm-go/internal/hooks"
+)
+
+// fireHook runs the hooks for an event about an issue in the background.
+// Dry runs run no hooks.
+func (o *Orchestrator) fireHook(codebase *config.Codebase, issueNum int, event string, payload interface{}) {
+	if o.dryRun {
+		return
+	}
+	o.hooks.Fire(hooks.NewEvent(event, codebase.Name, codebase.Repo, issueNum, payload))
+}
+
+// allowPickup runs the issue.pickup hooks and reports whether none of them
+// vetoed the pickup
+func (o *Orchestrator) allowPickup(codebase *config.Codebase, issueNum int, payload hooks.IssuePickup) bool {
+	event := hooks.NewEvent(config.HookIssuePickup, codebase.Name, codebase.Repo, issueNum, payload)
+	if err := o.hooks.Check(event); err != nil {
+		o.log("Not picking up %s#%d: %v", codebase.Repo, issueNum, err)
+		return false
+	}
+	return true
+}
@@ -8,60 +8,108 @@ import (
 	"github.com/nathanbarrett/dev-swarm-go/internal/config"
 	"github.com/nathanbarrett/dev-swarm-go/internal/git"
 	"github.com/nathanbarrett/dev-swarm-go/internal/github"
+	"github.com/nathanbarrett/dev-swarm-go/internal/hooks"
 	"github.com/nathanbarrett/dev-swarm-go/internal/session"
+	"github.com/nathanbarrett/dev-swarm-go/internal/state"
 )
 
-// mainLoop is the main polling loop
+// mainLoop is the main polling loop. Between polls it processes triggers
+// from webhook deliveries and finished sessions.
 func (o *Orchestrator) mainLoop() {
 	// Do initial poll immediately
 	o.poll()
 
+	slowed := false
 	for {
 		pollInterval := o.getPollInterval()
 
+		// Triggers do not push back the next poll
+		o.mu.RLock()
+		isPaused := o.isPaused
+		next := o.nextPollAt()
+		wait := next.Sub(o.now())
+		nowSlowed := next.After(o.lastPoll.Add(pollInterval))
+		o.mu.RUnlock()
+		if isPaused {
+			wait = pollInterval
+		}
+
+		if nowSlowed != slowed {
+			slowed = nowSlowed
+			if rl := o.ghClient.RateLimit(); slowed {
+				o.log("Slowing polls to save GitHub quota (%d of %d left until %s), next poll at %s",
+					rl.Remaining, rl.Limit, rl.Reset.Format("15:04:05"), next.Format("15:04:05"))
+			} else {
+				o.log("Polling every %s again", pollInterval)
+			}
+		}
+
 		select {
 		case <-o.ctx.Done():
 			return
 
-		case <-time.After(pollInterval):
-			o.mu.RLock()
-			isPaused := o.isPaused
-			o.mu.RUnlock()
+		case t := <-o.triggers:
+			if t.poll || !o.IsPaused() {
+				o.processTrigger(t)
+			}
 
-			if !isPaused {
+		case <-time.After(wait):
+			if !o.IsPaused() {
 				o.poll()
 			}
 		}
 	}
 }
 
+// stateRetention is how long issue history is kept after an issue stops being tracked
+const stateRetention = 30 * 24 * time.Hour
+
+// mergedPRsLookback is how far back the first cleanup of a codebase looks for merged PRs
+const mergedPRsLookback = 30 * 24 * time.Hour
+
 // poll performs a single polling cycle
 func (o *Orchestrator) poll() {
 	o.mu.Lock()
-	o.lastPoll = time.Now()
+	o.lastPoll = o.now()
 	o.mu.Unlock()
+	quotas := o.ghClient.RateLimits()
 
+	var candidates []*queuedIssue
 	for _, codebase := range o.config.GetEnabledCodebases() {
-		o.pollCodebase(&codebase)
+		codebase := codebase
+		candidates = append(candidates, o.pollCodebase(&codebase)...)
 	}
 
 	// Check session status and cleanup
 	o.checkSessionStatus()
 
+	// Hand free session slots to the highest ranked issues
+	o.drainQueue(candidates)
+
 	// Check CI status for active issues
 	o.checkCIStatus()
 
 	// Cleanup merged PRs
 	o.cleanupMergedPRs()
 
+	// What the poll cost spaces out the next ones while quota is short
+	o.recordPollCost(quotas)
+
+	// Reads between polls go to GitHub
+	o.snapshots.clear()
+
+	// Persist state at the end of every cycle
+	o.store.Prune(o.now().Add(-stateRetention))
+	o.saveState()
+
 	o.sendUpdate(StateUpdate{
 		Type:      UpdatePollComplete,
-		Timestamp: time.Now(),
+		Timestamp: o.now(),
 	})
 }
 
-// pollCodebase polls a single codebase for issues
-func (o *Orchestrator) pollCodebase(codebase *config.Codebase) {
+// pollCodebase polls a single codebase for issues and returns those eligible for pickup
+func (o *Orchestrator) pollCodebase(codebase *config.Codebase) []*queuedIssue {
 	o.mu.Lock()
 	cbState, exists := o.codebases[codebase.Name]
 	if !exists {
@@ -74,24 +122,33 @@ func (o *Orchestrator) pollCodebase(codebase *config.Codebase) {
 	}
 	o.mu.Unlock()
 
+	if o.rateLimited(cbState, nil) {
+		return nil
+	}
+	o.loadSnapshot(codebase)
+
 	// Get pickup labels
-	pickupLabels := o.getPickupLabels()
+	pickupLabels := o.getPickupLabels(codebase)
 
 	// Fetch issues with pickup labels
 	issues, err := o.ghClient.ListIssuesWithLabels(codebase.Repo, pickupLabels)
+	if o.rateLimited(cbState, err) {
+		return nil
+	}
 	if err != nil {
 		o.log("Error fetching issues for %s: %v", codebase.Repo, err)
 		o.mu.Lock()
 		cbState.IsHealthy = false
 		cbState.Error = err
 		o.mu.Unlock()
-		return
+		return nil
 	}
 
 	o.mu.Lock()
 	cbState.IsHealthy = true
+	cbState.RateLimited = false
 	cbState.Error = nil
-	cbState.LastPoll = time.Now()
+	cbState.LastPoll = o.now()
 
 	// Track current issue numbers
 	currentIssueNums := make(map[int]bool)
@@ -99,47 +156,57 @@ func (o *Orchestrator) pollCodebase(codebase *config.Codebase) {
 		currentIssueNums[issue.Number] = true
 	}
 
-	// Remove issues that are no longer active
-	for num := range cbState.Issues {
-		if !currentIssueNums[num] {
+	// Remove issues that are no longer active. Issues with a running session
+	// sit in a working label that is not polled, so they are kept.
+	for num, issueState := range cbState.Issues {
+		if !currentIssueNums[num] && !issueState.HasSession {
 			delete(cbState.Issues, num)
 			o.sendUpdate(StateUpdate{
 				Type:      UpdateIssueRemoved,
 				Codebase:  codebase.Name,
 				IssueNum:  num,
-				Timestamp: time.Now(),
+				Timestamp: o.now(),
 			})
 		}
 	}
 	o.mu.Unlock()
 
+	// Blocked and working issues only take slash commands; working issues
+	// left without a session are recovered by the watchdog
+	if parked, ok := o.pollCommands(codebase); ok {
+		o.recoverStaleWork(codebase, parked)
+	}
+
 	// Process each issue
+	var candidates []*queuedIssue
 	for _, issue := range issues {
-		o.processIssue(codebase, cbState, issue)
+		if entry := o.processIssue(codebase, cbState, issue); entry != nil {
+			candidates = append(candidates, entry)
+		}
 	}
+	return candidates
 }
 
-// processIssue processes a single issue
-func (o *Orchestrator) processIssue(codebase *config.Codebase, cbState *CodebaseState, issue github.Issue) {
-	currentLabel := o.getCurrentLabel(&issue)
+// processIssue updates the tracked state of a single issue and returns a
+// queue entry if it is eligible for pickup
+func (o *Orchestrator) processIssue(codebase *config.Codebase, cbState *CodebaseState, issue github.Issue) *queuedIssue {
+	currentLabel := o.getCurrentLabel(codebase, &issue)
 	if currentLabel == "" {
-		return // No dev-swarm label
+		return nil // No dev-swarm label
 	}
 
-	labelCfg := o.getLabelConfig(currentLabel)
+	labelCfg := o.getLabelConfig(codebase, currentLabel)
 	if labelCfg == nil {
-		return // Unknown label
+		return nil // Unknown label
 	}
 
-	sessionID := fmt.Sprintf("%s#%d", codebase.Repo, issue.Number)
-
 	o.mu.Lock()
 	issueState, exists := cbState.Issues[issue.Number]
 	if !exists {
 		issueState = &IssueState{
 			Issue:       &issue,
 			Label:       currentLabel,
-			LastChecked: time.Now(),
+			LastChecked: o.now(),
 		}
 		cbState.Issues[issue.Number] = issueState
 
@@ -148,82 +215,195 @@ func (o *Orchestrator) processIssue(codebase *config.Codebase, cbState *Codebase
 			Codebase:  codebase.Name,
 			IssueNum:  issue.Number,
 			Data:      issueState,
-			Timestamp: time.Now(),
+			Timestamp: o.now(),
 		})
 	} else {
 		// Update issue state
 		issueState.Issue = &issue
 		if issueState.Label != currentLabel {
+			o.labelChanged(codebase, issue.Number, issue.Title, issueState.Label, currentLabel)
 			issueState.Label = currentLabel
 			o.sendUpdate(StateUpdate{
 				Type:      UpdateLabelChanged,
 				Codebase:  codebase.Name,
 				IssueNum:  issue.Number,
 				Data:      currentLabel,
-				Timestamp: time.Now(),
+				Timestamp: o.now(),
 			})
 		}
-		issueState.LastChecked = time.Now()
+		issueState.LastChecked = o.now()
 	}
 	o.mu.Unlock()
 
-	// Check if we should pick up this issue
-	if !o.ShouldPickup(&issue, labelCfg) {
-		return
+	o.store.UpdateIssue(codebase.Name, issue.Number, func(rec *state.IssueRecord) {
+		rec.Title = issue.Title
+		if rec.Label != currentLabel {
+			// A user moving the issue out of blocked grants a fresh retry budget
+			if rec.Label != "" && rec.Label == o.roleLabel(codebase, config.RoleBlocked) {
+				rec.Attempts = 0
+				rec.NextAttemptAt = time.Time{}
+			}
+			rec.Label = currentLabel
+			rec.LabelChangedAt = o.now()
+		}
+		rec.LastChecked = o.now()
+	})
+
+	// Slash commands take effect before anything else
+	fullIssue, ran := o.processCommands(codebase, &issue)
+	if ran {
+		return nil
+	}
+
+	// Reviews on the PR are acted on directly instead of through a session
+	if labelCfg.Role == config.RoleCodeReview && !o.hasActiveSession(codebase, &issue) &&
+		o.handleReviews(codebase, issue.Number, currentLabel) {
+		return nil
 	}
 
 	// For conditional pickup, we need full issue details with comments
-	var fullIssue *github.Issue
 	if labelCfg.AIPickup == string(config.PickupOnUserComment) {
+		if o.hasActiveSession(codebase, &issue) || o.inBackoff(codebase.Name, issue.Number) {
+			return nil
+		}
+
 		var err error
-		fullIssue, err = o.ghClient.GetIssue(codebase.Repo, issue.Number)
+		fullIssue, err = o.withComments(codebase, fullIssue)
 		if err != nil {
 			o.log("Error fetching issue details for %s#%d: %v", codebase.Repo, issue.Number, err)
-			return
+			return nil
 		}
+	}
 
-		// Re-check with full comments
-		if !o.hasNewUserComment(fullIssue) {
-			// Also check PR comments for code review
-			if currentLabel == o.config.Labels.CodeReview.Name {
-				if !o.hasNewUserPRComment(codebase.Repo, issue.Number) {
-					return
-				}
-			} else {
-				return
-			}
+	// Check if we should pick up this issue
+	if !o.ShouldPickup(codebase, fullIssue, labelCfg) {
+		o.setWaitingOn(codebase.Name, issue.Number, nil)
+		return nil
+	}
+
+	// Dependencies may be listed in comments, which the issue list omits
+	fullIssue, err := o.withComments(codebase, fullIssue)
+	if err != nil {
+		o.log("Error fetching issue details for %s#%d: %v", codebase.Repo, issue.Number, err)
+		return nil
+	}
+	if !o.dependenciesResolved(codebase, fullIssue) {
+		return nil
+	}
+
+	return o.newQueuedIssue(codebase, fullIssue, currentLabel, labelCfg)
+}
+
+// spawnSession starts a session for an issue taken from the pickup queue
+func (o *Orchestrator) spawnSession(entry *queuedIssue) {
+	codebase, issue, currentLabel, labelCfg := entry.codebase, entry.issue, entry.label, entry.labelCfg
+	sessionID := fmt.Sprintf("%s#%d", codebase.Repo, issue.Number)
+
+	agent := o.config.AgentFor(codebase, labelCfg)
+	if o.dryRun {
+		action := fmt.Sprintf("start a %s session from %s", agent, currentLabel)
+		if labelCfg.WorkingLabel != "" && labelCfg.WorkingLabel != currentLabel {
+			action += fmt.Sprintf(" and move the issue to %s", labelCfg.WorkingLabel)
 		}
-	} else {
-		fullIssue = &issue
+		o.dryRunSkip(codebase, issue.Number, "%s", action)
+		return
+	}
+
+	pickup := hooks.IssuePickup{Title: issue.Title, Label: currentLabel, Phase: labelCfg.Phase, Agent: agent}
+	if !o.allowPickup(codebase, issue.Number, pickup) {
+		return
+	}
+
+	o.log("Picking up issue %s#%d (label: %s, agent: %s)", codebase.Repo, issue.Number, currentLabel, agent)
+
+	// Remember where the branch was so verification can tell if commits were pushed
+	var startHead string
+	if labelCfg.ExpectCommits {
+		head, err := o.ghClient.GetBranchHead(codebase.Repo, o.getBranchName(issue.Number))
+		if err != nil {
+			o.log("Error reading branch head for %s#%d: %v", codebase.Repo, issue.Number, err)
+		}
+		startHead = head
 	}
 
-	// Spawn session
-	o.log("Picking up issue %s#%d (label: %s)", codebase.Repo, issue.Number, currentLabel)
+	// Move the issue to the working label before the agent starts
+	working := labelCfg.WorkingLabel
+	if working != "" && working != currentLabel {
+		if err := o.transitionLabel(codebase, issue.Number, currentLabel, working); err != nil {
+			o.log("Error moving %s#%d to %s: %v", codebase.Repo, issue.Number, working, err)
+			return
+		}
+	}
 
+	maxRuntime, idleTimeout := o.config.SessionLimits(labelCfg)
+	rec, _ := o.store.GetIssue(codebase.Name, issue.Number)
+	nonce := session.NewNonce()
 	req := session.SpawnRequest{
-		Issue:        fullIssue,
-		Codebase:     codebase,
-		CurrentLabel: currentLabel,
-		AIAction:     labelCfg.AIAction,
+		Issue:             issue,
+		Codebase:          codebase,
+		CurrentLabel:      currentLabel,
+		AIAction:          labelCfg.AIAction,
+		Phase:             labelCfg.Phase,
+		Agent:             agent,
+		Identity:          o.identity,
+		MarkerNonce:       nonce,
+		Sessions:          rec.Sessions,
+		RevisionNotes:     rec.RevisionNotes,
+		MaxRuntime:        maxRuntime,
+		InactivityTimeout: idleTimeout,
 	}
 
 	sess, err := o.sessionManager.SpawnSession(req, o.config.AIInstructions.General)
 	if err != nil {
 		o.log("Error spawning session for %s#%d: %v", codebase.Repo, issue.Number, err)
+		if working != "" && working != currentLabel {
+			if err := o.transitionLabel(codebase, issue.Number, working, currentLabel); err != nil {
+				o.log("Error moving %s#%d back to %s: %v", codebase.Repo, issue.Number, currentLabel, err)
+			}
+		}
 		return
 	}
 
 	o.mu.Lock()
-	issueState.HasSession = true
-	issueState.SessionID = sessionID
+	if cbState, ok := o.codebases[codebase.Name]; ok {
+		if issueState, ok := cbState.Issues[issue.Number]; ok {
+			issueState.HasSession = true
+			issueState.SessionID = sessionID
+		}
+	}
 	o.mu.Unlock()
 
+	// Record the session before anything else can fail so a crash after
+	// spawning does not cause the same comments to be handled again
+	processedAt := o.latestCommentTime(codebase, issue, currentLabel)
+	o.store.UpdateIssue(codebase.Name, issue.Number, func(rec *state.IssueRecord) {
+		if processedAt.After(rec.LastProcessedCommentAt) {
+			rec.LastProcessedCommentAt = processedAt
+		}
+	})
+	// Session times are on the orchestrator's clock, like the comment times
+	// the session's markers are checked against
+	o.store.RecordSessionStart(codebase.Name, issue.Number, sessionID, currentLabel, nonce, o.now())
+	if startHead != "" {
+		o.store.UpdateIssue(codebase.Name, issue.Number, func(rec *state.IssueRecord) {
+			rec.LastSession().StartHead = startHead
+		})
+	}
+	o.saveState()
+
 	o.sendUpdate(StateUpdate{
 		Type:      UpdateSessionStarted,
 		Codebase:  codebase.Name,
 		IssueNum:  issue.Number,
 		Data:      sess.Info(),
-		Timestamp: time.Now(),
+		Timestamp: o.now(),
+	})
+	o.fireHook(codebase, issue.Number, config.HookSessionStarted, hooks.SessionStarted{
+		SessionID: sessionID,
+		Label:     currentLabel,
+		Agent:     agent,
+		Worktree:  sess.WorktreePath,
+		Branch:    sess.BranchName,
 	})
 }
 
@@ -245,21 +425,31 @@ func (o *Orchestrator) checkSessionStatus() {
 			}
 			o.mu.Unlock()
 
-			// Clean up session if done label was set
+			// The session may have changed the issue and its PR since this poll's snapshot
+			o.snapshots.forget(sess.Codebase.Repo, sess.Issue.Number, o.getBranchName(sess.Issue.Number))
+
+			// Sessions stopped by a command are neither verified nor retried
+			stopped := o.takeStopped(sess.ID)
+			o.recordSessionEnd(sess, stopped)
+			o.fireSessionEnded(sess)
+			if !stopped {
+				o.handleSessionResult(sess)
+			}
+			o.saveState()
+
+			// Clean up session if the issue reached a terminal state
 			if sess.Status == session.StatusCompleted {
-				// Check if issue now has done label
-				parts := strings.Split(sess.ID, "#")
-				if len(parts) == 2 {
-					repo := parts[0]
-					issueNum := sess.Issue.Number
-					issue, err := o.ghClient.GetIssue(repo, issueNum)
-					if err == nil && issue.HasLabel(o.config.Labels.Done.Name) {
-						// Clean up worktree
-						codebase := o.config.GetCodebaseByRepo(repo)
-						if codebase != nil {
-							worktreePath := git.GetWorktreePath(config.WorktreesDir(), codebase.Name, issueNum)
-							git.RemoveWorktree(codebase.LocalPath, worktreePath, false)
-						}
+				codebase := sess.Codebase
+				issueNum := sess.Issue.Number
+				issue, err := o.ghClient.GetIssue(codebase.Repo, issueNum)
+				if err == nil && o.isTerminal(codebase, issue) {
+					// Clean up worktree
+					worktreePath := git.GetWorktreePath(config.WorktreesDir(), codebase.Name, issueNum)
+					git.RemoveWorktree(codebase.LocalPath, worktreePath, false)
+
+					if codebase.GetWorkflow().HasRole(o.getCurrentLabel(codebase, issue), config.RoleDone) {
+						o.reportCost(codebase, issueNum)
+						o.saveState()
 					}
 				}
 			}
@@ -270,13 +460,19 @@ func (o *Orchestrator) checkSessionStatus() {
 	}
 }
 
-// checkCIStatus checks CI status for issues with implementing or code review labels
+// checkCIStatus checks CI status for issues in implementing or code review states
 func (o *Orchestrator) checkCIStatus() {
 	for _, cb := range o.codebases {
+		workflow := cb.Config.GetWorkflow()
+		ciFailed := workflow.RoleLabel(config.RoleCIFailed)
+		if ciFailed == "" {
+			continue
+		}
+
 		for _, issueState := range cb.Issues {
 			// Only check CI for implementing or code review states
-			if issueState.Label != o.config.Labels.Implementing.Name &&
-				issueState.Label != o.config.Labels.CodeReview.Name {
+			if !workflow.HasRole(issueState.Label, config.RoleImplementing) &&
+				!workflow.HasRole(issueState.Label, config.RoleCodeReview) {
 				continue
 			}
 
@@ -298,40 +494,38 @@ func (o *Orchestrator) checkCIStatus() {
 				continue
 			}
 
-			if failed && issueState.Label != o.config.Labels.CIFailed.Name {
+			if failed && issueState.Label != ciFailed {
 				// Update label to ci-failed
-				err := o.ghClient.UpdateIssueLabels(
-					cb.Config.Repo,
-					issueState.Issue.Number,
-					[]string{issueState.Label},
-					[]string{o.config.Labels.CIFailed.Name},
-				)
+				from := issueState.Label
+				err := o.transitionLabel(cb.Config, issueState.Issue.Number, from, ciFailed)
 				if err != nil {
 					o.log("Error updating label for %s#%d: %v", cb.Config.Repo, issueState.Issue.Number, err)
-				} else {
-					issueState.Label = o.config.Labels.CIFailed.Name
-					o.sendUpdate(StateUpdate{
-						Type:      UpdateLabelChanged,
-						Codebase:  cb.Config.Name,
-						IssueNum:  issueState.Issue.Number,
-						Data:      o.config.Labels.CIFailed.Name,
-						Timestamp: time.Now(),
-					})
+					continue
 				}
+				o.fireHook(cb.Config, issueState.Issue.Number, config.HookCIFailed, hooks.CIFailed{PR: pr.Number, Label: from})
 			}
 		}
 	}
 }
 
-// cleanupMergedPRs cleans up worktrees for merged PRs
+// cleanupMergedPRs cleans up worktrees for PRs merged since the last cleanup
 func (o *Orchestrator) cleanupMergedPRs() {
 	for _, cb := range o.config.GetEnabledCodebases() {
-		prs, err := o.ghClient.GetMergedPRs(cb.Repo)
+		since := o.store.MergedPRsSeen(cb.Name)
+		if since.IsZero() {
+			since = o.now().Add(-mergedPRsLookback)
+		}
+		prs, err := o.ghClient.GetMergedPRsSince(cb.Repo, since)
 		if err != nil {
 			continue
 		}
 
+		// GitHub's merge times, so the local clock cannot skip a merge
+		seen := since
 		for _, pr := range prs {
+			if pr.MergedAt.After(seen) {
+				seen = pr.MergedAt
+			}
 			// Check if this is a claude branch
 			if !strings.HasPrefix(pr.HeadRef, "claude/issue-") {
 				continue
@@ -347,9 +541,51 @@ func (o *Orchestrator) cleanupMergedPRs() {
 			// Clean up worktree if it exists
 			worktreePath := git.GetWorktreePath(config.WorktreesDir(), cb.Name, issueNum)
 			if git.WorktreeExists(worktreePath) {
+				if o.dryRunSkip(&cb, issueNum, "remove worktree %s for merged PR #%d", worktreePath, pr.Number) {
+					continue
+				}
 				git.RemoveWorktree(cb.LocalPath, worktreePath, true)
 				o.log("Cleaned up worktree for merged PR: %s", pr.HeadRef)
 			}
 		}
+		o.store.SetMergedPRsSeen(cb.Name, seen)
+	}
+}
+
+// fireSessionEnded runs the session.ended hooks for a finished session
+func (o *Orchestrator) fireSessionEnded(sess *session.Session) {
+	info := sess.Info()
+	payload := hooks.SessionEnded{
+		SessionID:       info.ID,
+		Label:           info.Label,
+		Agent:           info.Agent,
+		Status:          info.Status.String(),
+		ExitCode:        info.ExitCode,
+		DurationSeconds: info.Duration.Seconds(),
+		Usage:           info.Usage,
 	}
+	if info.Error != nil {
+		payload.Error = info.Error.Error()
+	}
+	o.fireHook(sess.Codebase, info.IssueNumber, config.HookSessionEnded, payload)
+}
+
+// recordSessionEnd stores the outcome of a finished session
+func (o *Orchestrator) recordSessionEnd(sess *session.Session, interrupted bool) {
+	info := sess.Info()
+
+	outcome := state.OutcomeCompleted
+	switch {
+	case interrupted:
+		outcome = state.OutcomeInterrupted
+	case info.Status == session.StatusFailed:
+		outcome = state.OutcomeFailed
+	case info.Status == session.StatusTimedOut:
+		outcome = state.OutcomeTimedOut
+	}
+
+	// The end is when the orchestrator noticed it, so comments the session
+	// posted before exiting fall within its recorded run
+	endedAt := o.now()
+	o.store.RecordSessionEnd(info.CodebaseName, info.IssueNumber, outcome, info.ExitCode, info.Error, info.Usage, endedAt)
 }
@@ -0,0 +1,48 @@
+package orchestrator
+
+import (
+	"time"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+	"github.com/nathanbarrett/dev-swarm-go/internal/hooks"
+	"github.com/nathanbarrett/dev-swarm-go/internal/notify"
+)
+
+// newNotifier builds the notifier for the configured sinks. The tui sink
+// shows notifications as StateUpdates.
+func (o *Orchestrator) newNotifier(cfg config.NotifyConfig) *notify.Notifier {
+	n := notify.NewNotifier(time.Duration(cfg.Digest)*time.Second, o.log)
+	for _, sinkCfg := range cfg.Sinks {
+		var sink notify.Sink
+		switch sinkCfg.Type {
+		case config.SinkDesktop:
+			sink = notify.DesktopSink{}
+		case config.SinkWebhook:
+			sink = notify.NewWebhookSink(sinkCfg.URL)
+		case config.SinkTUI:
+			sink = notify.FuncSink{SinkName: config.SinkTUI, Fn: func(batch []notify.Notification) {
+				o.sendUpdate(StateUpdate{
+					Type:      UpdateNotification,
+					Data:      batch,
+					Timestamp: o.now(),
+				})
+			}}
+		default:
+			continue
+		}
+		n.AddSink(sink, notify.Filter{Codebases: sinkCfg.Codebases, States: sinkCfg.States})
+	}
+	return n
+}
+
+// labelChanged fires the label.changed hooks for an issue and notifies the
+// sinks when its new label waits for a user. Dry runs do neither.
+func (o *Orchestrator) labelChanged(codebase *config.Codebase, issueNum int, title, from, to string) {
+	if o.dryRun {
+		return
+	}
+	o.fireHook(codebase, issueNum, config.HookLabelChanged, hooks.LabelChanged{From: from, To: to})
+	if to != "" && codebase.GetWorkflow().NeedsUser(to) {
+		o.notifier.Notify(notify.New(codebase.Name, codebase.Repo, issueNum, title, from, to))
+	}
+}
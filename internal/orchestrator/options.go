@@ -0,0 +1,79 @@
+package orchestrator
+
+import (
+	"time"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/github"
+)
+
+// GitHubClient is the set of GitHub operations the orchestrator performs.
+// github.API satisfies it, as does the in-memory forge in githubtest.
+type GitHubClient interface {
+	GetAuthenticatedUser() (string, error)
+	GetRepoPermission(repo, login string) (string, error)
+	IsTeamMember(org, team, login string) (bool, error)
+
+	GetBranchHead(repo, branch string) (string, error)
+	CommitsAhead(repo, base, head string) (int, error)
+	DependencyResolved(dep github.Dependency) (bool, error)
+
+	ListIssuesWithLabels(repo string, labels []string) ([]github.Issue, error)
+	GetIssue(repo string, number int) (*github.Issue, error)
+	UpdateIssueLabels(repo string, number int, removeLabels, addLabels []string) error
+	AddIssueComment(repo string, number int, body string) error
+	SyncLabels(repo string, labels []github.LabelInfo) error
+
+	GetPRForBranch(repo, branch string) (*github.PullRequest, error)
+	GetPR(repo string, number int) (*github.PullRequest, error)
+	GetPRMergeStatus(repo string, number int) (*github.PullRequest, error)
+	MergePR(repo string, number int, strategy string, deleteRemoteBranch bool) error
+	GetPRReviews(repo string, number int) ([]github.PRReview, error)
+	GetPRComments(repo string, number int) ([]github.PRComment, error)
+	GetMergedPRsSince(repo string, since time.Time) ([]github.PullRequest, error)
+
+	PRChecksFailed(repo string, number int) (bool, error)
+	GetLatestWorkflowRun(repo, branch string) (*github.WorkflowRun, error)
+	RerunFailedJobs(repo string, runID int) error
+
+	FetchRepoSnapshot(repo string, labels []string) (*github.RepoSnapshot, error)
+
+	RateLimit() github.RateLimit
+	RateLimits() map[string]github.RateLimit
+}
+
+var _ GitHubClient = github.API(nil)
+
+// Clock tells the orchestrator the time, so tests can script it
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the wall clock
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Option customizes an Orchestrator created by New
+type Option func(*Orchestrator)
+
+// WithGitHub makes the orchestrator use client instead of the configured
+// GitHub backend
+func WithGitHub(client GitHubClient) Option {
+	return func(o *Orchestrator) {
+		o.ghClient = client
+	}
+}
+
+// WithClock makes the orchestrator read the time from clock
+func WithClock(clock Clock) Option {
+	return func(o *Orchestrator) {
+		o.clock = clock
+	}
+}
+
+// now returns the orchestrator's current time
+func (o *Orchestrator) now() time.Time {
+	return o.clock.Now()
+}
@@ -4,28 +4,66 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/nathanbarrett/dev-swarm-go/internal/config"
 	"github.com/nathanbarrett/dev-swarm-go/internal/git"
 	"github.com/nathanbarrett/dev-swarm-go/internal/github"
+	"github.com/nathanbarrett/dev-swarm-go/internal/hooks"
+	"github.com/nathanbarrett/dev-swarm-go/internal/notify"
+	"github.com/nathanbarrett/dev-swarm-go/internal/schedule"
 	"github.com/nathanbarrett/dev-swarm-go/internal/session"
+	"github.com/nathanbarrett/dev-swarm-go/internal/state"
+	"github.com/nathanbarrett/dev-swarm-go/internal/webhook"
 )
 
 // Orchestrator manages the dev-swarm workflow
 type Orchestrator struct {
 	config         *config.Config
-	ghClient       *github.Client
+	ghClient       GitHubClient
+	snapshots      *snapshotClient // Wraps ghClient; serves reads during a poll from one query per repo
+	clock          Clock
 	sessionManager *session.Manager
+	store          *state.Store
+	identity       *session.Identity // Tells AI comments apart from user comments
+	hooks          *hooks.Runner     // User commands run on orchestrator events
+	notifier       *notify.Notifier  // Tells users about issues waiting for them
 
 	// State
-	mu         sync.RWMutex
-	codebases  map[string]*CodebaseState
-	startedAt  time.Time
-	lastPoll   time.Time
-	isPaused   bool
-	isRunning  bool
+	mu        sync.RWMutex
+	codebases map[string]*CodebaseState
+	startedAt time.Time
+	lastPoll  time.Time
+	pollCosts map[string]int // GitHub requests the last poll used of each quota, by resource
+	isPaused  bool
+	isRunning bool
+	dryRun    bool // Log side effects instead of performing them
+
+	// Pickup queue left by the last drain and the positions of its issues
+	queue          []*queuedIssue
+	queuePositions map[string]int
+
+	// Dependencies known to be closed or merged, by "owner/repo#N"
+	resolvedDeps map[string]bool
+
+	// Sessions stopped by a slash command, by session ID
+	stopped map[string]bool
+
+	// When new sessions may start, by codebase name; nil means any time
+	schedules map[string]*schedule.Schedule
+
+	// Why an exhausted budget holds back new sessions; empty while within budget
+	budgetHeld string
+
+	// Authorization lookups, by "owner/repo|login"
+	accessCache map[string]accessEntry
+
+	// Webhook receiver and the targeted processing it requests
+	webhook  *webhook.Server
+	triggers chan trigger
 
 	// Control
 	ctx    context.Context
@@ -39,21 +77,95 @@ type Orchestrator struct {
 }
 
 // New creates a new Orchestrator
-func New(cfg *config.Config, logger *log.Logger) (*Orchestrator, error) {
+func New(cfg *config.Config, logger *log.Logger, opts ...Option) (*Orchestrator, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	worktreesDir := config.WorktreesDir()
 
-	return &Orchestrator{
+	identity, err := session.LoadIdentity(config.IdentityKeyPath(), cfg.Settings.BotLogin)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	o := &Orchestrator{
 		config:         cfg,
-		ghClient:       github.NewClient(),
+		clock:          systemClock{},
 		sessionManager: session.NewManager(cfg.Settings.MaxConcurrentSessions, cfg.Settings.OutputBufferLines, worktreesDir),
+		store:          state.NewStore(config.StateFilePath()),
+		identity:       identity,
 		codebases:      make(map[string]*CodebaseState),
+		pollCosts:      make(map[string]int),
+		resolvedDeps:   make(map[string]bool),
+		stopped:        make(map[string]bool),
+		schedules:      make(map[string]*schedule.Schedule),
+		accessCache:    make(map[string]accessEntry),
 		ctx:            ctx,
 		cancel:         cancel,
 		stateChan:      make(chan StateUpdate, 100),
+		triggers:       make(chan trigger, 100),
 		logger:         logger,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.ghClient == nil {
+		ghClient, err := github.New(cfg.Settings.GitHub)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		o.ghClient = ghClient
+	}
+	o.snapshots = newSnapshotClient(o.ghClient)
+	o.ghClient = o.snapshots
+
+	codebaseLimits := make(map[string]int)
+	for _, cb := range cfg.Codebases {
+		if cb.MaxConcurrentSessions > 0 {
+			codebaseLimits[cb.Name] = cb.MaxConcurrentSessions
+		}
+
+		scheduleCfg := cb.Schedule
+		if scheduleCfg == nil {
+			scheduleCfg = &cfg.Settings.Schedule
+		}
+		sched, err := scheduleCfg.Build()
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("invalid schedule for %s: %w", cb.Name, err)
+		}
+		o.schedules[cb.Name] = sched
+	}
+	o.sessionManager.SetCodebaseLimits(codebaseLimits)
+	o.sessionManager.SetPhaseLimits(cfg.Settings.MaxSessionsPerPhase)
+
+	agents, err := session.NewAgents(cfg.Settings.Agents)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	o.sessionManager.SetAgents(agents)
+	o.hooks = hooks.NewRunner(cfg, config.HookLogFilePath(), o.log)
+	o.notifier = o.newNotifier(cfg.Settings.Notify)
+
+	if err := o.store.Load(); err != nil {
+		// Keep the unreadable file for inspection and start with empty state
+		backup := o.store.Path() + ".corrupt"
+		if renameErr := os.Rename(o.store.Path(), backup); renameErr != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to load state: %w", err)
+		}
+		o.log("Warning: %v (moved to %s, starting with empty state)", err, backup)
+	}
+
+	return o, nil
+}
+
+// SetDryRun makes the orchestrator run its full cycle while only reporting
+// label changes, comments, sessions and worktree cleanup. Call before Start.
+func (o *Orchestrator) SetDryRun(enabled bool) {
+	o.dryRun = enabled
 }
 
 // Start begins the orchestration loop
@@ -64,28 +176,45 @@ func (o *Orchestrator) Start() error {
 		return fmt.Errorf("orchestrator is already running")
 	}
 	o.isRunning = true
-	o.startedAt = time.Now()
+	o.startedAt = o.now()
 	o.mu.Unlock()
 
-	// Initialize codebase states
+	// Initialize codebase states, restoring issues tracked by a previous run
+	o.mu.Lock()
 	for _, cb := range o.config.GetEnabledCodebases() {
+		cb := cb
 		o.codebases[cb.Name] = &CodebaseState{
 			Config:    &cb,
-			Issues:    make(map[int]*IssueState),
+			Issues:    o.restoreIssues(cb.Name),
 			IsHealthy: true,
 		}
 	}
+	o.mu.Unlock()
 
 	// Sync labels
-	if err := o.syncLabels(); err != nil {
+	if o.dryRun {
+		o.log("Dry run: skipping label sync, state saving and all issue changes")
+	} else if err := o.syncLabels(); err != nil {
 		o.log("Warning: failed to sync labels: %v", err)
 	}
 
+	if botLogin := o.config.Settings.BotLogin; botLogin != "" {
+		if login, err := o.ghClient.GetAuthenticatedUser(); err != nil {
+			o.log("Warning: failed to check the authenticated user: %v", err)
+		} else if !strings.EqualFold(login, botLogin) {
+			o.log("Warning: gh is authenticated as %s, not bot_login %s; comments dev-swarm posts will count as user comments", login, botLogin)
+		}
+	}
+
 	// Ensure worktrees directory exists
 	if err := config.EnsureWorktreesDir(); err != nil {
 		return fmt.Errorf("failed to create worktrees directory: %w", err)
 	}
 
+	if err := o.startWebhookServer(); err != nil {
+		return fmt.Errorf("failed to start webhook server: %w", err)
+	}
+
 	// Start main loop
 	go o.mainLoop()
 
@@ -108,7 +237,18 @@ func (o *Orchestrator) Stop() {
 
 	o.log("Stopping orchestrator...")
 	o.cancel()
+	if o.webhook != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := o.webhook.Shutdown(ctx); err != nil {
+			o.log("Warning: failed to stop webhook server: %v", err)
+		}
+		cancel()
+	}
 	o.sessionManager.StopAll()
+	o.hooks.Wait()
+	o.notifier.Flush()
+	o.notifier.Wait()
+	o.saveState()
 	close(o.stateChan)
 	o.log("Orchestrator stopped.")
 }
@@ -136,9 +276,10 @@ func (o *Orchestrator) IsPaused() bool {
 	return o.isPaused
 }
 
-// ForceRefresh triggers an immediate poll
+// ForceRefresh asks the main loop for an immediate poll. Polls only run on
+// the main loop, so a finished session is never handled twice at once.
 func (o *Orchestrator) ForceRefresh() {
-	o.poll()
+	o.enqueueTrigger(trigger{poll: true})
 }
 
 // StateChan returns the state update channel
@@ -153,14 +294,27 @@ func (o *Orchestrator) Stats() Stats {
 
 	activeSessions := o.sessionManager.ActiveCount()
 	pollInterval := o.getPollInterval()
+	next := o.nextPollAt()
+	now := o.now()
 
 	return Stats{
-		ActiveSessions: activeSessions,
-		TotalIssues:    o.countTotalIssues(),
-		LastPoll:       o.lastPoll,
-		NextPoll:       o.lastPoll.Add(pollInterval),
-		IsPaused:       o.isPaused,
-		Uptime:         time.Since(o.startedAt),
+		ActiveSessions:  activeSessions,
+		QueuedSessions:  len(o.queuePositions),
+		WaitingSessions: o.countWaitingIssues(),
+		TotalIssues:     o.countTotalIssues(),
+		LastPoll:        o.lastPoll,
+		NextPoll:        next,
+		PollSlowed:      next.After(o.lastPoll.Add(pollInterval)),
+		RateLimit:       o.ghClient.RateLimit(),
+		IsPaused:        o.isPaused,
+		Uptime:          o.now().Sub(o.startedAt),
+		WebhookAddr:     o.webhookAddr(),
+		DryRun:          o.dryRun,
+		SpentToday:      o.store.DayUsage(now).CostUSD,
+		SpentThisMonth:  o.store.MonthUsage(now).CostUSD,
+		DailyBudget:     o.config.Settings.Budget.Daily,
+		MonthlyBudget:   o.config.Settings.Budget.Monthly,
+		BudgetExhausted: o.budgetHeld != "",
 	}
 }
 
@@ -172,11 +326,20 @@ func (o *Orchestrator) GetCodebaseInfo() []CodebaseInfo {
 	result := make([]CodebaseInfo, 0, len(o.codebases))
 	for _, cb := range o.codebases {
 		info := CodebaseInfo{
-			Name:      cb.Config.Name,
-			Repo:      cb.Config.Repo,
-			Issues:    make([]IssueInfo, 0, len(cb.Issues)),
-			IsIdle:    len(cb.Issues) == 0,
-			IsHealthy: cb.IsHealthy,
+			Name:        cb.Config.Name,
+			Repo:        cb.Config.Repo,
+			Issues:      make([]IssueInfo, 0, len(cb.Issues)),
+			IsIdle:      len(cb.Issues) == 0,
+			IsHealthy:   cb.IsHealthy,
+			RateLimited: cb.RateLimited,
+		}
+		if sched := o.schedules[cb.Config.Name]; sched != nil {
+			now := o.now()
+			info.Scheduled = true
+			info.ScheduleOpen = sched.Open(now)
+			if !info.ScheduleOpen {
+				info.NextWindow = sched.NextOpen(now)
+			}
 		}
 		if cb.Error != nil {
 			info.Error = cb.Error.Error()
@@ -184,13 +347,21 @@ func (o *Orchestrator) GetCodebaseInfo() []CodebaseInfo {
 
 		for _, issue := range cb.Issues {
 			issueInfo := IssueInfo{
-				Number:       issue.Issue.Number,
-				Title:        issue.Issue.Title,
-				Label:        issue.Label,
-				HasSession:   issue.HasSession,
-				SessionID:    issue.SessionID,
-				CodebaseName: cb.Config.Name,
-				Repo:         cb.Config.Repo,
+				Number:        issue.Issue.Number,
+				Title:         issue.Issue.Title,
+				Label:         issue.Label,
+				State:         o.getLabelConfig(cb.Config, issue.Label),
+				HasSession:    issue.HasSession,
+				SessionID:     issue.SessionID,
+				CodebaseName:  cb.Config.Name,
+				Repo:          cb.Config.Repo,
+				QueuePosition: o.queuePosition(cb.Config.Name, issue.Issue.Number),
+				WaitingOn:     issue.WaitingOn,
+			}
+
+			if rec, ok := o.store.GetIssue(cb.Config.Name, issue.Issue.Number); ok {
+				issueInfo.Attempts = rec.Attempts
+				issueInfo.RetryAt = rec.NextAttemptAt
 			}
 
 			if issue.HasSession {
@@ -215,21 +386,11 @@ func (o *Orchestrator) GetSessionManager() *session.Manager {
 	return o.sessionManager
 }
 
-// syncLabels ensures all required labels exist in all repos
+// syncLabels ensures all workflow labels exist in all repos
 func (o *Orchestrator) syncLabels() error {
-	labels := o.config.Labels.GetAllLabels()
-	labelInfos := make([]github.LabelInfo, 0, len(labels))
-	for _, l := range labels {
-		labelInfos = append(labelInfos, github.LabelInfo{
-			Name:        l.Name,
-			Color:       l.Color,
-			Description: l.Description,
-		})
-	}
-
 	for _, cb := range o.config.GetEnabledCodebases() {
 		o.log("Syncing labels for %s...", cb.Repo)
-		if err := o.ghClient.SyncLabels(cb.Repo, labelInfos); err != nil {
+		if err := o.ghClient.SyncLabels(cb.Repo, workflowLabelInfos(cb.GetWorkflow())); err != nil {
 			o.log("Warning: failed to sync labels for %s: %v", cb.Repo, err)
 		}
 	}
@@ -237,6 +398,19 @@ func (o *Orchestrator) syncLabels() error {
 	return nil
 }
 
+// workflowLabelInfos returns the GitHub labels for every state of a workflow
+func workflowLabelInfos(workflow *config.Workflow) []github.LabelInfo {
+	labelInfos := make([]github.LabelInfo, 0, len(workflow.States))
+	for _, l := range workflow.States {
+		labelInfos = append(labelInfos, github.LabelInfo{
+			Name:        l.Name,
+			Color:       l.Color,
+			Description: l.Description,
+		})
+	}
+	return labelInfos
+}
+
 // handleSessionEvents processes session output and status events
 func (o *Orchestrator) handleSessionEvents() {
 	outputChan := o.sessionManager.OutputChan()
@@ -251,14 +425,14 @@ func (o *Orchestrator) handleSessionEvents() {
 			o.sendUpdate(StateUpdate{
 				Type:      UpdateSessionOutput,
 				Data:      event,
-				Timestamp: time.Now(),
+				Timestamp: o.now(),
 			})
 
 		case event := <-statusChan:
 			o.sendUpdate(StateUpdate{
 				Type:      UpdateSessionEnded,
 				Data:      event,
-				Timestamp: time.Now(),
+				Timestamp: o.now(),
 			})
 
 			// Update issue state
@@ -273,6 +447,9 @@ func (o *Orchestrator) handleSessionEvents() {
 				}
 			}
 			o.mu.Unlock()
+
+			// Verify the session and hand its slot to the queue right away
+			o.enqueueTrigger(trigger{})
 		}
 	}
 }
@@ -294,12 +471,24 @@ func (o *Orchestrator) sendUpdate(update StateUpdate) {
 }
 
 func (o *Orchestrator) getPollInterval() time.Duration {
+	// Webhooks drive processing; polling only reconciles missed deliveries
+	if o.config.Settings.Webhook.Enabled {
+		return time.Duration(o.config.Settings.Webhook.ReconcileInterval) * time.Second
+	}
 	if o.sessionManager.ActiveCount() > 0 {
 		return time.Duration(o.config.Settings.ActivePollInterval) * time.Second
 	}
 	return time.Duration(o.config.Settings.PollInterval) * time.Second
 }
 
+// webhookAddr returns the address webhooks are received on, or "" if disabled
+func (o *Orchestrator) webhookAddr() string {
+	if o.webhook == nil {
+		return ""
+	}
+	return o.webhook.Addr()
+}
+
 func (o *Orchestrator) countTotalIssues() int {
 	count := 0
 	for _, cb := range o.codebases {
@@ -308,16 +497,34 @@ func (o *Orchestrator) countTotalIssues() int {
 	return count
 }
 
-func (o *Orchestrator) getSessionID(issue *github.Issue) string {
-	// Find codebase for this issue
-	for _, cb := range o.codebases {
-		for _, i := range cb.Issues {
-			if i.Issue.Number == issue.Number {
-				return fmt.Sprintf("%s#%d", cb.Config.Repo, issue.Number)
-			}
+// restoreIssues rebuilds issue states for a codebase from the state store.
+// Restored issues have no live session; the next poll refreshes them.
+func (o *Orchestrator) restoreIssues(codebaseName string) map[int]*IssueState {
+	issues := make(map[int]*IssueState)
+	for _, rec := range o.store.GetIssues(codebaseName) {
+		if rec.Label == "" {
+			continue
 		}
+		issues[rec.Number] = &IssueState{
+			Issue: &github.Issue{
+				Number: rec.Number,
+				Title:  rec.Title,
+			},
+			Label:       rec.Label,
+			LastChecked: rec.LastChecked,
+		}
+	}
+	return issues
+}
+
+// saveState writes the state store to disk. Dry runs keep state in memory only.
+func (o *Orchestrator) saveState() {
+	if o.dryRun {
+		return
+	}
+	if err := o.store.Save(); err != nil {
+		o.log("Warning: failed to save state: %v", err)
 	}
-	return ""
 }
 
 func (o *Orchestrator) getBranchName(issueNumber int) string {
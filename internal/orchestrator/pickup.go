@@ -1,24 +1,24 @@
 package orchestrator
 
 import (
-	"strings"
+	"fmt"
 	"time"
 
+	"github.com/nathanbarrett/dev-swarm-go/internal/command"
 	"github.com/nathanbarrett/dev-swarm-go/internal/config"
 	"github.com/nathanbarrett/dev-swarm-go/internal/github"
 )
 
-// ShouldPickup determines if an issue should be picked up for processing
-func (o *Orchestrator) ShouldPickup(issue *github.Issue, labelCfg *config.LabelConfig) bool {
-	sessionID := o.getSessionID(issue)
-
-	// Already has active session?
-	if o.sessionManager.HasSession(sessionID) {
+// ShouldPickup determines if an issue is eligible for pickup. Session capacity
+// is not checked here; eligible issues wait in the pickup queue for a slot.
+// For on_user_comment labels the issue must include its comments.
+func (o *Orchestrator) ShouldPickup(codebase *config.Codebase, issue *github.Issue, labelCfg *config.LabelConfig) bool {
+	if o.hasActiveSession(codebase, issue) {
 		return false
 	}
 
-	// At max capacity?
-	if !o.sessionManager.CanSpawn() {
+	// Waiting out the backoff after a failed session?
+	if o.inBackoff(codebase.Name, issue.Number) {
 		return false
 	}
 
@@ -31,35 +31,42 @@ func (o *Orchestrator) ShouldPickup(issue *github.Issue, labelCfg *config.LabelC
 		return false
 
 	case string(config.PickupOnUserComment):
-		return o.hasNewUserComment(issue)
+		handledAt := o.lastProcessedComment(codebase.Name, issue.Number)
+		if o.hasNewUserComment(codebase, issue, handledAt) {
+			return true
+		}
+		// Also check PR comments for code review
+		if labelCfg.Role == config.RoleCodeReview {
+			return o.hasNewUserPRComment(codebase, issue.Number, handledAt)
+		}
+		return false
 
 	default:
 		return false
 	}
 }
 
-// hasNewUserComment checks if there's a new user comment since the last AI comment
-func (o *Orchestrator) hasNewUserComment(issue *github.Issue) bool {
-	comments := issue.Comments
-	if len(comments) == 0 {
-		return false
-	}
-
-	var lastAICommentTime time.Time
-	var lastUserCommentTime time.Time
+// hasActiveSession checks if the issue already has a session
+func (o *Orchestrator) hasActiveSession(codebase *config.Codebase, issue *github.Issue) bool {
+	sessionID := fmt.Sprintf("%s#%d", codebase.Repo, issue.Number)
+	return o.sessionManager.HasSession(sessionID)
+}
 
-	for _, comment := range comments {
-		isAI := strings.Contains(comment.Body, "<!-- dev-swarm:ai -->")
+// lastProcessedComment returns when comments on an issue were last handed to a session
+func (o *Orchestrator) lastProcessedComment(codebaseName string, issueNumber int) time.Time {
+	rec, ok := o.store.GetIssue(codebaseName, issueNumber)
+	if !ok {
+		return time.Time{}
+	}
+	return rec.LastProcessedCommentAt
+}
 
-		if isAI {
-			if comment.CreatedAt.After(lastAICommentTime) {
-				lastAICommentTime = comment.CreatedAt
-			}
-		} else {
-			if comment.CreatedAt.After(lastUserCommentTime) {
-				lastUserCommentTime = comment.CreatedAt
-			}
-		}
+// hasNewUserComment checks if there's a new user comment since the last AI
+// comment and since comments were last handed to a session
+func (o *Orchestrator) hasNewUserComment(codebase *config.Codebase, issue *github.Issue, handledAt time.Time) bool {
+	lastAICommentTime, lastUserCommentTime := o.issueCommentTimes(codebase, issue)
+	if handledAt.After(lastAICommentTime) {
+		lastAICommentTime = handledAt
 	}
 
 	// New user comment exists if it's after the last AI comment
@@ -71,69 +78,141 @@ func (o *Orchestrator) hasNewUserComment(issue *github.Issue) bool {
 	return lastUserCommentTime.After(lastAICommentTime)
 }
 
-// hasNewUserPRComment checks if there's a new user comment on the PR since the last AI comment
-func (o *Orchestrator) hasNewUserPRComment(repo string, issueNumber int) bool {
+// hasNewUserPRComment checks if there's a new user comment on the PR since
+// the last AI comment and since comments were last handed to a session
+func (o *Orchestrator) hasNewUserPRComment(codebase *config.Codebase, issueNumber int, handledAt time.Time) bool {
+	lastAITime, lastUserTime, ok := o.prCommentTimes(codebase, issueNumber)
+	if !ok {
+		return false
+	}
+	if handledAt.After(lastAITime) {
+		lastAITime = handledAt
+	}
+
+	if lastUserTime.IsZero() {
+		return false
+	}
+
+	return lastUserTime.After(lastAITime)
+}
+
+// issueCommentTimes returns the times of the latest AI and user comments on an
+// issue. Comments from unauthorized users are ignored.
+func (o *Orchestrator) issueCommentTimes(codebase *config.Codebase, issue *github.Issue) (lastAI, lastUser time.Time) {
+	for _, comment := range issue.Comments {
+		// Notes and commands are handled by the orchestrator, not a session
+		if o.isNote(codebase, issue.Number, comment.Author.Login, comment.Body, comment.CreatedAt) || command.IsCommand(comment.Body) {
+			continue
+		}
+		if o.isAIComment(codebase, issue.Number, comment.Author.Login, comment.Body, comment.CreatedAt) {
+			if comment.CreatedAt.After(lastAI) {
+				lastAI = comment.CreatedAt
+			}
+		} else {
+			if comment.CreatedAt.After(lastUser) && o.isAuthorized(codebase, issue.Number, comment.Author.Login) {
+				lastUser = comment.CreatedAt
+			}
+		}
+	}
+	return lastAI, lastUser
+}
+
+// prCommentTimes returns the times of the latest AI and user comments and
+// reviews on the PR for an issue. Comments and reviews from unauthorized
+// users are ignored.
+func (o *Orchestrator) prCommentTimes(codebase *config.Codebase, issueNumber int) (lastAI, lastUser time.Time, ok bool) {
+	repo := codebase.Repo
 	branchName := o.getBranchName(issueNumber)
 
 	// Get PR for this issue
 	pr, err := o.ghClient.GetPRForBranch(repo, branchName)
 	if err != nil || pr == nil {
-		return false
+		return lastAI, lastUser, false
 	}
 
 	// Get PR comments
 	comments, err := o.ghClient.GetPRComments(repo, pr.Number)
 	if err != nil {
-		return false
+		return lastAI, lastUser, false
 	}
 
 	// Get PR reviews
 	reviews, err := o.ghClient.GetPRReviews(repo, pr.Number)
 	if err != nil {
-		return false
+		return lastAI, lastUser, false
 	}
 
-	var lastAITime time.Time
-	var lastUserTime time.Time
-
 	// Check comments
 	for _, comment := range comments {
-		isAI := strings.Contains(comment.Body, "<!-- dev-swarm:ai -->")
-		if isAI {
-			if comment.CreatedAt.After(lastAITime) {
-				lastAITime = comment.CreatedAt
+		if o.isNote(codebase, issueNumber, comment.Author.Login, comment.Body, comment.CreatedAt) {
+			continue
+		}
+		if o.isAIComment(codebase, issueNumber, comment.Author.Login, comment.Body, comment.CreatedAt) {
+			if comment.CreatedAt.After(lastAI) {
+				lastAI = comment.CreatedAt
 			}
 		} else {
-			if comment.CreatedAt.After(lastUserTime) {
-				lastUserTime = comment.CreatedAt
+			if comment.CreatedAt.After(lastUser) && o.isAuthorized(codebase, issueNumber, comment.Author.Login) {
+				lastUser = comment.CreatedAt
 			}
 		}
 	}
 
 	// Check reviews
 	for _, review := range reviews {
-		isAI := strings.Contains(review.Body, "<!-- dev-swarm:ai -->")
-		if isAI {
-			if review.CreatedAt.After(lastAITime) {
-				lastAITime = review.CreatedAt
+		if o.isAIComment(codebase, issueNumber, review.Author.Login, review.Body, review.CreatedAt) {
+			if review.CreatedAt.After(lastAI) {
+				lastAI = review.CreatedAt
 			}
 		} else {
-			if review.CreatedAt.After(lastUserTime) {
-				lastUserTime = review.CreatedAt
+			if review.CreatedAt.After(lastUser) && o.isAuthorized(codebase, issueNumber, review.Author.Login) {
+				lastUser = review.CreatedAt
 			}
 		}
 	}
 
-	if lastUserTime.IsZero() {
-		return false
+	return lastAI, lastUser, true
+}
+
+// isAIComment checks if a comment on an issue or its PR was posted by
+// dev-swarm or one of its sessions
+func (o *Orchestrator) isAIComment(codebase *config.Codebase, issueNumber int, author, body string, createdAt time.Time) bool {
+	rec, _ := o.store.GetIssue(codebase.Name, issueNumber)
+	return o.identity.IsAIComment(codebase.Repo, issueNumber, author, body, createdAt, rec.Sessions)
+}
+
+// isNote checks if a comment on an issue or its PR is a note posted by the
+// orchestrator
+func (o *Orchestrator) isNote(codebase *config.Codebase, issueNumber int, author, body string, createdAt time.Time) bool {
+	return o.identity.IsNote(codebase.Repo, issueNumber, author, body, createdAt)
+}
+
+// latestCommentTime returns the time of the most recent comment the session
+// will see, used as the processed-comment watermark for the issue
+func (o *Orchestrator) latestCommentTime(codebase *config.Codebase, issue *github.Issue, label string) time.Time {
+	lastAI, lastUser := o.issueCommentTimes(codebase, issue)
+	latest := lastAI
+	if lastUser.After(latest) {
+		latest = lastUser
 	}
 
-	return lastUserTime.After(lastAITime)
+	if codebase.GetWorkflow().HasRole(label, config.RoleCodeReview) {
+		if prAI, prUser, ok := o.prCommentTimes(codebase, issue.Number); ok {
+			if prAI.After(latest) {
+				latest = prAI
+			}
+			if prUser.After(latest) {
+				latest = prUser
+			}
+		}
+	}
+
+	return latest
 }
 
-// getPickupLabels returns all label names that should be polled
-func (o *Orchestrator) getPickupLabels() []string {
-	pickupLabels := o.config.Labels.GetPickupLabels()
+// getPickupLabels returns all label names that should be polled for a codebase
+func (o *Orchestrator) getPickupLabels(codebase *config.Codebase) []string {
+	pickupLabels := codebase.GetWorkflow().GetPickupLabels()
 	names := make([]string, 0, len(pickupLabels))
 	for _, label := range pickupLabels {
 		names = append(names, label.Name)
@@ -141,16 +220,34 @@ func (o *Orchestrator) getPickupLabels() []string {
 	return names
 }
 
-// getLabelConfig returns the label config for a given label name
-func (o *Orchestrator) getLabelConfig(name string) *config.LabelConfig {
-	return o.config.Labels.GetByName(name)
+// getCommandLabels returns the non-terminal labels that are not polled for
+// pickup but whose issues still take slash commands
+func (o *Orchestrator) getCommandLabels(codebase *config.Codebase) []string {
+	var names []string
+	for _, state := range codebase.GetWorkflow().States {
+		if state.Terminal || state.AIPickup == string(config.PickupAlways) || state.AIPickup == string(config.PickupOnUserComment) {
+			continue
+		}
+		names = append(names, state.Name)
+	}
+	return names
+}
+
+// getLabelConfig returns the workflow state for a given label name
+func (o *Orchestrator) getLabelConfig(codebase *config.Codebase, name string) *config.LabelConfig {
+	return codebase.GetWorkflow().GetByName(name)
 }
 
-// getCurrentLabel returns the current dev-swarm label for an issue
-func (o *Orchestrator) getCurrentLabel(issue *github.Issue) string {
+// roleLabel returns the label bound to a workflow role, or "" if the codebase has none
+func (o *Orchestrator) roleLabel(codebase *config.Codebase, role string) string {
+	return codebase.GetWorkflow().RoleLabel(role)
+}
+
+// getCurrentLabel returns the current workflow label for an issue
+func (o *Orchestrator) getCurrentLabel(codebase *config.Codebase, issue *github.Issue) string {
+	workflow := codebase.GetWorkflow()
 	for _, label := range issue.Labels {
-		// Check for dev-swarm labels
-		if strings.HasPrefix(label.Name, "user:") || strings.HasPrefix(label.Name, "ai:") {
+		if workflow.GetByName(label.Name) != nil {
 			return label.Name
 		}
 	}
@@ -158,10 +255,16 @@ func (o *Orchestrator) getCurrentLabel(issue *github.Issue) string {
 }
 
 // getAIAction returns the AI action for a label
-func (o *Orchestrator) getAIAction(labelName string) string {
-	labelCfg := o.getLabelConfig(labelName)
+func (o *Orchestrator) getAIAction(codebase *config.Codebase, labelName string) string {
+	labelCfg := o.getLabelConfig(codebase, labelName)
 	if labelCfg != nil {
 		return labelCfg.AIAction
 	}
 	return ""
 }
+
+// isTerminal checks if an issue is in a terminal workflow state
+func (o *Orchestrator) isTerminal(codebase *config.Codebase, issue *github.Issue) bool {
+	state := o.getLabelConfig(codebase, o.getCurrentLabel(codebase, issue))
+	return state != nil && state.Terminal
+}
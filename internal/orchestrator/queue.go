@@ -0,0 +1,187 @@
+package orchestrator
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+	"github.com/nathanbarrett/dev-swarm-go/internal/github"
+	"github.com/nathanbarrett/dev-swarm-go/internal/session"
+)
+
+// queuedIssue is an issue that is eligible for pickup and waiting for a session slot
+type queuedIssue struct {
+	codebase *config.Codebase
+	issue    *github.Issue
+	label    string
+	labelCfg *config.LabelConfig
+	score    int
+	leftover bool // Queued by an earlier drain; checked again before it is spawned
+}
+
+// key identifies the queued issue across codebases
+func (q *queuedIssue) key() string {
+	return queueKey(q.codebase.Name, q.issue.Number)
+}
+
+// queueKey builds the lookup key for an issue in the pickup queue
+func queueKey(codebaseName string, issueNumber int) string {
+	return fmt.Sprintf("%s#%d", codebaseName, issueNumber)
+}
+
+// newQueuedIssue builds a queue entry and scores it
+func (o *Orchestrator) newQueuedIssue(codebase *config.Codebase, issue *github.Issue, label string, labelCfg *config.LabelConfig) *queuedIssue {
+	return &queuedIssue{
+		codebase: codebase,
+		issue:    issue,
+		label:    label,
+		labelCfg: labelCfg,
+		score:    o.queueScore(codebase, issue, labelCfg),
+	}
+}
+
+// queueScore weighs an issue by its priority labels, workflow state and codebase
+func (o *Orchestrator) queueScore(codebase *config.Codebase, issue *github.Issue, labelCfg *config.LabelConfig) int {
+	score := labelCfg.Priority + codebase.Weight
+	for _, label := range issue.Labels {
+		score += o.config.Settings.PriorityLabels[label.Name]
+	}
+	return score
+}
+
+// rankedBefore orders entries by score, then oldest issue first
+func rankedBefore(a, b *queuedIssue) bool {
+	if a.score != b.score {
+		return a.score > b.score
+	}
+	if !a.issue.CreatedAt.Equal(b.issue.CreatedAt) {
+		return a.issue.CreatedAt.Before(b.issue.CreatedAt)
+	}
+	if a.codebase.Name != b.codebase.Name {
+		return a.codebase.Name < b.codebase.Name
+	}
+	return a.issue.Number < b.issue.Number
+}
+
+// fairShareOrder interleaves ranked entries across codebases. Each pick goes
+// to the codebase with the fewest running and already picked sessions so every
+// repo makes progress; ties go to the higher ranked entry.
+func fairShareOrder(entries []*queuedIssue, running map[string]int) []*queuedIssue {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return rankedBefore(entries[i], entries[j])
+	})
+
+	var names []string
+	byCodebase := make(map[string][]*queuedIssue)
+	for _, entry := range entries {
+		name := entry.codebase.Name
+		if _, ok := byCodebase[name]; !ok {
+			names = append(names, name)
+		}
+		byCodebase[name] = append(byCodebase[name], entry)
+	}
+
+	load := make(map[string]int, len(names))
+	for _, name := range names {
+		load[name] = running[name]
+	}
+
+	ordered := make([]*queuedIssue, 0, len(entries))
+	for len(ordered) < len(entries) {
+		best := ""
+		for _, name := range names {
+			if len(byCodebase[name]) == 0 {
+				continue
+			}
+			if best == "" || load[name] < load[best] ||
+				(load[name] == load[best] && rankedBefore(byCodebase[name][0], byCodebase[best][0])) {
+				best = name
+			}
+		}
+		ordered = append(ordered, byCodebase[best][0])
+		byCodebase[best] = byCodebase[best][1:]
+		load[best]++
+	}
+	return ordered
+}
+
+// drainQueue ranks the eligible issues found during a poll or trigger, spawns sessions
+// for as many as the session caps allow and keeps the rest queued
+func (o *Orchestrator) drainQueue(candidates []*queuedIssue) {
+	ordered := fairShareOrder(candidates, o.sessionManager.ActiveCountByCodebase())
+
+	// Dry runs start no sessions, so count the ones they would have started
+	var planned []session.SpawnRequest
+
+	remaining := make([]*queuedIssue, 0, len(ordered))
+	now := o.now()
+	exhausted := o.budgetExhausted(now)
+	o.noteBudget(exhausted)
+	for _, entry := range ordered {
+		// Issues stay queued while the budget is spent or outside their
+		// codebase's schedule
+		if exhausted != "" || !o.schedules[entry.codebase.Name].Open(now) {
+			remaining = append(remaining, entry)
+			continue
+		}
+		if !o.sessionManager.CanSpawnAlongside(entry.codebase.Name, entry.labelCfg.Phase, planned) {
+			remaining = append(remaining, entry)
+			continue
+		}
+		if entry.leftover {
+			fresh := o.recheckQueued(entry)
+			if fresh == nil {
+				continue // Moved on since it was queued
+			}
+			if fresh.labelCfg.Phase != entry.labelCfg.Phase &&
+				!o.sessionManager.CanSpawnAlongside(fresh.codebase.Name, fresh.labelCfg.Phase, planned) {
+				remaining = append(remaining, fresh)
+				continue
+			}
+			entry = fresh
+		}
+		o.spawnSession(entry)
+		if o.dryRun {
+			planned = append(planned, session.SpawnRequest{Codebase: entry.codebase, Phase: entry.labelCfg.Phase})
+		}
+	}
+
+	positions := make(map[string]int, len(remaining))
+	for i, entry := range remaining {
+		positions[entry.key()] = i + 1
+	}
+
+	o.mu.Lock()
+	previous := len(o.queuePositions)
+	o.queue = remaining
+	o.queuePositions = positions
+	o.mu.Unlock()
+
+	if len(remaining) > 0 && len(remaining) != previous {
+		o.log("%d issue(s) queued waiting for a session slot", len(remaining))
+	}
+}
+
+// queuePosition returns the 1-based position of an issue in the pickup queue,
+// or 0 if it is not queued. Callers must hold o.mu.
+func (o *Orchestrator) queuePosition(codebaseName string, issueNumber int) int {
+	return o.queuePositions[queueKey(codebaseName, issueNumber)]
+}
+
+// countWaitingIssues counts tracked issues that wait on a user action.
+// Callers must hold o.mu.
+func (o *Orchestrator) countWaitingIssues() int {
+	count := 0
+	for _, cb := range o.codebases {
+		for num, issue := range cb.Issues {
+			if issue.HasSession || o.queuePosition(cb.Config.Name, num) > 0 {
+				continue
+			}
+			labelCfg := o.getLabelConfig(cb.Config, issue.Label)
+			if labelCfg != nil && labelCfg.Owner == string(config.OwnerUser) {
+				count++
+			}
+		}
+	}
+	return count
+}
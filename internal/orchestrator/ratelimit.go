@@ -0,0 +1,85 @@
+package orchestrator
+
+import (
+	"time"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/github"
+)
+
+// rateLimitReserve is the share of the GitHub quota polling leaves alone for
+// sessions, whose agents usually call GitHub with the same token
+const rateLimitReserve = 0.2
+
+// quotaSpacing returns how far apart polls that each use cost requests must
+// be for the quota left, less the reserve, to last until it resets. Zero
+// while the quota or the cost of a poll is unknown.
+func quotaSpacing(rl github.RateLimit, cost int) time.Duration {
+	if !rl.Known() || cost <= 0 {
+		return 0
+	}
+	window := rl.Reset.Sub(rl.Observed)
+	if window <= 0 {
+		return 0
+	}
+	polls := (rl.Remaining - int(float64(rl.Limit)*rateLimitReserve)) / cost
+	if polls < 1 {
+		return window // Wait for the reset
+	}
+	return window / time.Duration(polls)
+}
+
+// nextPollAt returns when the next poll is due: the poll interval after the
+// last one, later while a GitHub quota runs short or a rate limit holds
+// requests back. Callers hold o.mu.
+func (o *Orchestrator) nextPollAt() time.Time {
+	next := o.lastPoll.Add(o.getPollInterval())
+	for resource, rl := range o.ghClient.RateLimits() {
+		if spaced := o.lastPoll.Add(quotaSpacing(rl, o.pollCosts[resource])); spaced.After(next) {
+			next = spaced
+		}
+	}
+	if held := o.ghClient.RateLimit().HeldUntil; held.After(next) {
+		next = held
+	}
+	return next
+}
+
+// recordPollCost notes how many requests a poll used of each quota, going by
+// the quotas before and after it. A quota that reset during the poll keeps
+// the cost of an earlier poll.
+func (o *Orchestrator) recordPollCost(before map[string]github.RateLimit) {
+	after := o.ghClient.RateLimits()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for resource, rl := range after {
+		start, ok := before[resource]
+		if !ok || !start.Known() || !rl.Reset.Equal(start.Reset) {
+			continue
+		}
+		if cost := start.Remaining - rl.Remaining; cost >= 0 {
+			o.pollCosts[resource] = cost
+		}
+	}
+}
+
+// rateLimited checks if a rate limit holds back requests to GitHub, and if
+// so marks the codebase so its poll is skipped rather than failed
+func (o *Orchestrator) rateLimited(cbState *CodebaseState, err error) bool {
+	if err == nil {
+		if rl := o.ghClient.RateLimit(); rl.HeldUntil.After(o.now()) {
+			err = &github.RateLimitError{Until: rl.HeldUntil}
+		}
+	}
+	if !github.IsRateLimited(err) {
+		return false
+	}
+
+	o.log("Skipping %s: %v", cbState.Config.Repo, err)
+	o.mu.Lock()
+	cbState.IsHealthy = true
+	cbState.RateLimited = true
+	cbState.Error = err
+	o.mu.Unlock()
+	return true
+}
@@ -0,0 +1,214 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+	"github.com/nathanbarrett/dev-swarm-go/internal/session"
+	"github.com/nathanbarrett/dev-swarm-go/internal/state"
+)
+
+// failureOutputLines is the number of output lines quoted when blocking an issue
+const failureOutputLines = 30
+
+// inBackoff returns true if the issue is waiting out a retry backoff
+func (o *Orchestrator) inBackoff(codebaseName string, issueNumber int) bool {
+	rec, ok := o.store.GetIssue(codebaseName, issueNumber)
+	if !ok {
+		return false
+	}
+	return o.now().Before(rec.NextAttemptAt)
+}
+
+// retryBackoff returns the delay before the next attempt after n failures
+func (o *Orchestrator) retryBackoff(failures int) time.Duration {
+	base := time.Duration(o.config.Settings.RetryBackoff) * time.Second
+	limit := time.Duration(o.config.Settings.MaxRetryBackoff) * time.Second
+
+	delay := base
+	for i := 1; i < failures && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+	return delay
+}
+
+// handleSessionResult verifies the outcome of a finished session and updates
+// the retry budget for its issue. Sessions that exit cleanly and leave the
+// issue in a valid state reset the budget. Failed sessions and invalid
+// outcomes back off exponentially, with the label rolled back to where the
+// session picked the issue up, until the budget is spent; then the issue is
+// moved to the blocked label.
+func (o *Orchestrator) handleSessionResult(sess *session.Session) {
+	info := sess.Info()
+	codebase := sess.Codebase
+
+	if info.Status == session.StatusTimedOut {
+		o.reportTimeout(codebase, info)
+	}
+
+	currentLabel, known := o.fetchCurrentLabel(codebase, info.IssueNumber)
+	var problem string
+	if known {
+		problem = o.verifyOutcome(sess, currentLabel)
+	}
+
+	// The poll never sees labels that are not picked up, such as blocked, so
+	// a label the session left in place is recorded here for hooks and
+	// notifications. Invalid labels are about to be rolled back instead.
+	if known && currentLabel != "" && (problem == "" || codebase.GetWorkflow().HasRole(currentLabel, config.RoleBlocked)) {
+		if rec, _ := o.store.GetIssue(codebase.Name, info.IssueNumber); rec.Label != currentLabel {
+			o.noteLabelChange(codebase, info.IssueNumber, currentLabel)
+		}
+	}
+
+	if problem == "" && info.Status != session.StatusFailed && info.Status != session.StatusTimedOut {
+		o.store.UpdateIssue(codebase.Name, info.IssueNumber, func(rec *state.IssueRecord) {
+			rec.Attempts = 0
+			rec.NextAttemptAt = time.Time{}
+			rec.RevisionNotes = "" // Addressed
+		})
+		return
+	}
+
+	var attempts int
+	o.store.UpdateIssue(codebase.Name, info.IssueNumber, func(rec *state.IssueRecord) {
+		rec.Attempts++
+		attempts = rec.Attempts
+		rec.NextAttemptAt = o.now().Add(o.retryBackoff(rec.Attempts))
+		if problem == "" {
+			return
+		}
+		if last := rec.LastSession(); last != nil && last.ID == info.ID {
+			last.Outcome = state.OutcomeFailed
+			last.Error = "verification failed: " + problem
+		}
+	})
+	if problem != "" {
+		o.log("Session for %s#%d failed verification: %s", codebase.Repo, info.IssueNumber, problem)
+	}
+
+	if !known {
+		// Without the current label the working label is the best guess
+		currentLabel = info.Label
+		if labelCfg := o.getLabelConfig(codebase, info.Label); labelCfg != nil && labelCfg.WorkingLabel != "" {
+			currentLabel = labelCfg.WorkingLabel
+		}
+	}
+
+	maxAttempts := o.config.Settings.MaxSessionAttempts
+	if attempts < maxAttempts {
+		o.log("Session for %s#%d failed (attempt %d of %d), retrying in %s",
+			codebase.Repo, info.IssueNumber, attempts, maxAttempts, o.retryBackoff(attempts))
+		if problem != "" {
+			o.rollbackLabel(codebase, info, currentLabel)
+		}
+		return
+	}
+
+	blocked := o.roleLabel(codebase, config.RoleBlocked)
+	if blocked == "" {
+		o.log("Session for %s#%d failed %d times, but the workflow has no blocked state",
+			codebase.Repo, info.IssueNumber, attempts)
+		if problem != "" {
+			o.rollbackLabel(codebase, info, currentLabel)
+		}
+		return
+	}
+
+	o.log("Session for %s#%d failed %d times, moving to %s",
+		codebase.Repo, info.IssueNumber, attempts, blocked)
+	o.blockAfterFailures(codebase, sess, currentLabel, blocked, attempts, problem)
+}
+
+// rollbackLabel moves an issue back to the label its session picked it up
+// from so it is retried once the backoff expires. Issues the agent moved to
+// the blocked label stay there.
+func (o *Orchestrator) rollbackLabel(codebase *config.Codebase, info session.SessionInfo, currentLabel string) {
+	if currentLabel == info.Label || codebase.GetWorkflow().HasRole(currentLabel, config.RoleBlocked) {
+		return
+	}
+
+	if err := o.transitionLabel(codebase, info.IssueNumber, currentLabel, info.Label); err != nil {
+		o.log("Error moving %s#%d back to %s: %v", codebase.Repo, info.IssueNumber, info.Label, err)
+		return
+	}
+
+	o.replayComments(codebase, info.IssueNumber, info.Label)
+}
+
+// replayComments lets the comments a session failed to handle trigger pickup
+// again once the issue is back in a state that waits for user comments
+func (o *Orchestrator) replayComments(codebase *config.Codebase, issueNum int, label string) {
+	if labelCfg := o.getLabelConfig(codebase, label); labelCfg != nil && labelCfg.AIPickup == string(config.PickupOnUserComment) {
+		o.store.UpdateIssue(codebase.Name, issueNum, func(rec *state.IssueRecord) {
+			rec.LastProcessedCommentAt = time.Time{}
+		})
+	}
+}
+
+// blockAfterFailures moves an issue to the blocked label and explains why
+func (o *Orchestrator) blockAfterFailures(codebase *config.Codebase, sess *session.Session, currentLabel, blocked string, attempts int, problem string) {
+	info := sess.Info()
+
+	if err := o.transitionLabel(codebase, info.IssueNumber, currentLabel, blocked); err != nil {
+		o.log("Error moving %s#%d to %s: %v", codebase.Repo, info.IssueNumber, blocked, err)
+		return
+	}
+
+	note := buildFailureComment(info, sess.GetRecentOutput(failureOutputLines), attempts, problem)
+	if err := o.postNote(codebase, info.IssueNumber, note); err != nil {
+		o.log("Error commenting on %s#%d: %v", codebase.Repo, info.IssueNumber, err)
+	}
+}
+
+// reportTimeout notes on the issue that its session was killed by a limit.
+// It is a note rather than a reply so the comments the session was handling
+// still trigger pickup once the issue is rolled back.
+func (o *Orchestrator) reportTimeout(codebase *config.Codebase, info session.SessionInfo) {
+	o.log("Session for %s#%d timed out: %s", codebase.Repo, info.IssueNumber, info.TimeoutReason)
+
+	body := fmt.Sprintf("The dev-swarm session for `%s` timed out after %s (%s) and was stopped.",
+		info.Label, info.Duration.Round(time.Second), info.TimeoutReason)
+	if err := o.postNote(codebase, info.IssueNumber, body); err != nil {
+		o.log("Error commenting on %s#%d: %v", codebase.Repo, info.IssueNumber, err)
+	}
+}
+
+// buildFailureComment describes the last failed session for the issue thread
+func buildFailureComment(info session.SessionInfo, output []session.OutputLine, attempts int, problem string) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("dev-swarm stopped retrying this issue after %d failed sessions.\n\n", attempts))
+	sb.WriteString(fmt.Sprintf("- **Label**: `%s`\n", info.Label))
+	if info.ExitCode != nil {
+		sb.WriteString(fmt.Sprintf("- **Last exit code**: %d\n", *info.ExitCode))
+	} else {
+		sb.WriteString("- **Last exit code**: unknown\n")
+	}
+	if info.TimeoutReason != "" {
+		sb.WriteString(fmt.Sprintf("- **Timed out**: %s\n", info.TimeoutReason))
+	} else if info.Error != nil {
+		sb.WriteString(fmt.Sprintf("- **Error**: %s\n", info.Error))
+	}
+	if problem != "" {
+		sb.WriteString(fmt.Sprintf("- **Verification**: %s\n", problem))
+	}
+
+	if len(output) > 0 {
+		sb.WriteString(fmt.Sprintf("\n<details>\n<summary>Last %d lines of output</summary>\n\n```\n", len(output)))
+		for _, line := range output {
+			// Keep the session output from closing the code fence
+			sb.WriteString(strings.ReplaceAll(line.Text, "```", "` ` `"))
+			sb.WriteString("\n")
+		}
+		sb.WriteString("```\n\n</details>\n")
+	}
+
+	sb.WriteString("\nOnce the problem is resolved, comment `/retry` or move the issue back to a pickup label.")
+	return sb.String()
+}
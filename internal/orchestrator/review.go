@@ -0,0 +1,165 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+	"github.com/nathanbarrett/dev-swarm-go/internal/github"
+	"github.com/nathanbarrett/dev-swarm-go/internal/hooks"
+	"github.com/nathanbarrett/dev-swarm-go/internal/state"
+)
+
+// handleReviews acts on the deciding review of an issue's PR: approved PRs
+// are merged once GitHub allows it and change requests send the issue back
+// to implementation. It returns true if the issue was moved on.
+func (o *Orchestrator) handleReviews(codebase *config.Codebase, issueNum int, currentLabel string) bool {
+	pr, err := o.ghClient.GetPRForBranch(codebase.Repo, o.getBranchName(issueNum))
+	if err != nil || pr == nil {
+		return false
+	}
+
+	reviews, err := o.ghClient.GetPRReviews(codebase.Repo, pr.Number)
+	if err != nil {
+		o.log("Error fetching reviews for %s#%d: %v", codebase.Repo, pr.Number, err)
+		return false
+	}
+
+	rec, _ := o.store.GetIssue(codebase.Name, issueNum)
+	review := github.ReviewDecision(reviews, pr.HeadOid, rec.HandledReviewID, func(r github.PRReview) bool {
+		return o.authorizedReviewer(codebase, issueNum, r)
+	})
+	if review == nil || review.ID == rec.HandledReviewID {
+		return false
+	}
+
+	switch review.State {
+	case github.ReviewChangesRequested:
+		return o.requestChanges(codebase, issueNum, currentLabel, pr, review)
+	case github.ReviewApproved:
+		if !o.config.Settings.AutoMergeOnApproval {
+			return false
+		}
+		return o.mergeApproved(codebase, issueNum, currentLabel, pr, review)
+	}
+	return false
+}
+
+// authorizedReviewer checks if a review counts towards merging or sending
+// back a PR. Without merge reviewers the codebase's access rules apply.
+func (o *Orchestrator) authorizedReviewer(codebase *config.Codebase, issueNum int, review github.PRReview) bool {
+	if len(codebase.Merge.Reviewers) == 0 {
+		return o.isAuthorized(codebase, issueNum, review.Author.Login)
+	}
+	for _, login := range codebase.Merge.Reviewers {
+		if strings.EqualFold(login, review.Author.Login) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestChanges moves an issue whose PR received a change request back to
+// implementation, quoting the review so the next session can address it
+func (o *Orchestrator) requestChanges(codebase *config.Codebase, issueNum int, currentLabel string, pr *github.PullRequest, review *github.PRReview) bool {
+	target := o.roleLabel(codebase, config.RoleReadyToImplement)
+	if target == "" {
+		return false // Leave the review to a code review session
+	}
+
+	if err := o.transitionLabel(codebase, issueNum, currentLabel, target); err != nil {
+		o.log("Error moving %s#%d to %s: %v", codebase.Repo, issueNum, target, err)
+		return false
+	}
+	o.log("Changes requested on %s#%d by %s, moved issue #%d to %s", codebase.Repo, pr.Number, review.Author.Login, issueNum, target)
+
+	note := fmt.Sprintf("@%s requested changes on #%d. dev-swarm moved this issue back to %s so the review can be addressed on the existing branch.",
+		review.Author.Login, pr.Number, target)
+	if body := strings.TrimSpace(review.Body); body != "" {
+		note += "\n\n> " + strings.ReplaceAll(body, "\n", "\n> ")
+	}
+	if err := o.postNote(codebase, issueNum, note); err != nil {
+		o.log("Error commenting on %s#%d: %v", codebase.Repo, issueNum, err)
+	}
+
+	o.markReviewHandled(codebase, issueNum, review)
+	return true
+}
+
+// mergeApproved merges an approved PR once GitHub reports it mergeable and
+// moves the issue to the done state
+func (o *Orchestrator) mergeApproved(codebase *config.Codebase, issueNum int, currentLabel string, pr *github.PullRequest, review *github.PRReview) bool {
+	// The approval itself needs no session, whether or not the merge can happen yet
+	o.store.UpdateIssue(codebase.Name, issueNum, func(rec *state.IssueRecord) {
+		if review.CreatedAt.After(rec.LastProcessedCommentAt) {
+			rec.LastProcessedCommentAt = review.CreatedAt
+		}
+	})
+
+	status, err := o.ghClient.GetPRMergeStatus(codebase.Repo, pr.Number)
+	if err != nil {
+		o.log("Error fetching merge status for %s#%d: %v", codebase.Repo, pr.Number, err)
+		return false
+	}
+	if status.HeadOid != review.Commit.Oid {
+		return false // New commits since the approval
+	}
+	if status.MergeBlocked() {
+		o.log("Approved PR %s#%d cannot be merged (%s)", codebase.Repo, pr.Number, strings.ToLower(status.MergeStateStatus))
+		note := fmt.Sprintf("#%d was approved by @%s but cannot be merged automatically: the branch has conflicts or is behind %s.",
+			pr.Number, review.Author.Login, codebase.DefaultBranch)
+		if err := o.postNote(codebase, issueNum, note); err != nil {
+			o.log("Error commenting on %s#%d: %v", codebase.Repo, issueNum, err)
+		}
+		o.markReviewHandled(codebase, issueNum, review)
+		return false
+	}
+	if !status.ReadyToMerge() {
+		return false // Waiting for required checks
+	}
+
+	mergeErr := o.mergePR(codebase, issueNum, currentLabel, pr.Number, review.Author.Login)
+	o.markReviewHandled(codebase, issueNum, review)
+
+	note := fmt.Sprintf("Merged #%d (%s) after approval from @%s.", pr.Number, codebase.Merge.MergeStrategy(), review.Author.Login)
+	if mergeErr != nil {
+		note = fmt.Sprintf("dev-swarm could not merge #%d after approval from @%s:\n\n```\n%s\n```",
+			pr.Number, review.Author.Login, mergeErr)
+	}
+	if err := o.postNote(codebase, issueNum, note); err != nil {
+		o.log("Error commenting on %s#%d: %v", codebase.Repo, issueNum, err)
+	}
+	return mergeErr == nil
+}
+
+// mergePR merges an issue's PR with the codebase's strategy and moves the
+// issue to the done state
+func (o *Orchestrator) mergePR(codebase *config.Codebase, issueNum int, currentLabel string, prNum int, approver string) error {
+	strategy := codebase.Merge.MergeStrategy()
+	if o.dryRunSkip(codebase, issueNum, "%s PR #%d approved by %s", strategy, prNum, approver) {
+		return nil
+	}
+	if err := o.ghClient.MergePR(codebase.Repo, prNum, strategy, !codebase.Merge.KeepBranch); err != nil {
+		o.log("Error merging %s#%d: %v", codebase.Repo, prNum, err)
+		return err
+	}
+	o.log("Merged %s#%d (%s) after approval from %s", codebase.Repo, prNum, strategy, approver)
+	o.fireHook(codebase, issueNum, config.HookPRMerged, hooks.PRMerged{PR: prNum, Strategy: strategy, ApprovedBy: approver})
+
+	if done := o.roleLabel(codebase, config.RoleDone); done != "" {
+		if err := o.transitionLabel(codebase, issueNum, currentLabel, done); err != nil {
+			o.log("Error moving %s#%d to %s: %v", codebase.Repo, issueNum, done, err)
+		}
+	}
+	return nil
+}
+
+// markReviewHandled records that the orchestrator acted on a review
+func (o *Orchestrator) markReviewHandled(codebase *config.Codebase, issueNum int, review *github.PRReview) {
+	o.store.UpdateIssue(codebase.Name, issueNum, func(rec *state.IssueRecord) {
+		rec.HandledReviewID = review.ID
+		if review.CreatedAt.After(rec.LastProcessedCommentAt) {
+			rec.LastProcessedCommentAt = review.CreatedAt
+		}
+	})
+}
@@ -0,0 +1,666 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+	"github.com/nathanbarrett/dev-swarm-go/internal/git"
+	"github.com/nathanbarrett/dev-swarm-go/internal/github"
+	"github.com/nathanbarrett/dev-swarm-go/internal/github/githubtest"
+	"github.com/nathanbarrett/dev-swarm-go/internal/hooks"
+	"github.com/nathanbarrett/dev-swarm-go/internal/notify"
+	"github.com/nathanbarrett/dev-swarm-go/internal/session"
+	"github.com/nathanbarrett/dev-swarm-go/internal/state"
+)
+
+const testRepo = "owner/app"
+
+// harness runs an orchestrator against an in-memory forge. Sessions run a
+// command agent that exits at once; tests play the agent's part by changing
+// the forge after the session ends.
+type harness struct {
+	t     *testing.T
+	o     *Orchestrator
+	forge *githubtest.Forge
+	home  string
+
+	mu            sync.Mutex
+	notifications []notify.Notification
+}
+
+// newHarness creates an orchestrator whose sessions run agent, "ok", "fail"
+// or "slow", in a clone of a local origin. State, worktrees and the events of
+// label.changed hooks live under a temporary home directory.
+func newHarness(t *testing.T, agent string) *harness {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	clone := initClone(t, home)
+
+	configPath := filepath.Join(home, "config.yaml")
+	configYAML := fmt.Sprintf(`settings:
+  agent: %s
+  agents:
+    ok:
+      command: sh
+      args: ["-c", "exit 0"]
+    fail:
+      command: sh
+      args: ["-c", "echo giving up; exit 1"]
+    slow:
+      command: sh
+      args: ["-c", "sleep 30"]
+  hooks:
+    - event: label.changed
+      command: "{ cat; echo; } >> %s"
+codebases:
+  - name: app
+    repo: %s
+    local_path: %s
+    default_branch: main
+    enabled: true
+    access:
+      users: [alice]
+`, agent, filepath.Join(home, "label-hooks.jsonl"), testRepo, clone)
+	if err := os.WriteFile(configPath, []byte(configYAML), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		t.Fatalf("config.Load() error = %v", err)
+	}
+
+	h := &harness{t: t, forge: githubtest.NewForge(), home: home}
+	h.start(cfg)
+	return h
+}
+
+// start creates the orchestrator, loading the state a previous one saved
+func (h *harness) start(cfg *config.Config) {
+	h.t.Helper()
+	o, err := New(cfg, log.New(io.Discard, "", 0), WithGitHub(h.forge), WithClock(h.forge))
+	if err != nil {
+		h.t.Fatalf("New() error = %v", err)
+	}
+	h.t.Cleanup(func() {
+		o.sessionManager.StopAll()
+		o.hooks.Wait()
+		o.notifier.Wait()
+		o.cancel()
+	})
+	o.notifier.AddSink(notify.FuncSink{SinkName: "test", Fn: func(batch []notify.Notification) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.notifications = append(h.notifications, batch...)
+	}}, notify.Filter{})
+	h.o = o
+}
+
+// restart saves the state and replaces the orchestrator with a new one, as
+// if dev-swarm was restarted
+func (h *harness) restart() {
+	h.o.saveState()
+	h.o.sessionManager.StopAll()
+	h.start(h.o.config)
+}
+
+// initClone creates an origin repository with a main branch and returns a clone of it
+func initClone(t *testing.T, dir string) string {
+	t.Helper()
+	origin := filepath.Join(dir, "origin.git")
+	clone := filepath.Join(dir, "app")
+
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@test.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@test.com")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Skipf("git %s failed: %v\n%s", strings.Join(args, " "), err, output)
+		}
+	}
+	run("init", "--bare", "-b", "main", origin)
+	run("clone", origin, clone)
+	run("-C", clone, "commit", "--allow-empty", "-m", "initial")
+	run("-C", clone, "push", "origin", "HEAD:main")
+	return clone
+}
+
+// poll runs one polling cycle
+func (h *harness) poll() {
+	h.o.poll()
+}
+
+// waitForSessions waits until every running session has ended
+func (h *harness) waitForSessions() {
+	h.t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		done := true
+		for _, sess := range h.o.sessionManager.GetAllSessions() {
+			if !sess.IsComplete() {
+				done = false
+			}
+		}
+		if done {
+			return
+		}
+		if time.Now().After(deadline) {
+			h.t.Fatalf("sessions did not finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// wantLabel checks an issue's labels on the forge
+func (h *harness) wantLabel(issueNum int, want string) {
+	h.t.Helper()
+	if got := h.forge.Labels(testRepo, issueNum); len(got) != 1 || got[0] != want {
+		h.t.Fatalf("labels of #%d = %v, want [%s]", issueNum, got, want)
+	}
+}
+
+// attempts returns the failed session attempts recorded for an issue
+func (h *harness) attempts(issueNum int) int {
+	rec, _ := h.o.store.GetIssue("app", issueNum)
+	return rec.Attempts
+}
+
+// signed wraps a reply the way the agent of the issue's latest session would
+func (h *harness) signed(issueNum int, body string) string {
+	h.t.Helper()
+	rec, _ := h.o.store.GetIssue("app", issueNum)
+	last := rec.LastSession()
+	if last == nil {
+		h.t.Fatalf("#%d has no session to sign a reply for", issueNum)
+	}
+	return h.o.identity.Wrap(testRepo, issueNum, last.Nonce, body)
+}
+
+// pastSession records a session that ran for an issue before the test started
+func (h *harness) pastSession(issueNum int, label string) {
+	now := h.forge.Now()
+	h.o.store.RecordSessionStart("app", issueNum, fmt.Sprintf("%s#%d", testRepo, issueNum), label, session.NewNonce(), now.Add(-time.Minute))
+	h.o.store.RecordSessionEnd("app", issueNum, state.OutcomeCompleted, nil, nil, nil, now)
+}
+
+// notified returns the notifications sent so far
+func (h *harness) notified() []notify.Notification {
+	h.o.notifier.Wait()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]notify.Notification(nil), h.notifications...)
+}
+
+// labelHooks returns the label changes the label.changed hooks received
+func (h *harness) labelHooks() []hooks.LabelChanged {
+	h.t.Helper()
+	h.o.hooks.Wait()
+	data, err := os.ReadFile(filepath.Join(h.home, "label-hooks.jsonl"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		h.t.Fatalf("failed to read hook events: %v", err)
+	}
+
+	var changes []hooks.LabelChanged
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var event struct {
+			Payload hooks.LabelChanged `json:"payload"`
+		}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			h.t.Fatalf("failed to decode hook event %q: %v", line, err)
+		}
+		changes = append(changes, event.Payload)
+	}
+	return changes
+}
+
+// lastComment returns the body of the newest comment on an issue
+func (h *harness) lastComment(issueNum int) string {
+	comments := h.forge.Issue(testRepo, issueNum).Comments
+	if len(comments) == 0 {
+		return ""
+	}
+	return comments[len(comments)-1].Body
+}
+
+func TestScenarioPlanImplementMerge(t *testing.T) {
+	h := newHarness(t, "ok")
+	issue := h.forge.CreateIssue(testRepo, "Dark mode", "Add a dark theme", "user:ready-to-plan")
+
+	h.poll()
+	h.wantLabel(issue, "ai:planning")
+
+	// The planning session posts a plan and hands the issue to the user
+	h.waitForSessions()
+	h.forge.Comment(testRepo, issue, "dev-swarm", h.signed(issue, "Plan: add a theme toggle"))
+	h.forge.Advance(time.Minute)
+	h.forge.SetLabels(testRepo, issue, "user:plan-review")
+	h.poll()
+	h.wantLabel(issue, "user:plan-review")
+	if got := h.attempts(issue); got != 0 {
+		t.Errorf("attempts after planning = %d, want 0", got)
+	}
+
+	// The plan is approved with a command rather than picked up again
+	h.forge.Advance(time.Minute)
+	h.forge.Comment(testRepo, issue, "alice", "/approve")
+	h.poll()
+	h.wantLabel(issue, "user:ready-to-implement")
+	if got := h.o.sessionManager.GetAllSessions(); len(got) != 0 {
+		t.Errorf("got %d sessions after /approve, want none", len(got))
+	}
+
+	h.forge.Advance(time.Minute)
+	h.poll()
+	h.wantLabel(issue, "ai:implementing")
+
+	// The implementing session pushes the branch and opens a PR
+	h.waitForSessions()
+	h.forge.Advance(time.Minute)
+	pr := h.forge.OpenPR(testRepo, git.GetBranchName(issue), "Dark mode")
+	h.forge.SetLabels(testRepo, issue, "user:code-review")
+	h.poll()
+	h.wantLabel(issue, "user:code-review")
+	if got := h.attempts(issue); got != 0 {
+		t.Errorf("attempts after implementing = %d, want 0", got)
+	}
+
+	// An approving review merges the PR
+	h.forge.Advance(time.Minute)
+	h.forge.Review(testRepo, pr, "alice", github.ReviewApproved, "Looks good")
+	h.poll()
+	h.wantLabel(issue, "ai:done")
+	if !h.forge.PRState(testRepo, pr).Merged {
+		t.Errorf("PR #%d was not merged", pr)
+	}
+	if got := h.lastComment(issue); !strings.Contains(got, fmt.Sprintf("Merged #%d", pr)) {
+		t.Errorf("last comment = %q, want the merge reported", got)
+	}
+
+	// Cleanup only asks for PRs merged since the ones it has seen
+	if got := h.o.store.MergedPRsSeen("app"); !got.Equal(h.forge.Now()) {
+		t.Errorf("MergedPRsSeen() = %v, want the merge time %v", got, h.forge.Now())
+	}
+	if git.WorktreeExists(git.GetWorktreePath(config.WorktreesDir(), "app", issue)) {
+		t.Errorf("worktree of #%d was not removed after the merge", issue)
+	}
+}
+
+func TestScenarioFailedSessionsBlock(t *testing.T) {
+	h := newHarness(t, "fail")
+	issue := h.forge.CreateIssue(testRepo, "Dark mode", "", "user:ready-to-plan")
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		h.poll()
+		h.wantLabel(issue, "ai:planning")
+		h.waitForSessions()
+		h.poll()
+		if got := h.attempts(issue); got != attempt {
+			t.Fatalf("attempts = %d, want %d", got, attempt)
+		}
+		if attempt == 3 {
+			break
+		}
+		h.wantLabel(issue, "user:ready-to-plan")
+
+		// Nothing is picked up until the backoff has passed
+		h.poll()
+		h.wantLabel(issue, "user:ready-to-plan")
+		h.forge.Advance(h.o.retryBackoff(attempt))
+	}
+
+	h.wantLabel(issue, "user:blocked")
+	if got := h.lastComment(issue); !strings.Contains(got, "stopped retrying this issue after 3 failed sessions") ||
+		!strings.Contains(got, "giving up") {
+		t.Errorf("last comment = %q, want the failure explained with the session output", got)
+	}
+	if got := h.lastComment(issue); !h.o.isNote(h.o.config.GetCodebaseByRepo(testRepo), issue, "dev-swarm", got, h.forge.Now()) {
+		t.Errorf("last comment = %q, want an orchestrator note rather than an AI reply", got)
+	}
+}
+
+func TestScenarioAgentBlocks(t *testing.T) {
+	h := newHarness(t, "ok")
+	issue := h.forge.CreateIssue(testRepo, "Dark mode", "", "user:ready-to-plan")
+
+	h.poll()
+	h.wantLabel(issue, "ai:planning")
+
+	// The agent gets stuck and asks for help; blocked is never polled
+	h.waitForSessions()
+	h.forge.SetLabels(testRepo, issue, "user:blocked")
+	h.poll()
+	h.wantLabel(issue, "user:blocked")
+	notes := h.notified()
+	if len(notes) != 1 || notes[0].Issue != issue || notes[0].From != "ai:planning" || notes[0].State != "user:blocked" {
+		t.Fatalf("notifications = %+v, want #%d reported as blocked", notes, issue)
+	}
+	if rec, _ := h.o.store.GetIssue("app", issue); rec.Label != "user:blocked" {
+		t.Errorf("recorded label = %q, want user:blocked", rec.Label)
+	}
+	changes := h.labelHooks()
+	if n := len(changes); n == 0 || changes[n-1] != (hooks.LabelChanged{From: "ai:planning", To: "user:blocked"}) {
+		t.Errorf("label.changed hooks = %+v, want the move to user:blocked last", changes)
+	}
+
+	// Later polls do not report it again
+	h.poll()
+	if got := h.notified(); len(got) != 1 {
+		t.Errorf("notifications = %+v, want the block reported once", got)
+	}
+}
+
+func TestScenarioQueuedIssueMovesOn(t *testing.T) {
+	h := newHarness(t, "slow")
+	h.o.sessionManager.SetCodebaseLimits(map[string]int{"app": 1})
+	first := h.forge.CreateIssue(testRepo, "Dark mode", "", "user:ready-to-plan")
+	second := h.forge.CreateIssue(testRepo, "Search", "", "user:ready-to-plan")
+
+	h.poll()
+	h.wantLabel(first, "ai:planning")
+	h.wantLabel(second, "user:ready-to-plan")
+
+	// The queued issue is blocked by hand before a slot frees up
+	h.forge.SetLabels(testRepo, second, "user:blocked")
+	h.o.sessionManager.StopSession(fmt.Sprintf("%s#%d", testRepo, first))
+	h.waitForSessions()
+	h.o.processTrigger(trigger{})
+	h.wantLabel(second, "user:blocked")
+	if sess := h.o.sessionManager.GetSessionForIssue(testRepo, second); sess != nil {
+		t.Errorf("got a session for #%d after it was blocked", second)
+	}
+}
+
+func TestScenarioForceRefresh(t *testing.T) {
+	h := newHarness(t, "ok")
+	h.forge.CreateIssue(testRepo, "Dark mode", "", "user:plan-review")
+
+	// The refresh is left to the main loop rather than polled on the caller's goroutine
+	h.o.ForceRefresh()
+	if got := h.forge.Calls("FetchRepoSnapshot"); got != 0 {
+		t.Fatalf("FetchRepoSnapshot calls = %d, want none before the main loop runs", got)
+	}
+	h.o.processTrigger(<-h.o.triggers)
+	if got := h.forge.Calls("FetchRepoSnapshot"); got != 1 {
+		t.Errorf("FetchRepoSnapshot calls = %d, want the trigger to poll", got)
+	}
+}
+
+func TestScenarioTimeoutReplaysComment(t *testing.T) {
+	h := newHarness(t, "slow")
+	issue := h.forge.CreateIssue(testRepo, "Dark mode", "", "user:plan-review")
+	h.pastSession(issue, "ai:planning")
+	h.forge.Comment(testRepo, issue, "dev-swarm", h.signed(issue, "Plan: add a theme toggle"))
+	h.forge.Advance(time.Minute)
+	h.forge.Comment(testRepo, issue, "alice", "Please use the system theme too")
+
+	h.poll()
+	h.wantLabel(issue, "ai:planning")
+	sessions := h.o.sessionManager.GetAllSessions()
+	if len(sessions) != 1 {
+		t.Fatalf("got %d sessions, want 1", len(sessions))
+	}
+	sessions[0].Timeout("exceeded max runtime of 2h0m0s")
+	h.waitForSessions()
+
+	// The timeout rolls the issue back and is noted without hiding the comment
+	h.poll()
+	h.wantLabel(issue, "user:plan-review")
+	if got := h.lastComment(issue); !strings.Contains(got, "timed out") {
+		t.Errorf("last comment = %q, want the timeout reported", got)
+	}
+
+	h.forge.Advance(h.o.retryBackoff(1))
+	h.poll()
+	h.wantLabel(issue, "ai:planning")
+}
+
+func TestScenarioPastedNoteMarker(t *testing.T) {
+	h := newHarness(t, "ok")
+	issue := h.forge.CreateIssue(testRepo, "Dark mode", "", "user:plan-review")
+	h.pastSession(issue, "ai:planning")
+	h.forge.Comment(testRepo, issue, "dev-swarm", h.signed(issue, "Plan: add a theme toggle"))
+
+	// A pasted note marker does not hide the feedback from pickup
+	h.forge.Advance(time.Minute)
+	h.forge.Comment(testRepo, issue, "alice", session.NoteMarker+"\nPlease use the system theme too")
+	h.poll()
+	h.wantLabel(issue, "ai:planning")
+}
+
+func TestScenarioReplayedAIMarker(t *testing.T) {
+	h := newHarness(t, "ok")
+	issue := h.forge.CreateIssue(testRepo, "Dark mode", "", "user:plan-review")
+	h.pastSession(issue, "ai:planning")
+	plan := h.signed(issue, "Plan: add a theme toggle")
+	h.forge.Comment(testRepo, issue, "dev-swarm", plan)
+	h.poll()
+	h.wantLabel(issue, "user:plan-review")
+
+	// A copy of the plan's marker does not pass the feedback off as a reply
+	h.forge.Advance(time.Hour)
+	h.forge.Comment(testRepo, issue, "alice", strings.Replace(plan, "Plan: add a theme toggle", "Please use the system theme too", 1))
+	h.poll()
+	h.wantLabel(issue, "ai:planning")
+}
+
+func TestScenarioCIFailure(t *testing.T) {
+	h := newHarness(t, "ok")
+	issue := h.forge.CreateIssue(testRepo, "Dark mode", "", "user:code-review")
+	branch := git.GetBranchName(issue)
+	pr := h.forge.OpenPR(testRepo, branch, "Dark mode")
+	h.forge.SetChecks(testRepo, pr, github.PRCheck{Name: "test", Status: "completed", Conclusion: "failure"})
+
+	h.poll()
+	h.wantLabel(issue, "ai:ci-failed")
+
+	// /rerun-ci re-runs the failed jobs and returns the issue to review
+	run := h.forge.AddWorkflowRun(testRepo, branch, "CI", "failure", "test\tFAIL")
+	h.forge.Advance(time.Minute)
+	h.forge.Comment(testRepo, issue, "alice", "/rerun-ci")
+	h.poll()
+	h.wantLabel(issue, "user:code-review")
+	if got := h.forge.Reruns(testRepo, run); got != 1 {
+		t.Errorf("reruns = %d, want 1", got)
+	}
+}
+
+func TestScenarioChangesRequested(t *testing.T) {
+	h := newHarness(t, "ok")
+	issue := h.forge.CreateIssue(testRepo, "Dark mode", "", "user:code-review")
+	pr := h.forge.OpenPR(testRepo, git.GetBranchName(issue), "Dark mode")
+
+	h.poll()
+	h.forge.Advance(time.Minute)
+	h.forge.Review(testRepo, pr, "mallory", github.ReviewChangesRequested, "Use a CSS variable")
+	h.poll()
+	h.wantLabel(issue, "user:code-review")
+
+	h.forge.Advance(time.Minute)
+	h.forge.Review(testRepo, pr, "alice", github.ReviewChangesRequested, "Use a CSS variable")
+	h.poll()
+	h.wantLabel(issue, "user:ready-to-implement")
+	if got := h.lastComment(issue); !strings.Contains(got, "> Use a CSS variable") {
+		t.Errorf("last comment = %q, want the review quoted", got)
+	}
+}
+
+func TestScenarioWatchdogRequeue(t *testing.T) {
+	h := newHarness(t, "ok")
+	// Left in a working state by a dev-swarm process that stopped
+	issue := h.forge.CreateIssue(testRepo, "Dark mode", "", "ai:planning")
+
+	h.poll()
+	h.forge.Advance(5 * time.Minute)
+	h.poll()
+	h.wantLabel(issue, "ai:planning")
+
+	h.forge.Advance(6 * time.Minute)
+	h.poll()
+	h.wantLabel(issue, "user:ready-to-plan")
+	if got := h.lastComment(issue); !strings.Contains(got, "without a running session") {
+		t.Errorf("last comment = %q, want the requeue explained", got)
+	}
+}
+
+func TestScenarioWatchdogAcrossRestarts(t *testing.T) {
+	h := newHarness(t, "ok")
+	issue := h.forge.CreateIssue(testRepo, "Dark mode", "", "ai:planning")
+
+	h.poll()
+	h.forge.Advance(6 * time.Minute)
+	h.restart()
+	h.poll()
+	h.wantLabel(issue, "ai:planning")
+
+	// The grace period runs from when the issue was first seen, not the restart
+	h.forge.Advance(5 * time.Minute)
+	h.poll()
+	h.wantLabel(issue, "user:ready-to-plan")
+}
+
+func TestScenarioGitHubUnavailable(t *testing.T) {
+	h := newHarness(t, "ok")
+	h.forge.CreateIssue(testRepo, "Dark mode", "", "user:plan-review")
+
+	h.forge.Fail("FetchRepoSnapshot", errors.New("HTTP 502"))
+	h.forge.Fail("ListIssuesWithLabels", errors.New("HTTP 502"))
+	h.poll()
+	info := h.o.GetCodebaseInfo()
+	if len(info) != 1 || info[0].IsHealthy || !strings.Contains(info[0].Error, "HTTP 502") {
+		t.Fatalf("GetCodebaseInfo() = %+v, want the codebase unhealthy", info)
+	}
+
+	h.forge.Fail("FetchRepoSnapshot", nil)
+	h.forge.Fail("ListIssuesWithLabels", nil)
+	h.poll()
+	info = h.o.GetCodebaseInfo()
+	if !info[0].IsHealthy || len(info[0].Issues) != 1 {
+		t.Errorf("GetCodebaseInfo() = %+v, want the codebase healthy with its issue", info)
+	}
+}
+
+func TestScenarioPollReadsSnapshot(t *testing.T) {
+	h := newHarness(t, "ok")
+	plan := h.forge.CreateIssue(testRepo, "Dark mode", "", "user:plan-review")
+	h.pastSession(plan, "ai:planning")
+	h.forge.Comment(testRepo, plan, "dev-swarm", h.signed(plan, "Plan: add a theme toggle"))
+	h.forge.Advance(time.Minute)
+	h.forge.Comment(testRepo, plan, "alice", "Please also cover the settings page")
+	review := h.forge.CreateIssue(testRepo, "Search", "", "user:code-review")
+	pr := h.forge.OpenPR(testRepo, git.GetBranchName(review), "Search")
+	h.pastSession(review, "ai:implementing")
+	h.forge.PRComment(testRepo, pr, "dev-swarm", h.signed(review, fmt.Sprintf("Opened #%d", pr)))
+	h.forge.SetChecks(testRepo, pr, github.PRCheck{Name: "test", Status: "completed", Conclusion: "success"})
+	h.forge.CreateIssue(testRepo, "Export", "", "user:blocked")
+
+	h.poll()
+	h.wantLabel(plan, "ai:planning")
+	h.wantLabel(review, "user:code-review")
+
+	if got := h.forge.Calls("FetchRepoSnapshot"); got != 1 {
+		t.Errorf("FetchRepoSnapshot calls = %d, want 1", got)
+	}
+	for _, method := range []string{"ListIssuesWithLabels", "GetIssue", "GetPRForBranch", "GetPRReviews", "GetPRComments", "PRChecksFailed"} {
+		if got := h.forge.Calls(method); got != 0 {
+			t.Errorf("%s calls = %d, want reads served from the snapshot", method, got)
+		}
+	}
+
+	// A failed snapshot falls back to a call per issue
+	h.waitForSessions()
+	h.forge.Fail("FetchRepoSnapshot", errors.New("HTTP 502"))
+	h.forge.Advance(time.Minute)
+	h.forge.SetLabels(testRepo, plan, "user:plan-review")
+	h.poll()
+	if got := h.forge.Calls("ListIssuesWithLabels"); got == 0 {
+		t.Errorf("ListIssuesWithLabels calls = 0, want issues listed without the snapshot")
+	}
+	if got := h.attempts(plan); got != 0 {
+		t.Errorf("attempts = %d, want the session verified without the snapshot", got)
+	}
+}
+
+func TestScenarioRateLimit(t *testing.T) {
+	h := newHarness(t, "ok")
+	h.forge.CreateIssue(testRepo, "Dark mode", "", "user:plan-review")
+	interval := time.Duration(h.o.config.Settings.PollInterval) * time.Second
+
+	// Plenty of quota: polls keep to the interval
+	now := h.forge.Now()
+	h.forge.SetRateLimit(github.RateLimit{Resource: "core", Limit: 5000, Remaining: 5000, Reset: now.Add(time.Hour), Observed: now})
+	h.poll()
+	stats := h.o.Stats()
+	if stats.PollSlowed || !stats.NextPoll.Equal(stats.LastPoll.Add(interval)) || stats.RateLimit.Remaining >= 5000 {
+		t.Errorf("Stats() = %+v, want the next poll one interval away and the quota spent", stats)
+	}
+
+	// Little quota left above the reserve: polls are spaced out until the reset
+	h.forge.SetRateLimit(github.RateLimit{Resource: "core", Limit: 5000, Remaining: 1010, Reset: now.Add(time.Hour), Observed: now})
+	h.poll()
+	stats = h.o.Stats()
+	if !stats.PollSlowed || !stats.NextPoll.After(stats.LastPoll.Add(interval)) {
+		t.Errorf("Stats() = %+v, want polls slowed", stats)
+	}
+
+	// A rate limit skips the codebase rather than failing it
+	h.forge.SetRateLimit(github.RateLimit{Resource: "core", Limit: 5000, Remaining: 0, Reset: now.Add(time.Hour), Observed: now, HeldUntil: now.Add(5 * time.Minute)})
+	calls := h.forge.Calls("FetchRepoSnapshot")
+	h.poll()
+	info := h.o.GetCodebaseInfo()
+	if !info[0].IsHealthy || !info[0].RateLimited || len(info[0].Issues) != 1 {
+		t.Errorf("GetCodebaseInfo() = %+v, want the codebase rate limited with its issue kept", info)
+	}
+	if got := h.forge.Calls("FetchRepoSnapshot"); got != calls {
+		t.Errorf("FetchRepoSnapshot calls = %d, want none while rate limited", got-calls)
+	}
+	if stats := h.o.Stats(); stats.NextPoll.Before(now.Add(5 * time.Minute)) {
+		t.Errorf("NextPoll = %v, want it after the rate limit passes", stats.NextPoll)
+	}
+
+	h.forge.Advance(5 * time.Minute)
+	h.forge.SetRateLimit(github.RateLimit{Resource: "core", Limit: 5000, Remaining: 5000, Reset: h.forge.Now().Add(time.Hour), Observed: h.forge.Now()})
+	h.poll()
+	if info := h.o.GetCodebaseInfo(); info[0].RateLimited {
+		t.Errorf("GetCodebaseInfo() = %+v, want the rate limit cleared", info)
+	}
+}
+
+func TestScenarioRateLimitPerResource(t *testing.T) {
+	h := newHarness(t, "ok")
+	h.forge.CreateIssue(testRepo, "Dark mode", "", "user:blocked")
+
+	// Core is closest to running out when the poll starts, the smaller graphql
+	// quota when it ends
+	now := h.forge.Now()
+	h.forge.SetRateLimit(github.RateLimit{Resource: "core", Limit: 5000, Remaining: 2499, Reset: now.Add(4 * time.Hour), Observed: now})
+	h.forge.SetRateLimit(github.RateLimit{Resource: "graphql", Limit: 100, Remaining: 50, Reset: now.Add(4 * time.Hour), Observed: now})
+	h.poll()
+
+	// The poll's graphql cost still spaces out polls to make that quota last
+	stats := h.o.Stats()
+	if stats.RateLimit.Resource != "graphql" {
+		t.Fatalf("RateLimit = %+v, want the graphql quota closest to running out", stats.RateLimit)
+	}
+	if !stats.PollSlowed {
+		t.Errorf("Stats() = %+v, want polls slowed for the graphql quota", stats)
+	}
+}
@@ -0,0 +1,260 @@
+package orchestrator
+
+import (
+	"sync"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+	"github.com/nathanbarrett/dev-swarm-go/internal/github"
+)
+
+// snapshotClient answers reads during a poll from one bulk fetch per
+// repository instead of a call per issue and PR. Reads the snapshot cannot
+// answer, and reads of anything changed since it was taken, go to GitHub.
+// Without snapshots every call goes to GitHub.
+type snapshotClient struct {
+	GitHubClient
+
+	mu    sync.Mutex
+	repos map[string]*repoSnapshot
+}
+
+// repoSnapshot is a repository's snapshot and what changed since it was taken
+type repoSnapshot struct {
+	*github.RepoSnapshot
+	staleIssues   map[int]bool
+	stalePRs      map[int]bool
+	staleBranches map[string]bool
+	allPRsStale   bool
+}
+
+// newSnapshotClient wraps a client
+func newSnapshotClient(client GitHubClient) *snapshotClient {
+	return &snapshotClient{GitHubClient: client, repos: make(map[string]*repoSnapshot)}
+}
+
+// load takes a snapshot of a repository
+func (c *snapshotClient) load(repo string, labels []string) error {
+	snapshot, err := c.GitHubClient.FetchRepoSnapshot(repo, labels)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.repos[repo] = &repoSnapshot{
+		RepoSnapshot:  snapshot,
+		staleIssues:   make(map[int]bool),
+		stalePRs:      make(map[int]bool),
+		staleBranches: make(map[string]bool),
+	}
+	return nil
+}
+
+// clear drops all snapshots
+func (c *snapshotClient) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.repos = make(map[string]*repoSnapshot)
+}
+
+// forget makes reads of an issue and its branch's PR go to GitHub, such as
+// after a session that may have changed them ended
+func (c *snapshotClient) forget(repo string, issueNum int, branch string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.repos[repo]; ok {
+		s.staleIssues[issueNum] = true
+		s.staleBranches[branch] = true
+	}
+}
+
+// snapshot returns a repository's snapshot, or nil. Callers hold the lock.
+func (c *snapshotClient) snapshot(repo string) *repoSnapshot {
+	return c.repos[repo]
+}
+
+// pr returns a PR of a snapshot that has not changed since, or nil.
+// Callers hold the lock.
+func (s *repoSnapshot) pr(number int) *github.PRSnapshot {
+	if s == nil || s.allPRsStale || s.stalePRs[number] {
+		return nil
+	}
+	pr := s.GetPR(number)
+	if pr == nil || s.staleBranches[pr.HeadRef] {
+		return nil
+	}
+	return pr
+}
+
+// ListIssuesWithLabels lists issues from the snapshot if it has every issue
+// with the labels and none of them changed since
+func (c *snapshotClient) ListIssuesWithLabels(repo string, labels []string) ([]github.Issue, error) {
+	c.mu.Lock()
+	s := c.snapshot(repo)
+	if s == nil || !s.IssuesComplete || len(s.staleIssues) > 0 || !containsAll(s.Labels, labels) {
+		c.mu.Unlock()
+		return c.GitHubClient.ListIssuesWithLabels(repo, labels)
+	}
+	defer c.mu.Unlock()
+
+	var issues []github.Issue
+	for _, snap := range s.Issues {
+		for _, label := range labels {
+			if snap.HasLabel(label) {
+				// Without comments, as the issue list reports them
+				issue := snap.Issue
+				issue.Labels = append([]github.Label(nil), snap.Labels...)
+				issue.Comments = nil
+				issues = append(issues, issue)
+				break
+			}
+		}
+	}
+	return issues, nil
+}
+
+// GetIssue returns an issue with its comments from the snapshot if it has them all
+func (c *snapshotClient) GetIssue(repo string, number int) (*github.Issue, error) {
+	c.mu.Lock()
+	if s := c.snapshot(repo); s != nil && !s.staleIssues[number] {
+		if snap := s.GetIssue(number); snap != nil && snap.CommentsComplete {
+			issue := snap.Issue
+			issue.Labels = append([]github.Label(nil), snap.Labels...)
+			issue.Comments = append([]github.Comment{}, snap.Comments...)
+			c.mu.Unlock()
+			return &issue, nil
+		}
+	}
+	c.mu.Unlock()
+	return c.GitHubClient.GetIssue(repo, number)
+}
+
+// GetPRForBranch returns the open PR for a branch from the snapshot
+func (c *snapshotClient) GetPRForBranch(repo, branch string) (*github.PullRequest, error) {
+	c.mu.Lock()
+	if s := c.snapshot(repo); s != nil && !s.allPRsStale && !s.staleBranches[branch] {
+		if snap := s.PRForBranch(branch); snap != nil && !s.stalePRs[snap.Number] {
+			pr := snap.PullRequest
+			c.mu.Unlock()
+			return &pr, nil
+		} else if snap == nil && s.PRsComplete {
+			c.mu.Unlock()
+			return nil, nil
+		}
+	}
+	c.mu.Unlock()
+	return c.GitHubClient.GetPRForBranch(repo, branch)
+}
+
+// GetPR returns an open PR from the snapshot
+func (c *snapshotClient) GetPR(repo string, number int) (*github.PullRequest, error) {
+	c.mu.Lock()
+	if snap := c.snapshot(repo).pr(number); snap != nil {
+		pr := snap.PullRequest
+		c.mu.Unlock()
+		return &pr, nil
+	}
+	c.mu.Unlock()
+	return c.GitHubClient.GetPR(repo, number)
+}
+
+// GetPRReviews returns a PR's reviews from the snapshot if it has them all
+func (c *snapshotClient) GetPRReviews(repo string, number int) ([]github.PRReview, error) {
+	c.mu.Lock()
+	if snap := c.snapshot(repo).pr(number); snap != nil && snap.ReviewsComplete {
+		reviews := append([]github.PRReview{}, snap.Reviews...)
+		c.mu.Unlock()
+		return reviews, nil
+	}
+	c.mu.Unlock()
+	return c.GitHubClient.GetPRReviews(repo, number)
+}
+
+// GetPRComments returns a PR's comments from the snapshot if it has them all
+func (c *snapshotClient) GetPRComments(repo string, number int) ([]github.PRComment, error) {
+	c.mu.Lock()
+	if snap := c.snapshot(repo).pr(number); snap != nil && snap.CommentsComplete {
+		comments := append([]github.PRComment{}, snap.Comments...)
+		c.mu.Unlock()
+		return comments, nil
+	}
+	c.mu.Unlock()
+	return c.GitHubClient.GetPRComments(repo, number)
+}
+
+// PRChecksFailed checks a PR's checks from the snapshot
+func (c *snapshotClient) PRChecksFailed(repo string, number int) (bool, error) {
+	c.mu.Lock()
+	if snap := c.snapshot(repo).pr(number); snap != nil {
+		failed := snap.ChecksFailed()
+		c.mu.Unlock()
+		return failed, nil
+	}
+	c.mu.Unlock()
+	return c.GitHubClient.PRChecksFailed(repo, number)
+}
+
+// UpdateIssueLabels changes labels and stops reads of the issue from the snapshot
+func (c *snapshotClient) UpdateIssueLabels(repo string, number int, removeLabels, addLabels []string) error {
+	c.markIssue(repo, number)
+	return c.GitHubClient.UpdateIssueLabels(repo, number, removeLabels, addLabels)
+}
+
+// AddIssueComment comments and stops reads of the issue from the snapshot
+func (c *snapshotClient) AddIssueComment(repo string, number int, body string) error {
+	c.markIssue(repo, number)
+	return c.GitHubClient.AddIssueComment(repo, number, body)
+}
+
+// MergePR merges and stops reads of the PR from the snapshot
+func (c *snapshotClient) MergePR(repo string, number int, strategy string, deleteRemoteBranch bool) error {
+	c.mu.Lock()
+	if s := c.snapshot(repo); s != nil {
+		s.stalePRs[number] = true
+		if pr := s.GetPR(number); pr != nil {
+			s.staleBranches[pr.HeadRef] = true
+		}
+	}
+	c.mu.Unlock()
+	return c.GitHubClient.MergePR(repo, number, strategy, deleteRemoteBranch)
+}
+
+// RerunFailedJobs re-runs a workflow run and stops reads of PRs from the
+// snapshot, since the run's checks belong to one of them
+func (c *snapshotClient) RerunFailedJobs(repo string, runID int) error {
+	c.mu.Lock()
+	if s := c.snapshot(repo); s != nil {
+		s.allPRsStale = true
+	}
+	c.mu.Unlock()
+	return c.GitHubClient.RerunFailedJobs(repo, runID)
+}
+
+// markIssue stops reads of an issue from the snapshot
+func (c *snapshotClient) markIssue(repo string, number int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s := c.snapshot(repo); s != nil {
+		s.staleIssues[number] = true
+	}
+}
+
+// containsAll checks if every item of want is in list
+func containsAll(list, want []string) bool {
+	for _, w := range want {
+		if !containsLabel(list, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// loadSnapshot takes the snapshot of a codebase's repository that the rest
+// of its poll reads from. Without one, the poll falls back to a call per
+// issue and PR.
+func (o *Orchestrator) loadSnapshot(codebase *config.Codebase) {
+	labels := append(o.getPickupLabels(codebase), o.getCommandLabels(codebase)...)
+	if err := o.snapshots.load(codebase.Repo, labels); err != nil && !github.IsRateLimited(err) {
+		o.log("Error fetching %s in one query, falling back to a query per issue: %v", codebase.Repo, err)
+	}
+}
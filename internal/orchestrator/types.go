@@ -29,6 +29,8 @@ const (
 	UpdateLabelChanged
 	UpdatePollComplete
 	UpdateError
+	UpdateDryRun
+	UpdateNotification
 )
 
 func (t UpdateType) String() string {
@@ -49,6 +51,10 @@ func (t UpdateType) String() string {
 		return "poll_complete"
 	case UpdateError:
 		return "error"
+	case UpdateDryRun:
+		return "dry_run"
+	case UpdateNotification:
+		return "notification"
 	default:
 		return "unknown"
 	}
@@ -56,11 +62,12 @@ func (t UpdateType) String() string {
 
 // CodebaseState tracks the state of a single codebase
 type CodebaseState struct {
-	Config    *config.Codebase
-	Issues    map[int]*IssueState
-	LastPoll  time.Time
-	IsHealthy bool
-	Error     error
+	Config      *config.Codebase
+	Issues      map[int]*IssueState
+	LastPoll    time.Time
+	IsHealthy   bool
+	RateLimited bool // The last poll was skipped for a GitHub rate limit
+	Error       error
 }
 
 // IssueState tracks the state of a single issue
@@ -70,39 +77,67 @@ type IssueState struct {
 	HasSession  bool
 	SessionID   string
 	LastChecked time.Time
+	WaitingOn   []string // Open dependencies holding pickup
 }
 
 // Stats contains orchestrator statistics
 type Stats struct {
 	ActiveSessions  int
-	QueuedSessions  int
-	WaitingSessions int
+	QueuedSessions  int // Eligible issues waiting for a session slot
+	WaitingSessions int // Issues waiting on a user action
 	TotalIssues     int
 	LastPoll        time.Time
 	NextPoll        time.Time
+	PollSlowed      bool             // NextPoll is later than the poll interval to save GitHub quota
+	RateLimit       github.RateLimit // The GitHub quota closest to running out
 	IsPaused        bool
 	Uptime          time.Duration
+	WebhookAddr     string // Address webhooks are received on; empty when polling only
+	DryRun          bool
+	SpentToday      float64 // US dollars spent by sessions that ended today
+	SpentThisMonth  float64
+	DailyBudget     float64 // Zero means no limit
+	MonthlyBudget   float64
+	BudgetExhausted bool // New sessions are held until there is budget left
+}
+
+// DryRunAction describes a side effect that dry-run mode skipped
+type DryRunAction struct {
+	Codebase string
+	IssueNum int
+	Action   string
+	Time     time.Time
 }
 
 // IssueInfo contains display information about an issue
 type IssueInfo struct {
-	Number       int
-	Title        string
-	Label        string
-	Status       session.Status
-	HasSession   bool
-	SessionID    string
-	Duration     time.Duration
-	CodebaseName string
-	Repo         string
+	Number        int
+	Title         string
+	Label         string
+	State         *config.LabelConfig // Workflow state of Label, nil if unknown
+	Status        session.Status
+	HasSession    bool
+	SessionID     string
+	Duration      time.Duration
+	CodebaseName  string
+	Repo          string
+	Attempts      int       // Failed sessions counted against the retry budget
+	RetryAt       time.Time // When a failed issue may be picked up again
+	QueuePosition int       // Position in the pickup queue, 0 if not queued
+	WaitingOn     []string  // Open dependencies holding pickup
 }
 
 // CodebaseInfo contains display information about a codebase
 type CodebaseInfo struct {
-	Name     string
-	Repo     string
-	Issues   []IssueInfo
-	IsIdle   bool
-	IsHealthy bool
-	Error    string
+	Name        string
+	Repo        string
+	Issues      []IssueInfo
+	IsIdle      bool
+	IsHealthy   bool
+	RateLimited bool // Polls are skipped until GitHub's rate limit passes
+	Error       string
+
+	Scheduled    bool      // Sessions only start inside the codebase's schedule
+	ScheduleOpen bool      // New sessions may start now
+	NextWindow   time.Time // When a closed schedule opens, zero if not within a week
 }
@@ -0,0 +1,118 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+	"github.com/nathanbarrett/dev-swarm-go/internal/session"
+)
+
+// fetchCurrentLabel reads an issue's workflow label from GitHub, since the
+// agent changes it while the session runs
+func (o *Orchestrator) fetchCurrentLabel(codebase *config.Codebase, issueNum int) (string, bool) {
+	issue, err := o.ghClient.GetIssue(codebase.Repo, issueNum)
+	if err != nil {
+		o.log("Error fetching issue %s#%d: %v", codebase.Repo, issueNum, err)
+		return "", false
+	}
+	return o.getCurrentLabel(codebase, issue), true
+}
+
+// verifyOutcome checks the issue against what the workflow expects after a
+// session picked it up from sess.Label. It returns a description of the first
+// problem found, or "" if the outcome is valid. Checks that cannot be made
+// because GitHub is unavailable are skipped rather than failed.
+func (o *Orchestrator) verifyOutcome(sess *session.Session, currentLabel string) string {
+	codebase := sess.Codebase
+	workflow := codebase.GetWorkflow()
+	pickup := workflow.GetByName(sess.Label)
+	if pickup == nil {
+		return ""
+	}
+
+	if currentLabel == "" {
+		return "the issue has no workflow label"
+	}
+	allowed := workflow.AllowedOutcomes(pickup.Name)
+	if !containsLabel(allowed, currentLabel) {
+		if currentLabel == pickup.WorkingLabel || currentLabel == pickup.Name {
+			return fmt.Sprintf("the session ended with the issue still in `%s`", currentLabel)
+		}
+		return fmt.Sprintf("the session moved the issue to `%s`, expected one of `%s`",
+			currentLabel, strings.Join(allowed, "`, `"))
+	}
+
+	// Asking for help is always a valid outcome
+	if workflow.HasRole(currentLabel, config.RoleBlocked) {
+		return ""
+	}
+
+	branch := o.getBranchName(sess.Issue.Number)
+	if pickup.ExpectCommits {
+		if problem := o.verifyCommits(codebase, branch, o.sessionStartHead(codebase.Name, sess)); problem != "" {
+			return problem
+		}
+	}
+
+	if target := workflow.GetByName(currentLabel); target != nil && target.RequiresPR {
+		pr, err := o.ghClient.GetPRForBranch(codebase.Repo, branch)
+		if err != nil {
+			o.log("Error checking PR for %s#%d: %v", codebase.Repo, sess.Issue.Number, err)
+		} else if pr == nil {
+			return fmt.Sprintf("`%s` requires an open pull request, but none exists for branch `%s`", currentLabel, branch)
+		}
+	}
+
+	return ""
+}
+
+// verifyCommits checks that a branch moved since startHead and has commits
+// that are not on the default branch
+func (o *Orchestrator) verifyCommits(codebase *config.Codebase, branch, startHead string) string {
+	head, err := o.ghClient.GetBranchHead(codebase.Repo, branch)
+	if err != nil {
+		o.log("Error reading branch head for %s: %v", branch, err)
+		return ""
+	}
+	if head == "" {
+		return fmt.Sprintf("branch `%s` was not pushed", branch)
+	}
+	if head == startHead {
+		return fmt.Sprintf("no new commits were pushed to `%s`", branch)
+	}
+
+	ahead, err := o.ghClient.CommitsAhead(codebase.Repo, codebase.DefaultBranch, branch)
+	if err != nil {
+		o.log("Error comparing %s with %s: %v", branch, codebase.DefaultBranch, err)
+		return ""
+	}
+	if ahead == 0 {
+		return fmt.Sprintf("branch `%s` has no commits ahead of `%s`", branch, codebase.DefaultBranch)
+	}
+	return ""
+}
+
+// sessionStartHead returns the branch head recorded when the session started
+func (o *Orchestrator) sessionStartHead(codebaseName string, sess *session.Session) string {
+	rec, ok := o.store.GetIssue(codebaseName, sess.Issue.Number)
+	if !ok {
+		return ""
+	}
+	for i := len(rec.Sessions) - 1; i >= 0; i-- {
+		if rec.Sessions[i].ID == sess.ID {
+			return rec.Sessions[i].StartHead
+		}
+	}
+	return ""
+}
+
+// containsLabel checks if a label is in a list of label names
+func containsLabel(labels []string, label string) bool {
+	for _, l := range labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package orchestrator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+	"github.com/nathanbarrett/dev-swarm-go/internal/github"
+	"github.com/nathanbarrett/dev-swarm-go/internal/state"
+)
+
+// recoverStaleWork requeues or blocks issues that have sat in a working state
+// without a running session for longer than the watchdog grace period, such
+// as issues whose session died with a previous dev-swarm process
+func (o *Orchestrator) recoverStaleWork(codebase *config.Codebase, issues []github.Issue) {
+	workflow := codebase.GetWorkflow()
+	grace := time.Duration(o.config.Settings.Watchdog.GracePeriod) * time.Minute
+	now := o.now()
+
+	for i := range issues {
+		issue := &issues[i]
+		label := o.getCurrentLabel(codebase, issue)
+		if !workflow.IsWorkingLabel(label) || o.hasActiveSession(codebase, issue) {
+			continue
+		}
+
+		since := o.idleSince(codebase, issue.Number, label, now)
+		if now.Sub(since) < grace {
+			continue
+		}
+		o.recoverIssue(codebase, issue.Number, label, now.Sub(since))
+	}
+}
+
+// idleSince returns when an issue in a working state last had a session: the
+// end of its last session or the move to the label, whichever is later. Both
+// come from the state store, so restarting dev-swarm does not restart the
+// grace period. An issue not known to be in the label is recorded as entering
+// it now.
+func (o *Orchestrator) idleSince(codebase *config.Codebase, issueNum int, label string, now time.Time) time.Time {
+	var since time.Time
+	o.store.UpdateIssue(codebase.Name, issueNum, func(rec *state.IssueRecord) {
+		rec.LastChecked = now // Keep the record from being pruned
+		if rec.Label != label || rec.LabelChangedAt.IsZero() {
+			rec.Label = label
+			rec.LabelChangedAt = now
+		}
+		since = rec.LabelChangedAt
+		if last := rec.LastSession(); last != nil {
+			// Interrupted sessions are given an end when the state is loaded,
+			// which says nothing about when they stopped
+			ended := last.StartedAt
+			if last.EndedAt != nil && last.Outcome != state.OutcomeInterrupted {
+				ended = *last.EndedAt
+			}
+			if ended.After(since) {
+				since = ended
+			}
+		}
+	})
+	return since
+}
+
+// recoverIssue moves an issue out of the working state it was left in, back
+// to the state it was picked up from or to the blocked state, as the watchdog
+// action says
+func (o *Orchestrator) recoverIssue(codebase *config.Codebase, issueNum int, label string, idle time.Duration) {
+	o.log("%s#%d has been in %s without a session for %s", codebase.Repo, issueNum, label, idle.Round(time.Minute))
+
+	if o.config.Settings.Watchdog.RecoveryAction() == config.WatchdogRequeue {
+		if target := o.resumeLabel(codebase, issueNum, label); target != "" {
+			if err := o.transitionLabel(codebase, issueNum, label, target); err != nil {
+				o.log("Error moving %s#%d to %s: %v", codebase.Repo, issueNum, target, err)
+				return
+			}
+			o.replayComments(codebase, issueNum, target)
+
+			note := fmt.Sprintf("This issue was left in `%s` without a running session, most likely because dev-swarm stopped during the session. dev-swarm moved it back to `%s` to pick it up again.",
+				label, target)
+			if err := o.postNote(codebase, issueNum, note); err != nil {
+				o.log("Error commenting on %s#%d: %v", codebase.Repo, issueNum, err)
+			}
+			return
+		}
+		o.log("No state to requeue %s#%d from, blocking it instead", codebase.Repo, issueNum)
+	}
+
+	blocked := o.roleLabel(codebase, config.RoleBlocked)
+	if blocked == "" {
+		o.log("Cannot recover %s#%d: the workflow has no blocked state", codebase.Repo, issueNum)
+		return
+	}
+	if err := o.transitionLabel(codebase, issueNum, label, blocked); err != nil {
+		o.log("Error moving %s#%d to %s: %v", codebase.Repo, issueNum, blocked, err)
+		return
+	}
+
+	note := fmt.Sprintf("This issue was left in `%s` without a running session for %s, most likely because dev-swarm stopped during the session. It was moved to `%s`; comment `/retry` to pick it up again.",
+		label, idle.Round(time.Minute), blocked)
+	if err := o.postNote(codebase, issueNum, note); err != nil {
+		o.log("Error commenting on %s#%d: %v", codebase.Repo, issueNum, err)
+	}
+}
+
+// resumeLabel returns the state to requeue an issue left in a working state
+// from: the state its last session was picked up from, or else the first
+// pickup state using that working state. Returns "" if there is none.
+func (o *Orchestrator) resumeLabel(codebase *config.Codebase, issueNum int, working string) string {
+	workflow := codebase.GetWorkflow()
+	if rec, ok := o.store.GetIssue(codebase.Name, issueNum); ok {
+		if last := rec.LastSession(); last != nil {
+			if state := workflow.GetByName(last.Label); state != nil && state.WorkingLabel == working {
+				return state.Name
+			}
+		}
+	}
+
+	for _, state := range workflow.GetPickupLabels() {
+		if state.WorkingLabel == working {
+			return state.Name
+		}
+	}
+	return ""
+}
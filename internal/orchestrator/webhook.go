@@ -0,0 +1,203 @@
+package orchestrator
+
+import (
+	"strings"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+	"github.com/nathanbarrett/dev-swarm-go/internal/git"
+	"github.com/nathanbarrett/dev-swarm-go/internal/webhook"
+)
+
+// trigger asks the main loop for targeted processing of a single issue. A
+// trigger without an issue or PR number only checks finished sessions and
+// hands free slots to the pickup queue.
+type trigger struct {
+	codebase string
+	issueNum int
+	prNum    int  // Resolved to an issue through the PR head branch
+	checkCI  bool // Also check CI status of tracked issues
+	poll     bool // Run a full poll instead, even while paused
+}
+
+// handleWebhookEvent maps a verified webhook delivery to a trigger. It runs on
+// the webhook server's goroutine, so it must not block.
+func (o *Orchestrator) handleWebhookEvent(event *webhook.Event) {
+	codebase := o.config.GetCodebaseByRepo(event.Repo)
+	if codebase == nil || !codebase.Enabled {
+		return
+	}
+
+	t := trigger{
+		codebase: codebase.Name,
+		checkCI:  event.Name == webhook.EventCheckSuite,
+	}
+	switch {
+	case !event.IsPR:
+		t.issueNum = event.Number
+	case event.HeadBranch != "":
+		n, ok := git.ParseBranchName(event.HeadBranch)
+		if !ok {
+			return // Not a dev-swarm branch
+		}
+		t.issueNum = n
+	default:
+		// PR comments carry no branch; resolve it on the main loop
+		t.prNum = event.Number
+	}
+	if t.issueNum == 0 && t.prNum == 0 {
+		return
+	}
+
+	o.enqueueTrigger(t)
+}
+
+// enqueueTrigger hands a trigger to the main loop without blocking. Dropped
+// triggers are picked up by the next reconciliation poll.
+func (o *Orchestrator) enqueueTrigger(t trigger) {
+	select {
+	case o.triggers <- t:
+	default:
+		o.log("Trigger queue full, leaving %s#%d for the next poll", t.codebase, t.issueNum)
+	}
+}
+
+// processTrigger refreshes the issue named by a trigger and spawns sessions
+// for the pickup queue
+func (o *Orchestrator) processTrigger(t trigger) {
+	if t.poll {
+		o.poll()
+		return
+	}
+
+	o.checkSessionStatus()
+
+	o.mu.RLock()
+	cbState := o.codebases[t.codebase]
+	o.mu.RUnlock()
+
+	var key string
+	var entry *queuedIssue
+	if cbState != nil && (t.issueNum != 0 || t.prNum != 0) {
+		codebase := cbState.Config
+		issueNum := t.issueNum
+		if issueNum == 0 {
+			issueNum = o.issueForPR(codebase, t.prNum)
+		}
+		if issueNum != 0 {
+			key = queueKey(codebase.Name, issueNum)
+			entry = o.refreshIssue(codebase, cbState, issueNum)
+		}
+	}
+
+	if t.checkCI {
+		o.checkCIStatus()
+	}
+
+	o.drainQueue(o.pendingQueue(key, entry))
+	o.saveState()
+}
+
+// issueForPR returns the issue a dev-swarm PR belongs to, or 0
+func (o *Orchestrator) issueForPR(codebase *config.Codebase, prNum int) int {
+	pr, err := o.ghClient.GetPR(codebase.Repo, prNum)
+	if err != nil {
+		o.log("Error fetching PR %s#%d: %v", codebase.Repo, prNum, err)
+		return 0
+	}
+	issueNum, _ := git.ParseBranchName(pr.HeadRef)
+	return issueNum
+}
+
+// refreshIssue re-reads a single issue and returns a queue entry if it is
+// eligible for pickup. Issues that left the polled labels stop being tracked,
+// though blocked and working issues still take slash commands.
+func (o *Orchestrator) refreshIssue(codebase *config.Codebase, cbState *CodebaseState, issueNum int) *queuedIssue {
+	issue, err := o.ghClient.GetIssue(codebase.Repo, issueNum)
+	if err != nil {
+		o.log("Error fetching issue %s#%d: %v", codebase.Repo, issueNum, err)
+		return nil
+	}
+
+	labelCfg := o.getLabelConfig(codebase, o.getCurrentLabel(codebase, issue))
+	polled := labelCfg != nil &&
+		(labelCfg.AIPickup == string(config.PickupAlways) || labelCfg.AIPickup == string(config.PickupOnUserComment))
+	if !strings.EqualFold(issue.State, "open") || !polled {
+		if strings.EqualFold(issue.State, "open") && labelCfg != nil && !labelCfg.Terminal {
+			o.processCommands(codebase, issue)
+		}
+		o.untrackIssue(codebase, cbState, issueNum)
+		return nil
+	}
+
+	return o.processIssue(codebase, cbState, *issue)
+}
+
+// untrackIssue stops tracking an issue unless a session is working on it
+func (o *Orchestrator) untrackIssue(codebase *config.Codebase, cbState *CodebaseState, issueNum int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	issueState, ok := cbState.Issues[issueNum]
+	if !ok || issueState.HasSession {
+		return
+	}
+	delete(cbState.Issues, issueNum)
+	o.sendUpdate(StateUpdate{
+		Type:      UpdateIssueRemoved,
+		Codebase:  codebase.Name,
+		IssueNum:  issueNum,
+		Timestamp: o.now(),
+	})
+}
+
+// pendingQueue returns the issues left queued by the last drain, with the
+// entry for key replaced by entry (or dropped when entry is nil). The
+// leftover entries are marked to be checked again before they are spawned.
+func (o *Orchestrator) pendingQueue(key string, entry *queuedIssue) []*queuedIssue {
+	o.mu.RLock()
+	queue := o.queue
+	o.mu.RUnlock()
+
+	pending := make([]*queuedIssue, 0, len(queue)+1)
+	for _, queued := range queue {
+		if queued.key() == key || o.hasActiveSession(queued.codebase, queued.issue) {
+			continue
+		}
+		leftover := *queued
+		leftover.leftover = true
+		pending = append(pending, &leftover)
+	}
+	if entry != nil {
+		pending = append(pending, entry)
+	}
+	return pending
+}
+
+// recheckQueued re-reads an issue left queued by an earlier drain, whose
+// label, backoff or dependencies may have changed since, and returns a fresh
+// entry if it is still eligible for pickup
+func (o *Orchestrator) recheckQueued(entry *queuedIssue) *queuedIssue {
+	o.mu.RLock()
+	cbState := o.codebases[entry.codebase.Name]
+	o.mu.RUnlock()
+	if cbState == nil {
+		return nil
+	}
+	return o.refreshIssue(entry.codebase, cbState, entry.issue.Number)
+}
+
+// startWebhookServer starts the webhook receiver if it is enabled
+func (o *Orchestrator) startWebhookServer() error {
+	cfg := o.config.Settings.Webhook
+	if !cfg.Enabled {
+		return nil
+	}
+
+	o.webhook = webhook.NewServer(cfg, o.handleWebhookEvent, o.logger)
+	if err := o.webhook.Start(); err != nil {
+		o.webhook = nil
+		return err
+	}
+	o.log("Receiving webhooks on %s%s (reconciling every %ds)", o.webhook.Addr(), cfg.Path, cfg.ReconcileInterval)
+	return nil
+}
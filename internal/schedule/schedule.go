@@ -0,0 +1,223 @@
+// Package schedule decides when new sessions may start, from weekly windows
+// such as "mon-fri 09:00-17:00" in a time zone.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minutesPerDay is the number of minutes in a day; "24:00" ends a window at midnight
+const minutesPerDay = 24 * 60
+
+// searchDays is how far ahead NextOpen looks for an opening
+const searchDays = 8
+
+// dayNames maps day abbreviations to weekdays
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Window is a weekly time range, such as "mon-fri 18:00-08:00". A window
+// that ends before it starts runs past midnight into the next day.
+type Window struct {
+	days  [7]bool
+	start int // Minutes after midnight
+	end   int // Minutes after midnight, up to 24:00
+}
+
+// ParseWindow parses a window of the form "[days] [HH:MM-HH:MM]". Days are
+// a comma-separated list of day names or ranges such as "mon-fri" or "*" for
+// every day; without hours the window covers the whole day.
+func ParseWindow(s string) (Window, error) {
+	fields := strings.Fields(strings.ToLower(s))
+	if len(fields) == 0 || len(fields) > 2 {
+		return Window{}, fmt.Errorf("window %q must be \"[days] [HH:MM-HH:MM]\"", s)
+	}
+
+	var w Window
+	daySpec, hourSpec := fields[0], ""
+	if len(fields) == 2 {
+		hourSpec = fields[1]
+	} else if strings.Contains(daySpec, ":") {
+		daySpec, hourSpec = "*", daySpec
+	}
+
+	days, err := parseDays(daySpec)
+	if err != nil {
+		return Window{}, fmt.Errorf("window %q: %w", s, err)
+	}
+	w.days = days
+
+	w.start, w.end = 0, minutesPerDay
+	if hourSpec != "" {
+		from, to, ok := strings.Cut(hourSpec, "-")
+		if !ok {
+			return Window{}, fmt.Errorf("window %q: hours must be HH:MM-HH:MM", s)
+		}
+		if w.start, err = parseClock(from); err != nil {
+			return Window{}, fmt.Errorf("window %q: %w", s, err)
+		}
+		if w.end, err = parseClock(to); err != nil {
+			return Window{}, fmt.Errorf("window %q: %w", s, err)
+		}
+		if w.start == w.end {
+			return Window{}, fmt.Errorf("window %q: start and end must differ", s)
+		}
+		if w.start == minutesPerDay {
+			return Window{}, fmt.Errorf("window %q: cannot start at 24:00", s)
+		}
+	}
+	return w, nil
+}
+
+// parseDays parses a list of days such as "mon,wed-fri" or "*"
+func parseDays(spec string) ([7]bool, error) {
+	var days [7]bool
+	if spec == "*" {
+		for i := range days {
+			days[i] = true
+		}
+		return days, nil
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, ok := dayNames[from]
+		if !ok {
+			return days, fmt.Errorf("unknown day %q", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = dayNames[to]; !ok {
+				return days, fmt.Errorf("unknown day %q", to)
+			}
+		}
+		// Ranges may wrap around the week, as in "fri-mon"
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseClock parses a time of day as minutes after midnight
+func parseClock(s string) (int, error) {
+	h, m, ok := strings.Cut(s, ":")
+	hour, hourErr := strconv.Atoi(h)
+	minute, minuteErr := strconv.Atoi(m)
+	if !ok || hourErr != nil || minuteErr != nil || hour < 0 || minute < 0 || minute > 59 ||
+		hour*60+minute > minutesPerDay {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return hour*60 + minute, nil
+}
+
+// contains checks if a local time falls inside the window
+func (w Window) contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if w.start < w.end {
+		return w.days[day] && minute >= w.start && minute < w.end
+	}
+	// Past midnight: the evening of a listed day or the morning after it
+	yesterday := (day + 6) % 7
+	return (w.days[day] && minute >= w.start) || (w.days[yesterday] && minute < w.end)
+}
+
+// Schedule says when new sessions may start: inside one of its windows, or
+// at any time if it has none, and never inside a pause window
+type Schedule struct {
+	location *time.Location
+	windows  []Window
+	pauses   []Window
+}
+
+// New builds a schedule from window specifications in a time zone. An empty
+// time zone means the local time zone.
+func New(timezone string, windows, pauses []string) (*Schedule, error) {
+	s := &Schedule{location: time.Local}
+	if timezone != "" {
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("unknown time zone %q", timezone)
+		}
+		s.location = location
+	}
+
+	for _, spec := range windows {
+		w, err := ParseWindow(spec)
+		if err != nil {
+			return nil, err
+		}
+		s.windows = append(s.windows, w)
+	}
+	for _, spec := range pauses {
+		w, err := ParseWindow(spec)
+		if err != nil {
+			return nil, err
+		}
+		s.pauses = append(s.pauses, w)
+	}
+	return s, nil
+}
+
+// Open checks if new sessions may start at t. A nil schedule is always open.
+func (s *Schedule) Open(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+	local := t.In(s.location)
+
+	for _, w := range s.pauses {
+		if w.contains(local) {
+			return false
+		}
+	}
+	if len(s.windows) == 0 {
+		return true
+	}
+	for _, w := range s.windows {
+		if w.contains(local) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns the first time at or after t when the schedule is open,
+// or the zero time if it does not open within the next week
+func (s *Schedule) NextOpen(t time.Time) time.Time {
+	if s.Open(t) {
+		return t
+	}
+	local := t.In(s.location)
+
+	// The schedule only changes state where a window starts or ends
+	var next time.Time
+	for day := 0; day < searchDays; day++ {
+		for _, w := range append(append([]Window{}, s.windows...), s.pauses...) {
+			for _, minute := range []int{w.start, w.end} {
+				candidate := time.Date(local.Year(), local.Month(), local.Day()+day, 0, minute, 0, 0, s.location)
+				if !candidate.After(t) || (!next.IsZero() && !candidate.Before(next)) {
+					continue
+				}
+				if s.Open(candidate) {
+					next = candidate
+				}
+			}
+		}
+	}
+	return next
+}
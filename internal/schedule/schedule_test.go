@@ -0,0 +1,134 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// at returns a time in UTC during the week starting Sunday 2024-06-02
+func at(day time.Weekday, hour, minute int) time.Time {
+	return time.Date(2024, 6, 2+int(day), hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{"mon-fri 09:00-17:00", false},
+		{"sat,sun", false},
+		{"fri-mon", false},
+		{"* 22:00-06:00", false},
+		{"18:00-24:00", false},
+		{"Mon 9:30-12:00", false},
+		{"", true},
+		{"mon-fri 09:00", true},
+		{"funday", true},
+		{"mon 25:00-26:00", true},
+		{"mon 09:00-09:00", true},
+		{"mon 24:00-06:00", true},
+		{"mon 09:00-17:00 extra", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := ParseWindow(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseWindow(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduleOpen(t *testing.T) {
+	tests := []struct {
+		name    string
+		windows []string
+		pauses  []string
+		at      time.Time
+		want    bool
+	}{
+		{"no windows", nil, nil, at(time.Wednesday, 12, 0), true},
+		{"inside window", []string{"mon-fri 09:00-17:00"}, nil, at(time.Monday, 9, 0), true},
+		{"window end is exclusive", []string{"mon-fri 09:00-17:00"}, nil, at(time.Monday, 17, 0), false},
+		{"outside window days", []string{"mon-fri 09:00-17:00"}, nil, at(time.Saturday, 12, 0), false},
+		{"overnight evening", []string{"mon-fri 18:00-08:00"}, nil, at(time.Friday, 23, 0), true},
+		{"overnight morning after", []string{"mon-fri 18:00-08:00"}, nil, at(time.Saturday, 7, 59), true},
+		{"overnight morning without evening", []string{"mon-fri 18:00-08:00"}, nil, at(time.Monday, 7, 0), false},
+		{"whole day", []string{"sat,sun"}, nil, at(time.Sunday, 3, 0), true},
+		{"wrapping day range", []string{"fri-mon"}, nil, at(time.Sunday, 3, 0), true},
+		{"paused", nil, []string{"sat-sun"}, at(time.Saturday, 12, 0), false},
+		{"pause wins over window", []string{"*"}, []string{"fri 12:00-24:00"}, at(time.Friday, 13, 0), false},
+		{"after pause", []string{"*"}, []string{"fri 12:00-24:00"}, at(time.Saturday, 0, 0), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New("UTC", tt.windows, tt.pauses)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := s.Open(tt.at); got != tt.want {
+				t.Errorf("Open(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleTimezone(t *testing.T) {
+	s, err := New("America/New_York", []string{"mon-fri 09:00-17:00"}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// 14:00 UTC is 10:00 in New York during daylight saving time
+	if !s.Open(at(time.Monday, 14, 0)) {
+		t.Error("Open() should use the schedule's time zone")
+	}
+	if s.Open(at(time.Monday, 10, 0)) {
+		t.Error("Open() at 06:00 New York time = true, want false")
+	}
+
+	if _, err := New("Mars/Olympus", nil, nil); err == nil {
+		t.Error("New() should reject an unknown time zone")
+	}
+}
+
+func TestScheduleNextOpen(t *testing.T) {
+	tests := []struct {
+		name    string
+		windows []string
+		pauses  []string
+		at      time.Time
+		want    time.Time
+	}{
+		{"already open", []string{"mon-fri 09:00-17:00"}, nil, at(time.Monday, 10, 0), at(time.Monday, 10, 0)},
+		{"later today", []string{"mon-fri 09:00-17:00"}, nil, at(time.Monday, 7, 30), at(time.Monday, 9, 0)},
+		{"over the weekend", []string{"mon-fri 09:00-17:00"}, nil, at(time.Friday, 18, 0), at(time.Monday, 9, 0).AddDate(0, 0, 7)},
+		{"end of pause", nil, []string{"sat-sun"}, at(time.Saturday, 12, 0), at(time.Monday, 0, 0).AddDate(0, 0, 7)},
+		{"never", []string{"mon 09:00-17:00"}, []string{"mon"}, at(time.Tuesday, 12, 0), time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New("UTC", tt.windows, tt.pauses)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := s.NextOpen(tt.at); !got.Equal(tt.want) {
+				t.Errorf("NextOpen(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNilScheduleIsOpen(t *testing.T) {
+	var s *Schedule
+	now := time.Now()
+	if !s.Open(now) {
+		t.Error("Open() on a nil schedule = false, want true")
+	}
+	if got := s.NextOpen(now); !got.Equal(now) {
+		t.Errorf("NextOpen() on a nil schedule = %v, want %v", got, now)
+	}
+}
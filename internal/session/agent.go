@@ -0,0 +1,169 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+	"github.com/nathanbarrett/dev-swarm-go/internal/state"
+)
+
+// Agent runs a coding agent for a session
+type Agent interface {
+	// Command builds the process that works on a prompt
+	Command(inv Invocation) *exec.Cmd
+
+	// ParseOutput turns a line of stdout into the lines to display and the
+	// usage it reports, if any
+	ParseOutput(line string) ([]string, *state.Usage)
+
+	// Succeeded checks if a session that exited with exitCode did its job
+	Succeeded(exitCode int, output []OutputLine) bool
+}
+
+// Invocation describes the work handed to an agent
+type Invocation struct {
+	Prompt      string
+	PromptFile  string
+	WorkDir     string
+	Repo        string
+	IssueNumber int
+}
+
+// NewAgents builds the built-in claude agent and the configured command agents, by name
+func NewAgents(configs map[string]config.AgentConfig) (map[string]Agent, error) {
+	agents := map[string]Agent{config.AgentClaude: ClaudeAgent{}}
+	for name, cfg := range configs {
+		agent, err := NewCommandAgent(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid agent %s: %w", name, err)
+		}
+		agents[name] = agent
+	}
+	return agents, nil
+}
+
+// ClaudeAgent runs Claude Code in print mode and reads its stream events
+type ClaudeAgent struct{}
+
+// Command builds the claude invocation
+func (ClaudeAgent) Command(inv Invocation) *exec.Cmd {
+	cmd := exec.Command("claude",
+		"--print",
+		"--dangerously-skip-permissions",
+		"--output-format", "stream-json",
+		"--verbose",
+		"--prompt-file", inv.PromptFile,
+	)
+	cmd.Dir = inv.WorkDir
+	return cmd
+}
+
+// ParseOutput condenses claude stream events into readable lines
+func (ClaudeAgent) ParseOutput(line string) ([]string, *state.Usage) {
+	return parseStreamLine(line)
+}
+
+// Succeeded checks that claude exited cleanly
+func (ClaudeAgent) Succeeded(exitCode int, output []OutputLine) bool {
+	return exitCode == 0
+}
+
+// CommandAgent runs any command described by an agent config. Its output is
+// displayed as is and it reports no usage.
+type CommandAgent struct {
+	cfg     config.AgentConfig
+	pattern *regexp.Regexp
+}
+
+// NewCommandAgent creates an agent from its config
+func NewCommandAgent(cfg config.AgentConfig) (*CommandAgent, error) {
+	agent := &CommandAgent{cfg: cfg}
+	if cfg.SuccessPattern != "" {
+		pattern, err := regexp.Compile(cfg.SuccessPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid success pattern: %w", err)
+		}
+		agent.pattern = pattern
+	}
+	return agent, nil
+}
+
+// Command builds the configured command with the placeholders replaced
+func (a *CommandAgent) Command(inv Invocation) *exec.Cmd {
+	replacer := strings.NewReplacer(
+		"{prompt_file}", inv.PromptFile,
+		"{prompt}", inv.Prompt,
+		"{worktree}", inv.WorkDir,
+		"{repo}", inv.Repo,
+		"{issue}", strconv.Itoa(inv.IssueNumber),
+	)
+
+	mode := a.cfg.PromptMode()
+	placeholder := map[string]string{config.PromptFile: "{prompt_file}", config.PromptArg: "{prompt}"}[mode]
+	passed := false
+	args := make([]string, 0, len(a.cfg.Args)+1)
+	for _, arg := range a.cfg.Args {
+		if placeholder != "" && strings.Contains(arg, placeholder) {
+			passed = true
+		}
+		args = append(args, replacer.Replace(arg))
+	}
+	// Without a placeholder the prompt goes last
+	if !passed {
+		switch mode {
+		case config.PromptFile:
+			args = append(args, inv.PromptFile)
+		case config.PromptArg:
+			args = append(args, inv.Prompt)
+		}
+	}
+
+	cmd := exec.Command(a.cfg.Command, args...)
+	cmd.Dir = inv.WorkDir
+	if mode == config.PromptStdin {
+		cmd.Stdin = strings.NewReader(inv.Prompt)
+	}
+	if len(a.cfg.Env) > 0 {
+		cmd.Env = os.Environ()
+		for key, value := range a.cfg.Env {
+			cmd.Env = append(cmd.Env, key+"="+replacer.Replace(value))
+		}
+	}
+	return cmd
+}
+
+// ParseOutput displays lines as they are
+func (a *CommandAgent) ParseOutput(line string) ([]string, *state.Usage) {
+	return []string{line}, nil
+}
+
+// Succeeded checks the exit code against the success exit codes and, with a
+// success pattern, that some buffered output line matches it
+func (a *CommandAgent) Succeeded(exitCode int, output []OutputLine) bool {
+	codes := a.cfg.SuccessExitCodes
+	if len(codes) == 0 {
+		codes = []int{0}
+	}
+	ok := false
+	for _, code := range codes {
+		if code == exitCode {
+			ok = true
+			break
+		}
+	}
+	if !ok || a.pattern == nil {
+		return ok
+	}
+
+	for _, line := range output {
+		if a.pattern.MatchString(line.Text) {
+			return true
+		}
+	}
+	return false
+}
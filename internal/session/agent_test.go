@@ -0,0 +1,140 @@
+package session
+
+import (
+	"io"
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+	"github.com/nathanbarrett/dev-swarm-go/internal/github"
+)
+
+func testInvocation() Invocation {
+	return Invocation{
+		Prompt:      "Fix the bug",
+		PromptFile:  "/work/.dev-swarm-prompt.md",
+		WorkDir:     "/work",
+		Repo:        "owner/repo",
+		IssueNumber: 42,
+	}
+}
+
+func TestCommandAgentArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.AgentConfig
+		want []string
+	}{
+		{"prompt file appended", config.AgentConfig{Command: "agent", Args: []string{"run"}}, []string{"agent", "run", "/work/.dev-swarm-prompt.md"}},
+		{"prompt file placeholder", config.AgentConfig{Command: "agent", Args: []string{"--input={prompt_file}", "--yes"}}, []string{"agent", "--input=/work/.dev-swarm-prompt.md", "--yes"}},
+		{"prompt argument", config.AgentConfig{Command: "agent", Args: []string{"exec"}, Prompt: config.PromptArg}, []string{"agent", "exec", "Fix the bug"}},
+		{"other placeholders", config.AgentConfig{Command: "agent", Args: []string{"{repo}#{issue}", "{prompt}"}, Prompt: config.PromptArg}, []string{"agent", "owner/repo#42", "Fix the bug"}},
+		{"prompt on stdin", config.AgentConfig{Command: "agent", Args: []string{"-"}, Prompt: config.PromptStdin}, []string{"agent", "-"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent, err := NewCommandAgent(tt.cfg)
+			if err != nil {
+				t.Fatalf("NewCommandAgent() error = %v", err)
+			}
+			cmd := agent.Command(testInvocation())
+			if !reflect.DeepEqual(cmd.Args, tt.want) {
+				t.Errorf("Args = %q, want %q", cmd.Args, tt.want)
+			}
+			if cmd.Dir != "/work" {
+				t.Errorf("Dir = %q, want %q", cmd.Dir, "/work")
+			}
+		})
+	}
+}
+
+func TestCommandAgentStdinAndEnv(t *testing.T) {
+	agent, err := NewCommandAgent(config.AgentConfig{
+		Command: "agent",
+		Prompt:  config.PromptStdin,
+		Env:     map[string]string{"AGENT_TASK": "{repo}#{issue}"},
+	})
+	if err != nil {
+		t.Fatalf("NewCommandAgent() error = %v", err)
+	}
+
+	cmd := agent.Command(testInvocation())
+	if cmd.Stdin == nil {
+		t.Fatal("Stdin = nil, want the prompt")
+	}
+	prompt, _ := io.ReadAll(cmd.Stdin)
+	if string(prompt) != "Fix the bug" {
+		t.Errorf("Stdin = %q, want %q", prompt, "Fix the bug")
+	}
+	if last := cmd.Env[len(cmd.Env)-1]; last != "AGENT_TASK=owner/repo#42" {
+		t.Errorf("Env ends with %q, want %q", last, "AGENT_TASK=owner/repo#42")
+	}
+}
+
+func TestCommandAgentSucceeded(t *testing.T) {
+	output := []OutputLine{{Text: "Applied 3 edits"}, {Text: "TASK COMPLETE"}}
+
+	tests := []struct {
+		name     string
+		cfg      config.AgentConfig
+		exitCode int
+		output   []OutputLine
+		want     bool
+	}{
+		{"zero exit", config.AgentConfig{}, 0, nil, true},
+		{"non-zero exit", config.AgentConfig{}, 1, nil, false},
+		{"custom success code", config.AgentConfig{SuccessExitCodes: []int{0, 3}}, 3, nil, true},
+		{"pattern matched", config.AgentConfig{SuccessPattern: "^TASK COMPLETE$"}, 0, output, true},
+		{"pattern missing", config.AgentConfig{SuccessPattern: "^TASK COMPLETE$"}, 0, output[:1], false},
+		{"pattern with failing exit", config.AgentConfig{SuccessPattern: "COMPLETE"}, 1, output, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Command = "agent"
+			agent, err := NewCommandAgent(tt.cfg)
+			if err != nil {
+				t.Fatalf("NewCommandAgent() error = %v", err)
+			}
+			if got := agent.Succeeded(tt.exitCode, tt.output); got != tt.want {
+				t.Errorf("Succeeded(%d) = %v, want %v", tt.exitCode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionUsesAgentSuccessCriteria(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	agent, err := NewCommandAgent(config.AgentConfig{Command: "sh", SuccessPattern: "^done$"})
+	if err != nil {
+		t.Fatalf("NewCommandAgent() error = %v", err)
+	}
+
+	cmd := exec.Command("sh", "-c", "echo working")
+	s := NewSession("owner/repo#1", &github.Issue{Number: 1}, &config.Codebase{Repo: "owner/repo"}, "", "", "", cmd, 10)
+	s.Agent = "sh"
+	s.agent = agent
+
+	statusChan := make(chan StatusEvent, 1)
+	if err := s.Start(make(chan OutputEvent, 10), statusChan); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	select {
+	case event := <-statusChan:
+		if event.Status != StatusFailed {
+			t.Errorf("Status = %v, want %v", event.Status, StatusFailed)
+		}
+		if event.Error == nil {
+			t.Error("Error = nil, want an error explaining the failure")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("session did not exit")
+	}
+}
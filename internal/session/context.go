@@ -7,23 +7,44 @@ import (
 
 	"github.com/nathanbarrett/dev-swarm-go/internal/config"
 	"github.com/nathanbarrett/dev-swarm-go/internal/github"
+	"github.com/nathanbarrett/dev-swarm-go/internal/state"
 )
 
-// AICommentMarkerStart is the marker for AI comment start
+// AICommentMarkerStart is the unsigned marker for AI comment start. It only
+// identifies comments posted before the identity key was created.
 const AICommentMarkerStart = "<!-- dev-swarm:ai -->"
 
 // AICommentMarkerEnd is the marker for AI comment end
 const AICommentMarkerEnd = "<!-- /dev-swarm:ai -->"
 
-// BuildContext creates the prompt context for a Claude session
+// NoteMarker is the unsigned marker for orchestrator notes, which count as
+// neither user nor AI replies. It only identifies notes posted before notes
+// were signed; see Identity.WrapNote.
+const NoteMarker = "<!-- dev-swarm:note -->"
+
+// BuildContext creates the prompt context for a Claude session. The session's
+// comment markers are signed with nonce; sessions are the issue's earlier
+// sessions, which tell AI comments apart from user comments. revisionNotes
+// are the notes of a /revise command the session must address.
 func BuildContext(
 	issue *github.Issue,
 	codebase *config.Codebase,
+	identity *Identity,
+	nonce string,
+	sessions []state.SessionRecord,
 	currentLabel string,
 	aiAction string,
+	revisionNotes string,
 	aiInstructions string,
 ) string {
 	var sb strings.Builder
+	workflow := codebase.GetWorkflow()
+
+	// The orchestrator moves the issue to the working label before spawning
+	pickupLabel := currentLabel
+	if state := workflow.GetByName(pickupLabel); state != nil && state.WorkingLabel != "" {
+		currentLabel = state.WorkingLabel
+	}
 
 	// Header
 	sb.WriteString("# dev-swarm Task\n\n")
@@ -61,9 +82,10 @@ func BuildContext(
 		}
 
 		for _, comment := range issue.Comments[start:] {
-			isAI := IsAIComment(comment.Body)
 			author := comment.Author.Login
-			if isAI {
+			if identity.IsNote(codebase.Repo, issue.Number, author, comment.Body, comment.CreatedAt) {
+				author = fmt.Sprintf("%s (dev-swarm)", author)
+			} else if identity.IsAIComment(codebase.Repo, issue.Number, author, comment.Body, comment.CreatedAt, sessions) {
 				author = fmt.Sprintf("%s (AI)", author)
 			}
 
@@ -76,6 +98,13 @@ func BuildContext(
 	// Current task
 	sb.WriteString("## Your Task\n\n")
 	sb.WriteString(fmt.Sprintf("**Current State**: %s\n\n", currentLabel))
+	if pickupLabel != currentLabel {
+		sb.WriteString(fmt.Sprintf("**Picked Up From**: %s\n\n", pickupLabel))
+	}
+	if outcomes := workflow.AllowedOutcomes(pickupLabel); len(outcomes) > 0 {
+		sb.WriteString(fmt.Sprintf("**Allowed Next Labels**: %s\n\n", strings.Join(outcomes, ", ")))
+		sb.WriteString("dev-swarm checks the label when you finish and rolls back any other outcome.\n\n")
+	}
 
 	if aiAction != "" {
 		sb.WriteString("### Instructions\n\n")
@@ -83,6 +112,13 @@ func BuildContext(
 		sb.WriteString("\n\n")
 	}
 
+	if revisionNotes != "" {
+		sb.WriteString("### Revision Notes\n\n")
+		sb.WriteString("A user sent the issue back with `/revise` and these notes. Address them first:\n\n")
+		sb.WriteString(revisionNotes)
+		sb.WriteString("\n\n")
+	}
+
 	// General instructions
 	sb.WriteString("## Important Guidelines\n\n")
 	sb.WriteString(fmt.Sprintf(`1. **Label Management**: Update labels using gh CLI:
@@ -90,11 +126,11 @@ func BuildContext(
 gh issue edit %d --repo %s --remove-label "current:label" --add-label "new:label"
 `+"```"+`
 
-2. **Comment Markers**: Wrap ALL your comments with these markers:
+2. **Comment Markers**: Wrap ALL your comments on the issue and its PR with these markers:
 `+"```"+`
-<!-- dev-swarm:ai -->
+%s
 Your comment here
-<!-- /dev-swarm:ai -->
+%s
 `+"```"+`
 
 3. **Commit Messages**: Always reference the issue:
@@ -105,14 +141,15 @@ Your comment here
 - Include "Closes #%d" in the PR body
 
 5. **Getting Stuck**: If you cannot proceed:
-- Change label to user:blocked
+- Change label to %s
 - Add a comment explaining what's blocking you
 
 6. **Code Quality**:
 - Follow existing code style
 - Write clear, maintainable code
 - Add tests when specified
-`, issue.Number, codebase.Repo, issue.Number, issue.Number, issue.Number))
+`, issue.Number, codebase.Repo, identity.Marker(codebase.Repo, issue.Number, nonce), AICommentMarkerEnd,
+		issue.Number, issue.Number, issue.Number, blockedLabel(workflow)))
 
 	// Add custom AI instructions if provided
 	if aiInstructions != "" {
@@ -124,19 +161,17 @@ Your comment here
 	return sb.String()
 }
 
-// IsAIComment checks if a comment was made by the AI
-func IsAIComment(body string) bool {
-	return strings.Contains(body, AICommentMarkerStart)
-}
-
-// WrapAIComment wraps a comment with AI markers
-func WrapAIComment(content string) string {
-	return fmt.Sprintf("%s\n%s\n%s", AICommentMarkerStart, content, AICommentMarkerEnd)
+// blockedLabel returns the label the AI should use when it gets stuck
+func blockedLabel(workflow *config.Workflow) string {
+	if label := workflow.RoleLabel(config.RoleBlocked); label != "" {
+		return label
+	}
+	return "a user-owned label"
 }
 
-// StripAIMarkers removes AI markers from a comment
+// StripAIMarkers removes AI markers, signed or not, from a comment
 func StripAIMarkers(body string) string {
-	body = strings.ReplaceAll(body, AICommentMarkerStart, "")
+	body = aiMarkerPattern.ReplaceAllString(body, "")
 	body = strings.ReplaceAll(body, AICommentMarkerEnd, "")
 	return strings.TrimSpace(body)
 }
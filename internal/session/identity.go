@@ -0,0 +1,238 @@
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/state"
+)
+
+// identityKeySize is the length of a generated signing key in bytes
+const identityKeySize = 32
+
+// signatureLength is the number of hex characters of the HMAC kept in markers
+const signatureLength = 32
+
+// nonceSize is the length of a session's marker nonce in bytes
+const nonceSize = 8
+
+// markerClockSkew is how far a comment's time may fall outside the session
+// that signed it, since GitHub and the local clock can disagree
+const markerClockSkew = time.Minute
+
+// aiMarkerPattern matches AI comment start markers, signed or not. Markers
+// given to sessions carry the session's nonce.
+var aiMarkerPattern = regexp.MustCompile(`<!-- dev-swarm:ai(?: n=([0-9a-f]+))?(?: sig=([0-9a-f]+))? -->`)
+
+// noteMarkerPattern matches orchestrator note markers, signed or not
+var noteMarkerPattern = regexp.MustCompile(`<!-- dev-swarm:note(?: sig=([0-9a-f]+))? -->`)
+
+// Identity tells comments posted by dev-swarm and its sessions apart from
+// user comments. With a bot login, the comment author decides; otherwise a
+// comment needs a start marker signed with the installation key.
+type Identity struct {
+	BotLogin   string    // Account dev-swarm and its sessions post as, if separate from users
+	CreatedAt  time.Time // Unsigned markers still count on comments older than this
+	UpgradedAt time.Time // Markers in an older signed format still count on comments older than this
+	key        []byte
+}
+
+// identityFile is the on-disk form of the signing key
+type identityFile struct {
+	Key        string    `json:"key"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpgradedAt time.Time `json:"upgraded_at,omitempty"`
+}
+
+// NewIdentity creates an identity that signs markers with key
+func NewIdentity(key []byte, createdAt time.Time, botLogin string) *Identity {
+	return &Identity{
+		BotLogin:   botLogin,
+		CreatedAt:  createdAt,
+		UpgradedAt: createdAt,
+		key:        key,
+	}
+}
+
+// LoadIdentity reads the signing key at path, generating and saving a new
+// key if the file does not exist
+func LoadIdentity(path, botLogin string) (*Identity, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		var file identityFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse identity key: %w", err)
+		}
+		key, err := hex.DecodeString(file.Key)
+		if err != nil || len(key) == 0 {
+			return nil, fmt.Errorf("identity key in %s is not a hex string", path)
+		}
+		if file.UpgradedAt.IsZero() {
+			// Written before notes were signed; older markers count up to now
+			file.UpgradedAt = time.Now().UTC()
+			if err := writeIdentityFile(path, file); err != nil {
+				return nil, err
+			}
+		}
+		id := NewIdentity(key, file.CreatedAt, botLogin)
+		id.UpgradedAt = file.UpgradedAt
+		return id, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read identity key: %w", err)
+	}
+
+	key := make([]byte, identityKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate identity key: %w", err)
+	}
+	now := time.Now().UTC()
+	file := identityFile{Key: hex.EncodeToString(key), CreatedAt: now, UpgradedAt: now}
+	if err := writeIdentityFile(path, file); err != nil {
+		return nil, err
+	}
+	return NewIdentity(key, file.CreatedAt, botLogin), nil
+}
+
+// writeIdentityFile saves the signing key to path, readable only by the owner
+func writeIdentityFile(path string, file identityFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal identity key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create identity key directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write identity key: %w", err)
+	}
+	return nil
+}
+
+// NewNonce returns a random nonce for the markers of a new session
+func NewNonce() string {
+	nonce := make([]byte, nonceSize)
+	rand.Read(nonce)
+	return hex.EncodeToString(nonce)
+}
+
+// signature returns the marker signature for the comments a session posts on
+// an issue and its PR. An empty nonce gives the per-issue signature of older
+// markers.
+func (id *Identity) signature(repo string, issueNumber int, nonce string) string {
+	mac := hmac.New(sha256.New, id.key)
+	if nonce == "" {
+		fmt.Fprintf(mac, "%s#%d", strings.ToLower(repo), issueNumber)
+	} else {
+		fmt.Fprintf(mac, "%s#%d\x00%s", strings.ToLower(repo), issueNumber, nonce)
+	}
+	return hex.EncodeToString(mac.Sum(nil))[:signatureLength]
+}
+
+// Marker returns the signed start marker for the comments a session with
+// nonce posts on an issue and its PR
+func (id *Identity) Marker(repo string, issueNumber int, nonce string) string {
+	return fmt.Sprintf("<!-- dev-swarm:ai n=%s sig=%s -->", nonce, id.signature(repo, issueNumber, nonce))
+}
+
+// Wrap wraps a comment on an issue or its PR with the signed AI markers of
+// the session with nonce
+func (id *Identity) Wrap(repo string, issueNumber int, nonce, content string) string {
+	return fmt.Sprintf("%s\n%s\n%s", id.Marker(repo, issueNumber, nonce), content, AICommentMarkerEnd)
+}
+
+// IsAIComment checks if a comment on an issue or its PR was posted by
+// dev-swarm or one of its sessions. A marker is only trusted on comments
+// posted while the session it was signed for ran, so a marker copied from
+// an earlier comment does not verify. sessions are the issue's recorded
+// sessions.
+func (id *Identity) IsAIComment(repo string, issueNumber int, author, body string, createdAt time.Time, sessions []state.SessionRecord) bool {
+	if id.BotLogin != "" {
+		return strings.EqualFold(author, id.BotLogin)
+	}
+
+	for _, match := range aiMarkerPattern.FindAllStringSubmatch(body, -1) {
+		nonce, sig := match[1], match[2]
+		switch {
+		case sig == "":
+			// Posted before markers were signed
+			if createdAt.Before(id.CreatedAt) {
+				return true
+			}
+		case !hmac.Equal([]byte(sig), []byte(id.signature(repo, issueNumber, nonce))):
+			// Forged, or signed for another issue or with another key
+		case nonce == "":
+			// Posted before markers were signed per session
+			if createdAt.Before(id.UpgradedAt) {
+				return true
+			}
+		case duringSession(sessions, nonce, createdAt):
+			return true
+		}
+	}
+	return false
+}
+
+// duringSession checks if t falls within the run of the session with nonce
+func duringSession(sessions []state.SessionRecord, nonce string, t time.Time) bool {
+	for _, s := range sessions {
+		if s.Nonce != nonce {
+			continue
+		}
+		if t.Before(s.StartedAt.Add(-markerClockSkew)) {
+			return false
+		}
+		return s.EndedAt == nil || !t.After(s.EndedAt.Add(markerClockSkew))
+	}
+	return false
+}
+
+// noteSignature returns the marker signature for a note on an issue. It
+// covers the note's text, so the marker cannot be moved to another comment.
+func (id *Identity) noteSignature(repo string, issueNumber int, content string) string {
+	mac := hmac.New(sha256.New, id.key)
+	fmt.Fprintf(mac, "note\x00%s#%d\x00%s", strings.ToLower(repo), issueNumber, normalizeBody(content))
+	return hex.EncodeToString(mac.Sum(nil))[:signatureLength]
+}
+
+// WrapNote marks a comment on an issue as an orchestrator note, signed over
+// its text
+func (id *Identity) WrapNote(repo string, issueNumber int, content string) string {
+	return fmt.Sprintf("<!-- dev-swarm:note sig=%s -->\n%s", id.noteSignature(repo, issueNumber, content), content)
+}
+
+// IsNote checks if a comment on an issue is an orchestrator note. A note
+// needs a marker signed over the rest of the comment; with a bot login it
+// must also be posted by the bot.
+func (id *Identity) IsNote(repo string, issueNumber int, author, body string, createdAt time.Time) bool {
+	if id.BotLogin != "" && !strings.EqualFold(author, id.BotLogin) {
+		return false
+	}
+
+	loc := noteMarkerPattern.FindStringSubmatchIndex(body)
+	if loc == nil {
+		return false
+	}
+	if loc[2] < 0 {
+		// Posted before notes were signed
+		return createdAt.Before(id.UpgradedAt)
+	}
+	sig := body[loc[2]:loc[3]]
+	content := body[:loc[0]] + body[loc[1]:]
+	return hmac.Equal([]byte(sig), []byte(id.noteSignature(repo, issueNumber, content)))
+}
+
+// normalizeBody evens out the line endings and surrounding whitespace
+// GitHub may change when it stores a comment
+func normalizeBody(body string) string {
+	return strings.TrimSpace(strings.ReplaceAll(body, "\r\n", "\n"))
+}
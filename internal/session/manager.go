@@ -3,17 +3,25 @@ package session
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"sync"
+	"time"
 
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+	apperrors "github.com/nathanbarrett/dev-swarm-go/internal/errors"
 	"github.com/nathanbarrett/dev-swarm-go/internal/git"
 )
 
+// timeoutCheckInterval is how often running sessions are checked against their limits
+const timeoutCheckInterval = 5 * time.Second
+
 // Manager manages all active sessions
 type Manager struct {
 	sessions          map[string]*Session
 	maxActive         int
+	codebaseLimits    map[string]int // Codebase name -> max running sessions
+	phaseLimits       map[string]int // Phase -> max running sessions
+	agents            map[string]Agent
 	outputBufferLines int
 	worktreesDir      string
 	mu                sync.RWMutex
@@ -30,11 +38,35 @@ func NewManager(maxActive, outputBufferLines int, worktreesDir string) *Manager
 		maxActive:         maxActive,
 		outputBufferLines: outputBufferLines,
 		worktreesDir:      worktreesDir,
+		agents:            map[string]Agent{config.AgentClaude: ClaudeAgent{}},
 		outputChan:        make(chan OutputEvent, 1000),
 		statusChan:        make(chan StatusEvent, 100),
 	}
 }
 
+// SetCodebaseLimits sets the running session caps per codebase name.
+// Codebases without an entry are only bound by the global cap.
+func (m *Manager) SetCodebaseLimits(limits map[string]int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.codebaseLimits = limits
+}
+
+// SetPhaseLimits sets the running session caps per workflow phase.
+// Phases without an entry are only bound by the global cap.
+func (m *Manager) SetPhaseLimits(limits map[string]int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.phaseLimits = limits
+}
+
+// SetAgents sets the agents sessions can be run with, by name
+func (m *Manager) SetAgents(agents map[string]Agent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.agents = agents
+}
+
 // CanSpawn returns true if we can spawn a new session
 func (m *Manager) CanSpawn() bool {
 	m.mu.RLock()
@@ -49,6 +81,63 @@ func (m *Manager) CanSpawn() bool {
 	return activeCount < m.maxActive
 }
 
+// CanSpawnFor returns true if a session for the codebase and phase fits
+// within the global, per-codebase and per-phase caps
+func (m *Manager) CanSpawnFor(codebaseName, phase string) bool {
+	return m.CanSpawnAlongside(codebaseName, phase, nil)
+}
+
+// CanSpawnAlongside is CanSpawnFor with the planned requests counted as
+// running sessions, for deciding several spawns that are not started yet
+func (m *Manager) CanSpawnAlongside(codebaseName, phase string, planned []SpawnRequest) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	active, inCodebase, inPhase := 0, 0, 0
+	count := func(codebase *config.Codebase, sessionPhase string) {
+		active++
+		if codebase != nil && codebase.Name == codebaseName {
+			inCodebase++
+		}
+		if phase != "" && sessionPhase == phase {
+			inPhase++
+		}
+	}
+	for _, s := range m.sessions {
+		if s.Status == StatusRunning {
+			count(s.Codebase, s.Phase)
+		}
+	}
+	for _, req := range planned {
+		count(req.Codebase, req.Phase)
+	}
+
+	if active >= m.maxActive {
+		return false
+	}
+	if limit, ok := m.codebaseLimits[codebaseName]; ok && inCodebase >= limit {
+		return false
+	}
+	if limit, ok := m.phaseLimits[phase]; ok && phase != "" && inPhase >= limit {
+		return false
+	}
+	return true
+}
+
+// ActiveCountByCodebase returns the number of running sessions per codebase name
+func (m *Manager) ActiveCountByCodebase() map[string]int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, s := range m.sessions {
+		if s.Status == StatusRunning && s.Codebase != nil {
+			counts[s.Codebase.Name]++
+		}
+	}
+	return counts
+}
+
 // HasSession returns true if a session exists for the given ID
 func (m *Manager) HasSession(sessionID string) bool {
 	m.mu.RLock()
@@ -72,11 +161,26 @@ func (m *Manager) ActiveCount() int {
 	return count
 }
 
-// SpawnSession creates and starts a new Claude session
+// SpawnSession creates and starts a new agent session
 func (m *Manager) SpawnSession(req SpawnRequest, aiInstructions string) (*Session, error) {
 	sessionID := fmt.Sprintf("%s#%d", req.Codebase.Repo, req.Issue.Number)
 	branchName := git.GetBranchName(req.Issue.Number)
 
+	if !m.CanSpawnFor(req.Codebase.Name, req.Phase) {
+		return nil, apperrors.ErrMaxSessionsReached
+	}
+
+	agentName := req.Agent
+	if agentName == "" {
+		agentName = config.AgentClaude
+	}
+	m.mu.RLock()
+	agent, ok := m.agents[agentName]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown agent %q", agentName)
+	}
+
 	// Create worktree path
 	worktreePath := git.GetWorktreePath(m.worktreesDir, req.Codebase.Name, req.Issue.Number)
 
@@ -88,8 +192,8 @@ func (m *Manager) SpawnSession(req SpawnRequest, aiInstructions string) (*Sessio
 		}
 	}
 
-	// Build context for Claude
-	context := BuildContext(req.Issue, req.Codebase, req.CurrentLabel, req.AIAction, aiInstructions)
+	// Build context for the agent
+	context := BuildContext(req.Issue, req.Codebase, req.Identity, req.MarkerNonce, req.Sessions, req.CurrentLabel, req.AIAction, req.RevisionNotes, aiInstructions)
 
 	// Write context to prompt file
 	promptFile := filepath.Join(worktreePath, ".dev-swarm-prompt.md")
@@ -97,14 +201,18 @@ func (m *Manager) SpawnSession(req SpawnRequest, aiInstructions string) (*Sessio
 		return nil, fmt.Errorf("failed to write prompt file: %w", err)
 	}
 
-	// Create Claude command
-	cmd := exec.Command("claude",
-		"--print",
-		"--dangerously-skip-permissions",
-		"--prompt-file", promptFile,
-	)
-	cmd.Dir = worktreePath
-	cmd.Env = append(os.Environ(),
+	// Create agent command
+	cmd := agent.Command(Invocation{
+		Prompt:      context,
+		PromptFile:  promptFile,
+		WorkDir:     worktreePath,
+		Repo:        req.Codebase.Repo,
+		IssueNumber: req.Issue.Number,
+	})
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env,
 		fmt.Sprintf("DEV_SWARM_ISSUE=%d", req.Issue.Number),
 		fmt.Sprintf("DEV_SWARM_REPO=%s", req.Codebase.Repo),
 	)
@@ -120,6 +228,11 @@ func (m *Manager) SpawnSession(req SpawnRequest, aiInstructions string) (*Sessio
 		cmd,
 		m.outputBufferLines,
 	)
+	session.Phase = req.Phase
+	session.Agent = agentName
+	session.agent = agent
+	session.maxRuntime = req.MaxRuntime
+	session.inactivityTimeout = req.InactivityTimeout
 
 	// Track session
 	m.mu.Lock()
@@ -134,9 +247,34 @@ func (m *Manager) SpawnSession(req SpawnRequest, aiInstructions string) (*Sessio
 		return nil, fmt.Errorf("failed to start session: %w", err)
 	}
 
+	if req.MaxRuntime > 0 || req.InactivityTimeout > 0 {
+		go m.enforceTimeouts(session)
+	}
+
 	return session, nil
 }
 
+// enforceTimeouts kills a session once it exceeds its runtime or inactivity limit
+func (m *Manager) enforceTimeouts(s *Session) {
+	ticker := time.NewTicker(timeoutCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case now := <-ticker.C:
+			if s.IsComplete() {
+				return
+			}
+			if reason := s.checkTimeout(now); reason != "" {
+				s.Timeout(reason)
+				return
+			}
+		}
+	}
+}
+
 // GetSession returns a session by ID
 func (m *Manager) GetSession(sessionID string) *Session {
 	m.mu.RLock()
@@ -180,13 +318,16 @@ func (m *Manager) StopSession(sessionID string) {
 	}
 }
 
-// StopAll stops all running sessions
+// StopAll stops all running sessions. Finished sessions that are still
+// tracked are left alone.
 func (m *Manager) StopAll() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	for _, s := range m.sessions {
-		s.Stop()
+		if s.IsRunning() {
+			s.Stop()
+		}
 	}
 }
 
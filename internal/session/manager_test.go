@@ -1,7 +1,13 @@
 package session
 
 import (
+	"os/exec"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+	"github.com/nathanbarrett/dev-swarm-go/internal/github"
 )
 
 func TestNewManager(t *testing.T) {
@@ -75,6 +81,82 @@ func TestManagerCanSpawn(t *testing.T) {
 	}
 }
 
+func TestManagerCanSpawnFor(t *testing.T) {
+	m := NewManager(4, 100, "/tmp")
+	m.SetCodebaseLimits(map[string]int{"busy": 2})
+	m.SetPhaseLimits(map[string]int{"planning": 1})
+
+	busy := &config.Codebase{Name: "busy"}
+	quiet := &config.Codebase{Name: "quiet"}
+
+	m.mu.Lock()
+	m.sessions["busy#1"] = &Session{Status: StatusRunning, Codebase: busy, Phase: "implementing"}
+	m.sessions["busy#2"] = &Session{Status: StatusRunning, Codebase: busy, Phase: "planning"}
+	m.sessions["busy#3"] = &Session{Status: StatusCompleted, Codebase: busy, Phase: "implementing"}
+	m.mu.Unlock()
+
+	tests := []struct {
+		name     string
+		codebase string
+		phase    string
+		want     bool
+	}{
+		{"codebase at its cap", "busy", "implementing", false},
+		{"other codebase has room", "quiet", "implementing", true},
+		{"phase at its cap", "quiet", "planning", false},
+		{"phase without a cap", "quiet", "ci_fix", true},
+		{"no phase", "quiet", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.CanSpawnFor(tt.codebase, tt.phase); got != tt.want {
+				t.Errorf("CanSpawnFor(%q, %q) = %v, want %v", tt.codebase, tt.phase, got, tt.want)
+			}
+		})
+	}
+
+	// Fill the global cap
+	m.mu.Lock()
+	m.sessions["quiet#1"] = &Session{Status: StatusRunning, Codebase: quiet, Phase: "implementing"}
+	m.sessions["quiet#2"] = &Session{Status: StatusRunning, Codebase: quiet, Phase: "ci_fix"}
+	m.mu.Unlock()
+
+	if m.CanSpawnFor("other", "ci_fix") {
+		t.Error("CanSpawnFor should return false at global capacity")
+	}
+
+	counts := m.ActiveCountByCodebase()
+	if counts["busy"] != 2 || counts["quiet"] != 2 {
+		t.Errorf("ActiveCountByCodebase = %v, want busy=2 quiet=2", counts)
+	}
+}
+
+func TestManagerCanSpawnAlongside(t *testing.T) {
+	m := NewManager(3, 100, "/tmp")
+	m.SetCodebaseLimits(map[string]int{"busy": 1})
+
+	busy := &config.Codebase{Name: "busy"}
+	quiet := &config.Codebase{Name: "quiet"}
+
+	if !m.CanSpawnAlongside("busy", "planning", nil) {
+		t.Fatal("CanSpawnAlongside should allow the first session")
+	}
+
+	planned := []SpawnRequest{{Codebase: busy, Phase: "planning"}}
+	if m.CanSpawnAlongside("busy", "planning", planned) {
+		t.Error("CanSpawnAlongside should count planned sessions against the codebase cap")
+	}
+	if !m.CanSpawnAlongside("quiet", "planning", planned) {
+		t.Error("CanSpawnAlongside should allow other codebases")
+	}
+
+	planned = append(planned, SpawnRequest{Codebase: quiet}, SpawnRequest{Codebase: quiet})
+	if m.CanSpawnAlongside("other", "", planned) {
+		t.Error("CanSpawnAlongside should count planned sessions against the global cap")
+	}
+}
+
 func TestManagerHasSession(t *testing.T) {
 	m := NewManager(5, 100, "/tmp")
 
@@ -244,6 +326,30 @@ func TestManagerCleanupCompleted(t *testing.T) {
 	}
 }
 
+func TestManagerStopAllAfterStop(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	m := NewManager(5, 100, "/tmp")
+	cmd := exec.Command("sh", "-c", "sleep 30")
+	s := NewSession("owner/repo#1", &github.Issue{Number: 1}, &config.Codebase{Repo: "owner/repo"}, "", "", "", cmd, 10)
+	if err := s.Start(make(chan OutputEvent, 10), make(chan StatusEvent, 1)); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// A stopped session stays tracked until the next status check, and
+	// sessions that already finished are never stopped
+	m.mu.Lock()
+	m.sessions[s.ID] = s
+	m.sessions["owner/repo#2"] = &Session{Status: StatusCompleted}
+	m.mu.Unlock()
+
+	s.Stop()
+	s.Stop()
+	m.StopAll()
+}
+
 func TestManagerOutputChan(t *testing.T) {
 	m := NewManager(5, 100, "/tmp")
 
@@ -271,6 +377,7 @@ func TestStatusString(t *testing.T) {
 		{StatusRunning, "running"},
 		{StatusCompleted, "completed"},
 		{StatusFailed, "failed"},
+		{StatusTimedOut, "timed_out"},
 		{Status(99), "unknown"},
 	}
 
@@ -283,3 +390,77 @@ func TestStatusString(t *testing.T) {
 		})
 	}
 }
+
+func TestSessionCheckTimeout(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		status     Status
+		maxRuntime time.Duration
+		idle       time.Duration
+		lastOutput time.Time
+		now        time.Time
+		wantReason string
+	}{
+		{"within limits", StatusRunning, time.Hour, 10 * time.Minute, start.Add(5 * time.Minute), start.Add(10 * time.Minute), ""},
+		{"max runtime exceeded", StatusRunning, time.Hour, 0, start.Add(59 * time.Minute), start.Add(time.Hour), "max runtime"},
+		{"idle exceeded", StatusRunning, time.Hour, 10 * time.Minute, start.Add(5 * time.Minute), start.Add(15 * time.Minute), "no output"},
+		{"limits disabled", StatusRunning, 0, 0, start, start.Add(48 * time.Hour), ""},
+		{"not running", StatusCompleted, time.Minute, time.Minute, start, start.Add(time.Hour), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Session{
+				Status:            tt.status,
+				StartedAt:         start,
+				lastOutputAt:      tt.lastOutput,
+				maxRuntime:        tt.maxRuntime,
+				inactivityTimeout: tt.idle,
+			}
+
+			reason := s.checkTimeout(tt.now)
+			if tt.wantReason == "" && reason != "" {
+				t.Errorf("checkTimeout() = %q, want no timeout", reason)
+			}
+			if tt.wantReason != "" && !strings.Contains(reason, tt.wantReason) {
+				t.Errorf("checkTimeout() = %q, want reason containing %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestSessionTimeoutKillsProcessTree(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	// The child sleep would keep the pipes open if only the shell were killed
+	cmd := exec.Command("sh", "-c", "sleep 30 & sleep 30")
+	s := NewSession("owner/repo#1", &github.Issue{Number: 1}, &config.Codebase{Repo: "owner/repo"}, "", "", "", cmd, 10)
+
+	outputChan := make(chan OutputEvent, 10)
+	statusChan := make(chan StatusEvent, 1)
+	if err := s.Start(outputChan, statusChan); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	s.Timeout("test limit")
+
+	select {
+	case event := <-statusChan:
+		if event.Status != StatusTimedOut {
+			t.Errorf("Status = %v, want %v", event.Status, StatusTimedOut)
+		}
+		if event.TimeoutReason != "test limit" {
+			t.Errorf("TimeoutReason = %q, want %q", event.TimeoutReason, "test limit")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("session did not exit after Timeout()")
+	}
+
+	if !s.IsComplete() {
+		t.Error("IsComplete should be true for timed out session")
+	}
+}
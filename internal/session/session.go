@@ -2,13 +2,16 @@ package session
 
 import (
 	"bufio"
+	"fmt"
 	"io"
 	"os/exec"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/nathanbarrett/dev-swarm-go/internal/config"
 	"github.com/nathanbarrett/dev-swarm-go/internal/github"
+	"github.com/nathanbarrett/dev-swarm-go/internal/state"
 )
 
 // Session represents an active Claude Code session
@@ -19,15 +22,20 @@ type Session struct {
 	WorktreePath string
 	BranchName   string
 	Label        string
+	Phase        string
+	Agent        string // Name of the agent running the session
 
 	// Process
+	agent  Agent
 	cmd    *exec.Cmd
 	stdin  io.WriteCloser
 	stdout io.ReadCloser
 	stderr io.ReadCloser
 
 	// Output
-	output *OutputBuffer
+	output  *OutputBuffer
+	readers sync.WaitGroup
+	usage   *state.Usage
 
 	// Status
 	Status      Status
@@ -36,9 +44,17 @@ type Session struct {
 	ExitCode    *int
 	Error       error
 
+	// Limits, enforced by the manager
+	maxRuntime        time.Duration
+	inactivityTimeout time.Duration
+	lastOutputAt      time.Time
+	timedOut          bool
+	TimeoutReason     string
+
 	// Control
 	mu       sync.RWMutex
 	stopChan chan struct{}
+	stopOnce sync.Once
 }
 
 // NewSession creates a new session
@@ -80,15 +96,25 @@ func (s *Session) Start(outputChan chan<- OutputEvent, statusChan chan<- StatusE
 		return err
 	}
 
-	s.stdin, err = s.cmd.StdinPipe()
-	if err != nil {
-		return err
+	// Agents that read the prompt from stdin bring their own
+	if s.cmd.Stdin == nil {
+		s.stdin, err = s.cmd.StdinPipe()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Run in its own process group so the whole tree can be killed
+	if s.cmd.SysProcAttr == nil {
+		s.cmd.SysProcAttr = &syscall.SysProcAttr{}
 	}
+	s.cmd.SysProcAttr.Setpgid = true
 
 	// Update status
 	s.mu.Lock()
 	s.Status = StatusRunning
 	s.StartedAt = time.Now()
+	s.lastOutputAt = s.StartedAt
 	s.mu.Unlock()
 
 	// Start the process
@@ -101,6 +127,7 @@ func (s *Session) Start(outputChan chan<- OutputEvent, statusChan chan<- StatusE
 	}
 
 	// Start output capture goroutines
+	s.readers.Add(2)
 	go s.captureOutput(s.stdout, "stdout", outputChan)
 	go s.captureOutput(s.stderr, "stderr", outputChan)
 
@@ -110,8 +137,11 @@ func (s *Session) Start(outputChan chan<- OutputEvent, statusChan chan<- StatusE
 	return nil
 }
 
-// captureOutput reads from a stream and sends lines to the output channel
+// captureOutput reads from a stream and sends lines to the output channel.
+// Agent events on stdout are turned into readable lines as they arrive.
 func (s *Session) captureOutput(reader io.Reader, stream string, outputChan chan<- OutputEvent) {
+	defer s.readers.Done()
+
 	scanner := bufio.NewScanner(reader)
 	// Increase buffer size for long lines
 	buf := make([]byte, 0, 64*1024)
@@ -122,18 +152,33 @@ func (s *Session) captureOutput(reader io.Reader, stream string, outputChan chan
 		case <-s.stopChan:
 			return
 		default:
-			line := OutputLine{
-				Timestamp: time.Now(),
-				Text:      scanner.Text(),
-				Stream:    stream,
+			now := time.Now()
+			texts := []string{scanner.Text()}
+			var usage *state.Usage
+			if stream == "stdout" && s.agent != nil {
+				texts, usage = s.agent.ParseOutput(scanner.Text())
 			}
 
-			s.output.Append(line)
-
-			select {
-			case outputChan <- OutputEvent{SessionID: s.ID, Line: line}:
-			default:
-				// Channel full, drop message
+			s.mu.Lock()
+			s.lastOutputAt = now
+			if usage != nil {
+				s.usage = usage
+			}
+			s.mu.Unlock()
+
+			for _, text := range texts {
+				line := OutputLine{
+					Timestamp: now,
+					Text:      text,
+					Stream:    stream,
+				}
+				s.output.Append(line)
+
+				select {
+				case outputChan <- OutputEvent{SessionID: s.ID, Line: line}:
+				default:
+					// Channel full, drop message
+				}
 			}
 		}
 	}
@@ -141,42 +186,109 @@ func (s *Session) captureOutput(reader io.Reader, stream string, outputChan chan
 
 // waitForCompletion waits for the process to exit and updates status
 func (s *Session) waitForCompletion(statusChan chan<- StatusEvent) {
+	// Wait closes the pipes, so read everything first to keep the final usage
+	s.readers.Wait()
 	err := s.cmd.Wait()
 
 	s.mu.Lock()
 	now := time.Now()
 	s.CompletedAt = &now
 
-	if err != nil {
+	exited := err == nil
+	code := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		exited = true
+		code = exitErr.ExitCode()
+	}
+	if exited {
+		s.ExitCode = &code
+	}
+
+	switch {
+	case s.timedOut:
+		s.Status = StatusTimedOut
+		s.Error = fmt.Errorf("session timed out: %s", s.TimeoutReason)
+	case !exited:
+		s.Status = StatusFailed
+		s.Error = err
+	case !s.succeeded(code):
 		s.Status = StatusFailed
 		s.Error = err
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			code := exitErr.ExitCode()
-			s.ExitCode = &code
+		if s.Error == nil {
+			s.Error = fmt.Errorf("agent %s did not report success", s.Agent)
 		}
-	} else {
+	default:
 		s.Status = StatusCompleted
-		code := 0
-		s.ExitCode = &code
 	}
 	s.mu.Unlock()
 
 	select {
 	case statusChan <- StatusEvent{
-		SessionID: s.ID,
-		Status:    s.Status,
-		ExitCode:  s.ExitCode,
-		Error:     s.Error,
+		SessionID:     s.ID,
+		Status:        s.Status,
+		ExitCode:      s.ExitCode,
+		Error:         s.Error,
+		TimeoutReason: s.TimeoutReason,
 	}:
 	default:
 		// Channel full
 	}
 }
 
-// Stop terminates the session
+// succeeded checks if the session did its job after exiting with code
+func (s *Session) succeeded(code int) bool {
+	if s.agent == nil {
+		return code == 0
+	}
+	return s.agent.Succeeded(code, s.output.GetAll())
+}
+
+// Stop terminates the session. Calling it again has no effect.
 func (s *Session) Stop() {
-	close(s.stopChan)
-	if s.cmd != nil && s.cmd.Process != nil {
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+		s.killProcessTree()
+	})
+}
+
+// Timeout terminates a running session that exceeded one of its limits
+func (s *Session) Timeout(reason string) {
+	s.mu.Lock()
+	if s.Status != StatusRunning || s.timedOut {
+		s.mu.Unlock()
+		return
+	}
+	s.timedOut = true
+	s.TimeoutReason = reason
+	s.mu.Unlock()
+
+	s.killProcessTree()
+}
+
+// checkTimeout returns the reason the session exceeded a limit at now, or ""
+func (s *Session) checkTimeout(now time.Time) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.Status != StatusRunning {
+		return ""
+	}
+	if s.maxRuntime > 0 && now.Sub(s.StartedAt) >= s.maxRuntime {
+		return fmt.Sprintf("exceeded max runtime of %s", s.maxRuntime)
+	}
+	if s.inactivityTimeout > 0 && now.Sub(s.lastOutputAt) >= s.inactivityTimeout {
+		return fmt.Sprintf("no output for %s", s.inactivityTimeout)
+	}
+	return ""
+}
+
+// killProcessTree kills the session process and everything it spawned
+func (s *Session) killProcessTree() {
+	if s.cmd == nil || s.cmd.Process == nil {
+		return
+	}
+	// A negative PID signals the process group created in Start
+	if err := syscall.Kill(-s.cmd.Process.Pid, syscall.SIGKILL); err != nil {
 		s.cmd.Process.Kill()
 	}
 }
@@ -195,7 +307,11 @@ func (s *Session) GetRecentOutput(n int) []OutputLine {
 func (s *Session) Duration() time.Duration {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	return s.duration()
+}
 
+// duration computes the session duration. Callers must hold s.mu.
+func (s *Session) duration() time.Duration {
 	if s.CompletedAt != nil {
 		return s.CompletedAt.Sub(s.StartedAt)
 	}
@@ -208,18 +324,21 @@ func (s *Session) Info() SessionInfo {
 	defer s.mu.RUnlock()
 
 	return SessionInfo{
-		ID:           s.ID,
-		IssueNumber:  s.Issue.Number,
-		IssueTitle:   s.Issue.Title,
-		Repo:         s.Codebase.Repo,
-		CodebaseName: s.Codebase.Name,
-		Status:       s.Status,
-		Label:        s.Label,
-		StartedAt:    s.StartedAt,
-		CompletedAt:  s.CompletedAt,
-		Duration:     s.Duration(),
-		ExitCode:     s.ExitCode,
-		Error:        s.Error,
+		ID:            s.ID,
+		IssueNumber:   s.Issue.Number,
+		IssueTitle:    s.Issue.Title,
+		Repo:          s.Codebase.Repo,
+		CodebaseName:  s.Codebase.Name,
+		Status:        s.Status,
+		Label:         s.Label,
+		Agent:         s.Agent,
+		StartedAt:     s.StartedAt,
+		CompletedAt:   s.CompletedAt,
+		Duration:      s.duration(),
+		ExitCode:      s.ExitCode,
+		Error:         s.Error,
+		TimeoutReason: s.TimeoutReason,
+		Usage:         s.usage,
 	}
 }
 
@@ -234,5 +353,5 @@ func (s *Session) IsRunning() bool {
 func (s *Session) IsComplete() bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.Status == StatusCompleted || s.Status == StatusFailed
+	return s.Status == StatusCompleted || s.Status == StatusFailed || s.Status == StatusTimedOut
 }
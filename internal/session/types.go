@@ -5,6 +5,7 @@ import (
 
 	"github.com/nathanbarrett/dev-swarm-go/internal/config"
 	"github.com/nathanbarrett/dev-swarm-go/internal/github"
+	"github.com/nathanbarrett/dev-swarm-go/internal/state"
 )
 
 // Status represents the session status
@@ -15,6 +16,7 @@ const (
 	StatusRunning
 	StatusCompleted
 	StatusFailed
+	StatusTimedOut
 )
 
 func (s Status) String() string {
@@ -27,6 +29,8 @@ func (s Status) String() string {
 		return "completed"
 	case StatusFailed:
 		return "failed"
+	case StatusTimedOut:
+		return "timed_out"
 	default:
 		return "unknown"
 	}
@@ -47,26 +51,30 @@ type OutputEvent struct {
 
 // StatusEvent is sent when session status changes
 type StatusEvent struct {
-	SessionID string
-	Status    Status
-	ExitCode  *int
-	Error     error
+	SessionID     string
+	Status        Status
+	ExitCode      *int
+	Error         error
+	TimeoutReason string
 }
 
 // SessionInfo contains information about a session for display
 type SessionInfo struct {
-	ID           string
-	IssueNumber  int
-	IssueTitle   string
-	Repo         string
-	CodebaseName string
-	Status       Status
-	Label        string
-	StartedAt    time.Time
-	CompletedAt  *time.Time
-	Duration     time.Duration
-	ExitCode     *int
-	Error        error
+	ID            string
+	IssueNumber   int
+	IssueTitle    string
+	Repo          string
+	CodebaseName  string
+	Status        Status
+	Label         string
+	Agent         string
+	StartedAt     time.Time
+	CompletedAt   *time.Time
+	Duration      time.Duration
+	ExitCode      *int
+	Error         error
+	TimeoutReason string
+	Usage         *state.Usage // Reported by the agent when the session ended
 }
 
 // SpawnRequest contains all information needed to spawn a session
@@ -75,4 +83,18 @@ type SpawnRequest struct {
 	Codebase     *config.Codebase
 	CurrentLabel string
 	AIAction     string
+	Phase        string    // Workflow phase, used for per-phase session caps
+	Agent        string    // Agent to run; defaults to the built-in claude agent
+	Identity     *Identity // Signs the comment markers given to the session
+	MarkerNonce  string    // Binds the session's markers to this session; see NewNonce
+
+	// Earlier sessions of the issue, which tell AI comments apart from user comments
+	Sessions []state.SessionRecord
+
+	// Notes from a /revise command the session must address
+	RevisionNotes string
+
+	// Limits enforced by the manager; zero disables the limit
+	MaxRuntime        time.Duration
+	InactivityTimeout time.Duration
 }
@@ -0,0 +1,71 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/state"
+)
+
+// streamEvent is a line of `claude --output-format stream-json` output
+type streamEvent struct {
+	Type    string `json:"type"`
+	Subtype string `json:"subtype"`
+	Message struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+			Name string `json:"name"`
+		} `json:"content"`
+	} `json:"message"`
+	NumTurns     int     `json:"num_turns"`
+	TotalCostUSD float64 `json:"total_cost_usd"`
+	Usage        struct {
+		InputTokens              int64 `json:"input_tokens"`
+		OutputTokens             int64 `json:"output_tokens"`
+		CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+		CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+	} `json:"usage"`
+}
+
+// parseStreamLine turns a line of agent output into the lines to display.
+// The result event at the end of a session also carries its total usage.
+// Lines that are not stream events are displayed as they are.
+func parseStreamLine(line string) ([]string, *state.Usage) {
+	var event streamEvent
+	if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &event) != nil || event.Type == "" {
+		return []string{line}, nil
+	}
+
+	switch event.Type {
+	case "assistant":
+		var lines []string
+		for _, block := range event.Message.Content {
+			switch block.Type {
+			case "text":
+				lines = append(lines, strings.Split(strings.TrimRight(block.Text, "\n"), "\n")...)
+			case "tool_use":
+				lines = append(lines, "→ "+block.Name)
+			}
+		}
+		return lines, nil
+
+	case "result":
+		usage := &state.Usage{
+			InputTokens:      event.Usage.InputTokens,
+			OutputTokens:     event.Usage.OutputTokens,
+			CacheReadTokens:  event.Usage.CacheReadInputTokens,
+			CacheWriteTokens: event.Usage.CacheCreationInputTokens,
+			CostUSD:          event.TotalCostUSD,
+		}
+		summary := fmt.Sprintf("Finished after %d turns, $%.2f", event.NumTurns, event.TotalCostUSD)
+		if event.Subtype != "" && event.Subtype != "success" {
+			summary = fmt.Sprintf("Finished with %s after %d turns, $%.2f", event.Subtype, event.NumTurns, event.TotalCostUSD)
+		}
+		return []string{summary}, usage
+	}
+
+	// System events and tool results are not displayed
+	return nil, nil
+}
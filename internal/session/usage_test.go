@@ -0,0 +1,55 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/state"
+)
+
+func TestParseStreamLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{"plain text", "Cloning into 'repo'...", []string{"Cloning into 'repo'..."}},
+		{"not an event", `{"message": "hi"}`, []string{`{"message": "hi"}`}},
+		{"assistant text", `{"type":"assistant","message":{"content":[{"type":"text","text":"Reading the code\nLooks good\n"}]}}`, []string{"Reading the code", "Looks good"}},
+		{"tool use", `{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Bash","input":{}}]}}`, []string{"→ Bash"}},
+		{"system event", `{"type":"system","subtype":"init","session_id":"abc"}`, nil},
+		{"tool result", `{"type":"user","message":{"content":[{"type":"tool_result","content":"ok"}]}}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, usage := parseStreamLine(tt.line)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseStreamLine() = %q, want %q", got, tt.want)
+			}
+			if usage != nil {
+				t.Errorf("parseStreamLine() usage = %+v, want nil", usage)
+			}
+		})
+	}
+}
+
+func TestParseStreamLineResult(t *testing.T) {
+	line := `{"type":"result","subtype":"success","num_turns":12,"total_cost_usd":0.4213,` +
+		`"usage":{"input_tokens":120,"output_tokens":3400,"cache_creation_input_tokens":9000,"cache_read_input_tokens":54000}}`
+
+	got, usage := parseStreamLine(line)
+	want := &state.Usage{
+		InputTokens:      120,
+		OutputTokens:     3400,
+		CacheReadTokens:  54000,
+		CacheWriteTokens: 9000,
+		CostUSD:          0.4213,
+	}
+	if !reflect.DeepEqual(usage, want) {
+		t.Errorf("parseStreamLine() usage = %+v, want %+v", usage, want)
+	}
+	if len(got) != 1 || got[0] != "Finished after 12 turns, $0.42" {
+		t.Errorf("parseStreamLine() = %q, want the session summary", got)
+	}
+}
@@ -0,0 +1,326 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Store persists orchestrator state to a JSON file
+type Store struct {
+	path string
+	data *State
+	mu   sync.Mutex
+
+	// saveMu serializes Save so snapshots reach disk in the order they
+	// were taken
+	saveMu sync.Mutex
+}
+
+// NewStore creates a store backed by the file at path
+func NewStore(path string) *Store {
+	return &Store{
+		path: path,
+		data: newState(),
+	}
+}
+
+func newState() *State {
+	return &State{
+		Version:   CurrentVersion,
+		Codebases: make(map[string]*CodebaseRecord),
+		Spend:     make(map[string]Usage),
+	}
+}
+
+// Path returns the path of the state file
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Load reads the state file. A missing file leaves the store empty.
+// Sessions that were still running when the file was written are marked
+// as interrupted, since their processes did not survive the restart.
+func (s *Store) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.data = newState()
+			return nil
+		}
+		return fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	loaded := newState()
+	if err := json.Unmarshal(data, loaded); err != nil {
+		return fmt.Errorf("failed to parse state file: %w", err)
+	}
+	if loaded.Version > CurrentVersion {
+		return fmt.Errorf("state file version %d is newer than supported version %d", loaded.Version, CurrentVersion)
+	}
+	if loaded.Codebases == nil {
+		loaded.Codebases = make(map[string]*CodebaseRecord)
+	}
+	if loaded.Spend == nil {
+		loaded.Spend = make(map[string]Usage)
+	}
+
+	now := time.Now()
+	for _, cb := range loaded.Codebases {
+		if cb.Issues == nil {
+			cb.Issues = make(map[int]*IssueRecord)
+		}
+		for _, issue := range cb.Issues {
+			for i := range issue.Sessions {
+				if issue.Sessions[i].Outcome == OutcomeRunning {
+					issue.Sessions[i].Outcome = OutcomeInterrupted
+					issue.Sessions[i].EndedAt = &now
+				}
+			}
+		}
+	}
+
+	loaded.Version = CurrentVersion
+	s.data = loaded
+	return nil
+}
+
+// Save atomically writes the state file. The data is written to a
+// temporary file in the same directory, synced, and renamed over the
+// previous file so a crash never leaves a partially written state.
+// Concurrent saves are serialized so an older snapshot never replaces a
+// newer one.
+func (s *Store) Save() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.mu.Lock()
+	s.data.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(s.data, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp state file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write temp state file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to sync temp state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to close temp state file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+
+	// Sync the directory so the rename itself is durable
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+
+	return nil
+}
+
+// GetIssue returns a copy of the record for an issue
+func (s *Store) GetIssue(codebase string, number int) (IssueRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cb, ok := s.data.Codebases[codebase]
+	if !ok {
+		return IssueRecord{}, false
+	}
+	rec, ok := cb.Issues[number]
+	if !ok {
+		return IssueRecord{}, false
+	}
+	return copyRecord(rec), true
+}
+
+// GetIssues returns copies of all issue records for a codebase
+func (s *Store) GetIssues(codebase string) []IssueRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cb, ok := s.data.Codebases[codebase]
+	if !ok {
+		return nil
+	}
+
+	result := make([]IssueRecord, 0, len(cb.Issues))
+	for _, rec := range cb.Issues {
+		result = append(result, copyRecord(rec))
+	}
+	return result
+}
+
+// UpdateIssue applies fn to the record for an issue, creating it if needed
+func (s *Store) UpdateIssue(codebase string, number int, fn func(rec *IssueRecord)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fn(s.issue(codebase, number))
+}
+
+// RemoveIssue deletes the record for an issue
+func (s *Store) RemoveIssue(codebase string, number int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cb, ok := s.data.Codebases[codebase]; ok {
+		delete(cb.Issues, number)
+	}
+}
+
+// RecordSessionStart appends a running session to an issue's history. nonce
+// is the one the session's comment markers are signed with.
+func (s *Store) RecordSessionStart(codebase string, number int, id, label, nonce string, startedAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.issue(codebase, number)
+	rec.Sessions = append(rec.Sessions, SessionRecord{
+		ID:        id,
+		Label:     label,
+		StartedAt: startedAt,
+		Outcome:   OutcomeRunning,
+		Nonce:     nonce,
+	})
+	if len(rec.Sessions) > maxSessionHistory {
+		rec.Sessions = rec.Sessions[len(rec.Sessions)-maxSessionHistory:]
+	}
+}
+
+// RecordSessionEnd marks the most recent running session for an issue as
+// finished and adds the usage it reported, if any, to the spend totals
+func (s *Store) RecordSessionEnd(codebase string, number int, outcome Outcome, exitCode *int, sessErr error, usage *Usage, endedAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.issue(codebase, number)
+	for i := len(rec.Sessions) - 1; i >= 0; i-- {
+		if rec.Sessions[i].Outcome != OutcomeRunning {
+			continue
+		}
+		rec.Sessions[i].Outcome = outcome
+		rec.Sessions[i].EndedAt = &endedAt
+		if exitCode != nil {
+			code := *exitCode
+			rec.Sessions[i].ExitCode = &code
+		}
+		if sessErr != nil {
+			rec.Sessions[i].Error = sessErr.Error()
+		}
+		if usage != nil {
+			u := *usage
+			u.Sessions = 1
+			rec.Sessions[i].Usage = &u
+			s.addSpend(codebase, rec, u, endedAt)
+		}
+		return
+	}
+}
+
+// Prune removes issue records that have not been checked since the cutoff
+func (s *Store) Prune(cutoff time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for name, cb := range s.data.Codebases {
+		for num, rec := range cb.Issues {
+			if rec.LastChecked.Before(cutoff) {
+				delete(cb.Issues, num)
+				removed++
+			}
+		}
+		if len(cb.Issues) == 0 && cb.Usage.IsZero() && cb.MergedPRsSeen.IsZero() {
+			delete(s.data.Codebases, name)
+		}
+	}
+	return removed
+}
+
+// MergedPRsSeen returns the time PRs merged before were cleaned up after,
+// or the zero time if none were
+func (s *Store) MergedPRsSeen(codebase string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cb, ok := s.data.Codebases[codebase]; ok {
+		return cb.MergedPRsSeen
+	}
+	return time.Time{}
+}
+
+// SetMergedPRsSeen records that PRs merged before t were cleaned up after
+func (s *Store) SetMergedPRsSeen(codebase string, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.codebase(codebase).MergedPRsSeen = t
+}
+
+// codebase returns the live record for a codebase, creating it if needed.
+// Callers must hold s.mu.
+func (s *Store) codebase(name string) *CodebaseRecord {
+	cb, ok := s.data.Codebases[name]
+	if !ok {
+		cb = &CodebaseRecord{Issues: make(map[int]*IssueRecord)}
+		s.data.Codebases[name] = cb
+	}
+	return cb
+}
+
+// issue returns the live record for an issue, creating it if needed.
+// Callers must hold s.mu.
+func (s *Store) issue(codebase string, number int) *IssueRecord {
+	cb := s.codebase(codebase)
+	rec, ok := cb.Issues[number]
+	if !ok {
+		rec = &IssueRecord{Number: number}
+		cb.Issues[number] = rec
+	}
+	return rec
+}
+
+func copyRecord(rec *IssueRecord) IssueRecord {
+	c := *rec
+	c.Sessions = make([]SessionRecord, len(rec.Sessions))
+	copy(c.Sessions, rec.Sessions)
+	for i := range c.Sessions {
+		if u := c.Sessions[i].Usage; u != nil {
+			usage := *u
+			c.Sessions[i].Usage = &usage
+		}
+	}
+	c.WaitingOn = append([]string(nil), rec.WaitingOn...)
+	return c
+}
@@ -0,0 +1,318 @@
+package state
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoadMissingFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "state-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	s := NewStore(filepath.Join(tmpDir, "state.json"))
+	if err := s.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if _, ok := s.GetIssue("example", 1); ok {
+		t.Error("GetIssue should return false for empty store")
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "state-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "nested", "state.json")
+	s := NewStore(path)
+
+	commentAt := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	s.UpdateIssue("example", 42, func(rec *IssueRecord) {
+		rec.Title = "Add dark mode"
+		rec.Label = "user:plan-review"
+		rec.LastProcessedCommentAt = commentAt
+	})
+	s.RecordSessionStart("example", 42, "owner/repo#42", "user:ready-to-plan", "0a1b2c3d", commentAt)
+	code := 0
+	s.RecordSessionEnd("example", 42, OutcomeCompleted, &code, nil, nil, commentAt.Add(time.Minute))
+
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// No temp files should be left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("state directory has %d entries, want 1", len(entries))
+	}
+
+	loaded := NewStore(path)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	rec, ok := loaded.GetIssue("example", 42)
+	if !ok {
+		t.Fatal("GetIssue should find saved issue")
+	}
+	if rec.Title != "Add dark mode" {
+		t.Errorf("Title = %q, want %q", rec.Title, "Add dark mode")
+	}
+	if rec.Label != "user:plan-review" {
+		t.Errorf("Label = %q, want %q", rec.Label, "user:plan-review")
+	}
+	if !rec.LastProcessedCommentAt.Equal(commentAt) {
+		t.Errorf("LastProcessedCommentAt = %v, want %v", rec.LastProcessedCommentAt, commentAt)
+	}
+	if len(rec.Sessions) != 1 {
+		t.Fatalf("len(Sessions) = %d, want 1", len(rec.Sessions))
+	}
+	if rec.Sessions[0].Outcome != OutcomeCompleted {
+		t.Errorf("Outcome = %q, want %q", rec.Sessions[0].Outcome, OutcomeCompleted)
+	}
+	if rec.Sessions[0].ExitCode == nil || *rec.Sessions[0].ExitCode != 0 {
+		t.Error("ExitCode should be 0")
+	}
+	if rec.Sessions[0].Nonce != "0a1b2c3d" {
+		t.Errorf("Nonce = %q, want %q", rec.Sessions[0].Nonce, "0a1b2c3d")
+	}
+}
+
+func TestLoadMarksRunningSessionsInterrupted(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "state-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "state.json")
+	s := NewStore(path)
+	s.RecordSessionStart("example", 7, "owner/repo#7", "user:ready-to-implement", "", time.Now())
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewStore(path)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	rec, _ := loaded.GetIssue("example", 7)
+	last := rec.LastSession()
+	if last == nil {
+		t.Fatal("LastSession should not be nil")
+	}
+	if last.Outcome != OutcomeInterrupted {
+		t.Errorf("Outcome = %q, want %q", last.Outcome, OutcomeInterrupted)
+	}
+	if last.EndedAt == nil {
+		t.Error("EndedAt should be set for interrupted session")
+	}
+}
+
+func TestLoadCorruptFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "state-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewStore(path)
+	if err := s.Load(); err == nil {
+		t.Error("Load() should error for corrupt file")
+	}
+}
+
+func TestRecordSessionEndUpdatesLatestRunning(t *testing.T) {
+	s := NewStore("/tmp/unused-state.json")
+	start := time.Now()
+
+	s.RecordSessionStart("example", 1, "a", "user:ready-to-plan", "", start)
+	s.RecordSessionEnd("example", 1, OutcomeFailed, nil, errors.New("boom"), nil, start)
+	s.RecordSessionStart("example", 1, "b", "user:ready-to-plan", "", start)
+	s.RecordSessionEnd("example", 1, OutcomeCompleted, nil, nil, nil, start)
+
+	rec, _ := s.GetIssue("example", 1)
+	if len(rec.Sessions) != 2 {
+		t.Fatalf("len(Sessions) = %d, want 2", len(rec.Sessions))
+	}
+	if rec.Sessions[0].Outcome != OutcomeFailed || rec.Sessions[0].Error != "boom" {
+		t.Errorf("first session = %+v, want failed with error", rec.Sessions[0])
+	}
+	if rec.Sessions[1].Outcome != OutcomeCompleted {
+		t.Errorf("second session outcome = %q, want %q", rec.Sessions[1].Outcome, OutcomeCompleted)
+	}
+}
+
+func TestSessionHistoryIsCapped(t *testing.T) {
+	s := NewStore("/tmp/unused-state.json")
+	for i := 0; i < maxSessionHistory+5; i++ {
+		s.RecordSessionStart("example", 1, "id", "label", "", time.Now())
+	}
+
+	rec, _ := s.GetIssue("example", 1)
+	if len(rec.Sessions) != maxSessionHistory {
+		t.Errorf("len(Sessions) = %d, want %d", len(rec.Sessions), maxSessionHistory)
+	}
+}
+
+func TestGetIssueReturnsCopy(t *testing.T) {
+	s := NewStore("/tmp/unused-state.json")
+	s.RecordSessionStart("example", 1, "id", "label", "", time.Now())
+
+	rec, _ := s.GetIssue("example", 1)
+	rec.Label = "changed"
+	rec.Sessions[0].ID = "changed"
+
+	again, _ := s.GetIssue("example", 1)
+	if again.Label == "changed" || again.Sessions[0].ID == "changed" {
+		t.Error("GetIssue should return a copy that does not alias store data")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	s := NewStore("/tmp/unused-state.json")
+	now := time.Now()
+
+	s.UpdateIssue("example", 1, func(rec *IssueRecord) { rec.LastChecked = now.Add(-48 * time.Hour) })
+	s.UpdateIssue("example", 2, func(rec *IssueRecord) { rec.LastChecked = now })
+
+	removed := s.Prune(now.Add(-24 * time.Hour))
+	if removed != 1 {
+		t.Errorf("Prune() = %d, want 1", removed)
+	}
+	if _, ok := s.GetIssue("example", 1); ok {
+		t.Error("stale issue should be pruned")
+	}
+	if _, ok := s.GetIssue("example", 2); !ok {
+		t.Error("recent issue should be kept")
+	}
+}
+
+func TestRecordSessionEndUsage(t *testing.T) {
+	s := NewStore("/tmp/unused-state.json")
+	day := time.Date(2024, 6, 14, 12, 0, 0, 0, time.Local)
+
+	s.RecordSessionStart("example", 1, "a", "user:ready-to-plan", "", day)
+	s.RecordSessionEnd("example", 1, OutcomeCompleted, nil, nil, &Usage{InputTokens: 100, OutputTokens: 50, CostUSD: 1.5}, day)
+	s.RecordSessionStart("example", 2, "b", "user:ready-to-plan", "", day)
+	s.RecordSessionEnd("example", 2, OutcomeCompleted, nil, nil, &Usage{CostUSD: 0.5}, day.AddDate(0, 0, 1))
+	s.RecordSessionStart("example", 2, "c", "user:ready-to-plan", "", day)
+	s.RecordSessionEnd("example", 2, OutcomeFailed, nil, errors.New("boom"), nil, day.AddDate(0, 0, 1))
+
+	rec, _ := s.GetIssue("example", 1)
+	if rec.Sessions[0].Usage == nil || rec.Sessions[0].Usage.CostUSD != 1.5 {
+		t.Errorf("session usage = %+v, want cost 1.5", rec.Sessions[0].Usage)
+	}
+	if rec.Usage.Tokens() != 150 || rec.Usage.Sessions != 1 {
+		t.Errorf("issue usage = %+v, want 150 tokens from 1 session", rec.Usage)
+	}
+
+	if got := s.DayUsage(day).CostUSD; got != 1.5 {
+		t.Errorf("DayUsage() cost = %v, want 1.5", got)
+	}
+	if got := s.MonthUsage(day).CostUSD; got != 2 {
+		t.Errorf("MonthUsage() cost = %v, want 2", got)
+	}
+	if got := s.MonthUsage(day.AddDate(0, 1, 0)).CostUSD; got != 0 {
+		t.Errorf("MonthUsage() of the next month cost = %v, want 0", got)
+	}
+	if got := s.CodebaseUsage("example"); got.CostUSD != 2 || got.Sessions != 2 {
+		t.Errorf("CodebaseUsage() = %+v, want cost 2 from 2 sessions", got)
+	}
+}
+
+func TestPruneKeepsCodebaseUsage(t *testing.T) {
+	s := NewStore("/tmp/unused-state.json")
+	now := time.Now()
+
+	s.RecordSessionStart("example", 1, "a", "user:ready-to-plan", "", now)
+	s.RecordSessionEnd("example", 1, OutcomeCompleted, nil, nil, &Usage{CostUSD: 3}, now)
+	s.UpdateIssue("example", 1, func(rec *IssueRecord) { rec.LastChecked = now.Add(-48 * time.Hour) })
+
+	s.Prune(now.Add(-24 * time.Hour))
+	if got := s.CodebaseUsage("example").CostUSD; got != 3 {
+		t.Errorf("CodebaseUsage() cost after prune = %v, want 3", got)
+	}
+}
+
+func TestMergedPRsSeen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s := NewStore(path)
+	seen := time.Date(2024, 6, 14, 12, 0, 0, 0, time.UTC)
+
+	if got := s.MergedPRsSeen("example"); !got.IsZero() {
+		t.Errorf("MergedPRsSeen() = %v, want zero before any cleanup", got)
+	}
+	s.SetMergedPRsSeen("example", seen)
+	s.Prune(time.Now())
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded := NewStore(path)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := loaded.MergedPRsSeen("example"); !got.Equal(seen) {
+		t.Errorf("MergedPRsSeen() = %v, want %v kept through prune and reload", got, seen)
+	}
+}
+
+func TestConcurrentSaves(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s := NewStore(path)
+
+	const writers = 8
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				s.UpdateIssue("example", n, func(rec *IssueRecord) {
+					rec.Title = "issue"
+				})
+				if err := s.Save(); err != nil {
+					t.Errorf("Save() error = %v", err)
+				}
+			}
+		}(i + 1)
+	}
+	wg.Wait()
+
+	// The last save to finish saw every update, so nothing may be missing
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("state directory has %d entries, want 1", len(entries))
+	}
+
+	loaded := NewStore(path)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := len(loaded.GetIssues("example")); got != writers {
+		t.Errorf("len(GetIssues()) = %d, want %d", got, writers)
+	}
+}
@@ -0,0 +1,78 @@
+package state
+
+import (
+	"time"
+)
+
+// CurrentVersion is the on-disk format version of the state file
+const CurrentVersion = 1
+
+// maxSessionHistory is the number of session records kept per issue
+const maxSessionHistory = 20
+
+// State is the persisted orchestrator state
+type State struct {
+	Version   int                        `json:"version"`
+	UpdatedAt time.Time                  `json:"updated_at"`
+	Codebases map[string]*CodebaseRecord `json:"codebases"`
+	Spend     map[string]Usage           `json:"spend,omitempty"` // Usage per local day, keyed "2006-01-02"
+}
+
+// CodebaseRecord holds the persisted state of a single codebase
+type CodebaseRecord struct {
+	Issues        map[int]*IssueRecord `json:"issues"`
+	Usage         Usage                `json:"usage"`                     // Usage of all sessions, including those of pruned issues
+	MergedPRsSeen time.Time            `json:"merged_prs_seen,omitempty"` // PRs merged before this were cleaned up after
+}
+
+// IssueRecord holds the persisted history of a single issue
+type IssueRecord struct {
+	Number                 int             `json:"number"`
+	Title                  string          `json:"title"`
+	Label                  string          `json:"label"`
+	LabelChangedAt         time.Time       `json:"label_changed_at"`
+	LastChecked            time.Time       `json:"last_checked"`
+	LastProcessedCommentAt time.Time       `json:"last_processed_comment_at"`
+	Attempts               int             `json:"attempts,omitempty"`
+	NextAttemptAt          time.Time       `json:"next_attempt_at,omitempty"`
+	Sessions               []SessionRecord `json:"sessions,omitempty"`
+	WaitingOn              []string        `json:"waiting_on,omitempty"`          // Open dependencies last noted on the issue
+	HandledReviewID        string          `json:"handled_review_id,omitempty"`   // Last PR review the orchestrator acted on
+	CommandsCheckedAt      time.Time       `json:"commands_checked_at,omitempty"` // Comments up to here were scanned for slash commands
+	Usage                  Usage           `json:"usage"`                         // Usage of all sessions run for the issue
+	CostReported           bool            `json:"cost_reported,omitempty"`       // The cost was noted on the issue when it was done
+	RevisionNotes          string          `json:"revision_notes,omitempty"`      // From /revise, for sessions until one succeeds
+}
+
+// SessionRecord describes a single session run for an issue
+type SessionRecord struct {
+	ID        string     `json:"id"`
+	Label     string     `json:"label"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	Outcome   Outcome    `json:"outcome"`
+	ExitCode  *int       `json:"exit_code,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	StartHead string     `json:"start_head,omitempty"` // Branch head when the session started
+	Usage     *Usage     `json:"usage,omitempty"`      // Reported by the agent when the session ended
+	Nonce     string     `json:"nonce,omitempty"`      // Signs the comment markers given to the session
+}
+
+// Outcome represents how a session ended
+type Outcome string
+
+const (
+	OutcomeRunning     Outcome = "running"
+	OutcomeCompleted   Outcome = "completed"
+	OutcomeFailed      Outcome = "failed"
+	OutcomeTimedOut    Outcome = "timed_out"
+	OutcomeInterrupted Outcome = "interrupted"
+)
+
+// LastSession returns the most recent session record, if any
+func (r *IssueRecord) LastSession() *SessionRecord {
+	if len(r.Sessions) == 0 {
+		return nil
+	}
+	return &r.Sessions[len(r.Sessions)-1]
+}
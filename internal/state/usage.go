@@ -0,0 +1,96 @@
+package state
+
+import (
+	"strings"
+	"time"
+)
+
+// dayFormat is the key format for daily spend totals, in local time
+const dayFormat = "2006-01-02"
+
+// spendHistoryDays is the number of days of spend totals kept
+const spendHistoryDays = 62
+
+// Usage holds the tokens used and money spent by one or more sessions
+type Usage struct {
+	InputTokens      int64   `json:"input_tokens,omitempty"`
+	OutputTokens     int64   `json:"output_tokens,omitempty"`
+	CacheReadTokens  int64   `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int64   `json:"cache_write_tokens,omitempty"`
+	CostUSD          float64 `json:"cost_usd,omitempty"`
+	Sessions         int     `json:"sessions,omitempty"` // Sessions that reported usage
+}
+
+// Add adds the usage of other to u
+func (u *Usage) Add(other Usage) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.CacheReadTokens += other.CacheReadTokens
+	u.CacheWriteTokens += other.CacheWriteTokens
+	u.CostUSD += other.CostUSD
+	u.Sessions += other.Sessions
+}
+
+// Tokens returns the total number of tokens used
+func (u Usage) Tokens() int64 {
+	return u.InputTokens + u.OutputTokens + u.CacheReadTokens + u.CacheWriteTokens
+}
+
+// IsZero checks if no usage was recorded
+func (u Usage) IsZero() bool {
+	return u == Usage{}
+}
+
+// DayUsage returns the usage of sessions that ended on the local day of t
+func (s *Store) DayUsage(t time.Time) Usage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.data.Spend[t.Local().Format(dayFormat)]
+}
+
+// MonthUsage returns the usage of sessions that ended in the local month of t
+func (s *Store) MonthUsage(t time.Time) Usage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefix := t.Local().Format("2006-01-")
+	var total Usage
+	for day, usage := range s.data.Spend {
+		if strings.HasPrefix(day, prefix) {
+			total.Add(usage)
+		}
+	}
+	return total
+}
+
+// CodebaseUsage returns the usage of all sessions recorded for a codebase
+func (s *Store) CodebaseUsage(codebase string) Usage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cb, ok := s.data.Codebases[codebase]; ok {
+		return cb.Usage
+	}
+	return Usage{}
+}
+
+// addSpend adds usage to the issue, its codebase and the day it ended on, and
+// drops daily totals that are too old to count toward any budget.
+// Callers must hold s.mu.
+func (s *Store) addSpend(codebase string, rec *IssueRecord, usage Usage, endedAt time.Time) {
+	rec.Usage.Add(usage)
+	s.data.Codebases[codebase].Usage.Add(usage)
+
+	day := endedAt.Local().Format(dayFormat)
+	total := s.data.Spend[day]
+	total.Add(usage)
+	s.data.Spend[day] = total
+
+	oldest := endedAt.Local().AddDate(0, 0, -spendHistoryDays).Format(dayFormat)
+	for key := range s.data.Spend {
+		if key < oldest {
+			delete(s.data.Spend, key)
+		}
+	}
+}
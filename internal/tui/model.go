@@ -18,17 +18,17 @@ type Model struct {
 	sessions  map[string]session.SessionInfo
 
 	// UI state
-	selectedIdx  int
-	scrollOffset int
-	outputScroll int
+	selectedIdx    int
+	scrollOffset   int
+	outputScroll   int
 	focusedSession string // Session ID of focused session for full-screen output
 
 	// Status
-	lastPoll  time.Time
-	nextPoll  time.Time
-	isPaused  bool
-	showHelp  bool
-	showLogs  bool
+	lastPoll time.Time
+	nextPoll time.Time
+	isPaused bool
+	showHelp bool
+	showLogs bool
 
 	// Dimensions
 	width  int
@@ -37,6 +37,13 @@ type Model struct {
 	// Key bindings
 	keys KeyMap
 
+	// Side effects skipped in dry-run mode, oldest first
+	dryRunActions []orchestrator.DryRunAction
+
+	// Latest notification about issues waiting for the user, shown until toastUntil
+	toast      string
+	toastUntil time.Time
+
 	// Update channel
 	updateChan <-chan orchestrator.StateUpdate
 
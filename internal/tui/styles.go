@@ -1,6 +1,9 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+)
 
 var (
 	// Colors
@@ -121,15 +124,17 @@ var (
 	TreeLine   = lipgloss.NewStyle().Foreground(ColorGray).Render("│")
 )
 
-// GetLabelStyle returns the appropriate style for a label
-func GetLabelStyle(label string) lipgloss.Style {
+// GetLabelStyle returns the appropriate style for a workflow state
+func GetLabelStyle(state *config.LabelConfig) lipgloss.Style {
 	switch {
-	case label == "ai:planning" || label == "ai:implementing":
-		return LabelActiveStyle
-	case label == "ai:ci-failed" || label == "user:blocked":
+	case state == nil:
+		return LabelWaitingStyle
+	case state.Role == config.RoleBlocked || state.Role == config.RoleCIFailed:
 		return LabelFailedStyle
-	case label == "ai:done":
+	case state.Terminal:
 		return LabelDoneStyle
+	case state.Owner == string(config.OwnerAI) && state.AIPickup == string(config.PickupNever):
+		return LabelActiveStyle
 	default:
 		return LabelWaitingStyle
 	}
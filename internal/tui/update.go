@@ -1,8 +1,13 @@
 package tui
 
 import (
+	"fmt"
+	"os"
+	"time"
+
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nathanbarrett/dev-swarm-go/internal/notify"
 	"github.com/nathanbarrett/dev-swarm-go/internal/orchestrator"
 	"github.com/nathanbarrett/dev-swarm-go/internal/session"
 )
@@ -130,6 +135,9 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// maxDryRunActions is the number of skipped side effects kept for display
+const maxDryRunActions = 200
+
 // handleStateUpdate handles orchestrator state updates
 func (m Model) handleStateUpdate(msg StateUpdateMsg) (tea.Model, tea.Cmd) {
 	switch msg.Type {
@@ -156,11 +164,37 @@ func (m Model) handleStateUpdate(msg StateUpdateMsg) (tea.Model, tea.Cmd) {
 		// Output is captured in the session, just trigger a repaint
 		return m, m.listenForUpdates()
 
+	case orchestrator.UpdateDryRun:
+		if action, ok := msg.Data.(orchestrator.DryRunAction); ok {
+			m.dryRunActions = append(m.dryRunActions, action)
+			if len(m.dryRunActions) > maxDryRunActions {
+				m.dryRunActions = m.dryRunActions[len(m.dryRunActions)-maxDryRunActions:]
+			}
+		}
+		return m, m.listenForUpdates()
+
+	case orchestrator.UpdateNotification:
+		if batch, ok := msg.Data.([]notify.Notification); ok && len(batch) > 0 {
+			m.toast = notify.Summary(batch)
+			m.toastUntil = time.Now().Add(toastDuration)
+			return m, tea.Batch(ringBell, m.listenForUpdates())
+		}
+		return m, m.listenForUpdates()
+
 	default:
 		return m, m.listenForUpdates()
 	}
 }
 
+// toastDuration is how long a notification stays in the status bar
+const toastDuration = 15 * time.Second
+
+// ringBell rings the terminal bell
+func ringBell() tea.Msg {
+	fmt.Fprint(os.Stdout, "\a")
+	return nil
+}
+
 // listenForUpdates returns a command that listens for orchestrator updates
 func (m Model) listenForUpdates() tea.Cmd {
 	return func() tea.Msg {
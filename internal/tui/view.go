@@ -137,9 +137,22 @@ func (m Model) renderCodebaseHeader(cb CodebaseInfo, _ int) string {
 	health := ""
 	if !cb.IsHealthy {
 		health = ErrorStyle.Render(" [error]")
+	} else if cb.RateLimited {
+		health = lipgloss.NewStyle().Foreground(ColorYellow).Render(" [rate limited]")
 	}
 
-	return fmt.Sprintf("  %s%s%s", name, repo, health)
+	sched := ""
+	if cb.Scheduled && !cb.ScheduleOpen {
+		text := " [outside schedule]"
+		if !cb.NextWindow.IsZero() {
+			text = fmt.Sprintf(" [outside schedule, opens %s]", formatWindow(cb.NextWindow))
+		}
+		sched = lipgloss.NewStyle().Foreground(ColorYellow).Render(text)
+	} else if cb.Scheduled {
+		sched = lipgloss.NewStyle().Foreground(ColorGray).Render(" [in schedule]")
+	}
+
+	return fmt.Sprintf("  %s%s%s%s", name, repo, health, sched)
 }
 
 // renderIssueLine renders an issue line
@@ -159,21 +172,37 @@ func (m Model) renderIssueLine(issue IssueInfo, isLast bool) string {
 	titleStyled := IssueStyle.Render(title)
 
 	// Label and status
-	labelStyle := GetLabelStyle(issue.Label)
+	labelStyle := GetLabelStyle(issue.State)
 	label := labelStyle.Render(issue.Label)
 
 	// Status indicator
 	isActive := issue.HasSession && issue.Status == session.StatusRunning
-	isFailed := issue.HasSession && issue.Status == session.StatusFailed
-	isDone := strings.Contains(issue.Label, "done")
+	isFailed := issue.HasSession && (issue.Status == session.StatusFailed || issue.Status == session.StatusTimedOut)
+	isDone := issue.State != nil && issue.State.Terminal
 	icon := GetStatusIcon(isActive, isFailed, isDone)
+	if !isActive && !isFailed && issue.QueuePosition > 0 {
+		icon = IconQueued
+	}
 
-	// Duration if active
+	// Duration if active, queue position if waiting for a slot, open
+	// dependencies, or the remaining backoff after a failed session
 	duration := ""
 	if isActive {
 		duration = lipgloss.NewStyle().Foreground(ColorGray).Render(
 			fmt.Sprintf(" (%s)", formatDuration(issue.Duration)),
 		)
+	} else if issue.QueuePosition > 0 {
+		duration = lipgloss.NewStyle().Foreground(ColorCyan).Render(
+			fmt.Sprintf(" (queued #%d)", issue.QueuePosition),
+		)
+	} else if len(issue.WaitingOn) > 0 {
+		duration = lipgloss.NewStyle().Foreground(ColorGray).Render(
+			fmt.Sprintf(" (waiting on %s)", strings.Join(issue.WaitingOn, ", ")),
+		)
+	} else if remaining := time.Until(issue.RetryAt); remaining > 0 {
+		duration = lipgloss.NewStyle().Foreground(ColorGray).Render(
+			fmt.Sprintf(" (retry %d in %s)", issue.Attempts+1, formatDuration(remaining)),
+		)
 	}
 
 	return fmt.Sprintf("  %s %s %s  %s %s%s",
@@ -184,6 +213,9 @@ func (m Model) renderIssueLine(issue IssueInfo, isLast bool) string {
 func (m Model) renderOutputPanel(height int) string {
 	// Get selected session's output
 	sess := m.GetSelectedSession()
+	if sess == nil && m.Stats().DryRun {
+		return m.renderDryRunPanel(height)
+	}
 	if sess == nil {
 		return lipgloss.NewStyle().
 			Width(m.width).
@@ -228,6 +260,33 @@ func (m Model) renderOutputPanel(height int) string {
 	return lipgloss.JoinVertical(lipgloss.Left, title, content)
 }
 
+// renderDryRunPanel lists the side effects a dry run skipped, newest last
+func (m Model) renderDryRunPanel(height int) string {
+	title := OutputTitleStyle.Render(" Dry run: actions that would have been taken ")
+
+	actions := m.dryRunActions
+	if len(actions) > height-2 && height > 2 {
+		actions = actions[len(actions)-(height-2):]
+	}
+
+	var lines []string
+	for _, action := range actions {
+		timestamp := OutputTimestampStyle.Render(action.Time.Format("[15:04:05]"))
+		text := OutputLineStyle.Render(fmt.Sprintf("%s#%d: %s", action.Codebase, action.IssueNum, action.Action))
+		lines = append(lines, fmt.Sprintf("%s %s", timestamp, text))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, OutputLineStyle.Render("Nothing to do yet"))
+	}
+
+	content := strings.Join(lines, "\n")
+	if len(lines) < height-2 {
+		content += strings.Repeat("\n", height-2-len(lines))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, title, content)
+}
+
 // renderStatusBar renders the status bar
 func (m Model) renderStatusBar() string {
 	stats := m.Stats()
@@ -238,17 +297,46 @@ func (m Model) renderStatusBar() string {
 		activeText = StatusBarActiveStyle.Render(fmt.Sprintf("Active: %d", stats.ActiveSessions))
 	}
 
+	// Queue depth
+	queuedText := StatusBarValueStyle.Render(fmt.Sprintf("Queued: %d", stats.QueuedSessions))
+
 	// Total issues
 	totalText := StatusBarValueStyle.Render(fmt.Sprintf("Issues: %d", stats.TotalIssues))
 
-	// Poll countdown
-	pollText := StatusBarValueStyle.Render("Poll: --")
+	// Spend against the budgets, highlighted once new sessions are held back
+	spend := fmt.Sprintf("Spend: %s today, %s this month",
+		formatSpend(stats.SpentToday, stats.DailyBudget), formatSpend(stats.SpentThisMonth, stats.MonthlyBudget))
+	spendText := StatusBarValueStyle.Render(spend)
+	if stats.BudgetExhausted {
+		spendText = StatusBarActiveStyle.Render(spend + " [BUDGET SPENT]")
+	}
+
+	// Poll countdown; with webhooks the poll only reconciles
+	pollLabel := "Poll"
+	if stats.WebhookAddr != "" {
+		pollLabel = "Reconcile"
+	}
+	pollText := StatusBarValueStyle.Render(pollLabel + ": --")
 	if !stats.LastPoll.IsZero() {
 		remaining := time.Until(stats.NextPoll)
 		if remaining < 0 {
 			remaining = 0
 		}
-		pollText = StatusBarValueStyle.Render(fmt.Sprintf("Poll: %ds", int(remaining.Seconds())))
+		pollText = StatusBarValueStyle.Render(fmt.Sprintf("%s: %ds", pollLabel, int(remaining.Seconds())))
+	}
+
+	// GitHub quota, highlighted while it slows polling or holds requests back
+	quotaText := ""
+	if rl := stats.RateLimit; rl.Known() {
+		quota := fmt.Sprintf("API: %d/%d", rl.Remaining, rl.Limit)
+		switch {
+		case time.Now().Before(rl.HeldUntil):
+			quotaText = "  │  " + StatusBarActiveStyle.Render(fmt.Sprintf("%s [RATE LIMITED until %s]", quota, rl.HeldUntil.Format("15:04")))
+		case stats.PollSlowed:
+			quotaText = "  │  " + StatusBarActiveStyle.Render(quota+" [SLOWED]")
+		default:
+			quotaText = "  │  " + StatusBarValueStyle.Render(quota)
+		}
 	}
 
 	// Paused indicator
@@ -256,12 +344,18 @@ func (m Model) renderStatusBar() string {
 	if stats.IsPaused {
 		pausedText = StatusBarActiveStyle.Render(" [PAUSED]")
 	}
+	if stats.DryRun {
+		pausedText += StatusBarActiveStyle.Render(" [DRY RUN]")
+	}
 
-	// Help hint
+	// Help hint, replaced by a recent notification
 	helpText := HelpStyle.Render("↑↓ Nav  r Refresh  p Pause  q Quit  ? Help")
+	if m.toast != "" && time.Now().Before(m.toastUntil) {
+		helpText = StatusBarActiveStyle.Render("🔔 " + m.toast)
+	}
 
 	// Combine
-	left := fmt.Sprintf("  %s  │  %s  │  %s%s", activeText, totalText, pollText, pausedText)
+	left := fmt.Sprintf("  %s  │  %s  │  %s  │  %s  │  %s%s%s", activeText, queuedText, totalText, spendText, pollText, quotaText, pausedText)
 	right := helpText
 
 	gap := m.width - lipgloss.Width(left) - lipgloss.Width(right) - 4
@@ -274,6 +368,14 @@ func (m Model) renderStatusBar() string {
 	)
 }
 
+// formatSpend formats an amount in US dollars, with its budget if there is one
+func formatSpend(spent, budget float64) string {
+	if budget > 0 {
+		return fmt.Sprintf("$%.2f/$%.2f", spent, budget)
+	}
+	return fmt.Sprintf("$%.2f", spent)
+}
+
 // renderFocusedOutput renders full-screen output for a session
 func (m Model) renderFocusedOutput() string {
 	sess := m.GetFocusedSession()
@@ -373,3 +475,13 @@ func formatDuration(d time.Duration) string {
 	mins := int(d.Minutes()) % 60
 	return fmt.Sprintf("%dh %dm", hours, mins)
 }
+
+// formatWindow formats when a schedule window opens, in local time
+func formatWindow(t time.Time) string {
+	t = t.Local()
+	now := time.Now()
+	if t.YearDay() == now.YearDay() && t.Year() == now.Year() {
+		return t.Format("15:04")
+	}
+	return t.Format("Mon 15:04")
+}
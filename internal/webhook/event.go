@@ -0,0 +1,116 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Event is the part of a GitHub webhook delivery the orchestrator acts on
+type Event struct {
+	Name       string // Value of the X-GitHub-Event header
+	DeliveryID string // Value of the X-GitHub-Delivery header
+	Action     string
+	Repo       string // "owner/repo" format
+	Number     int    // Issue or PR number; 0 if the event has none
+	IsPR       bool   // Number refers to a pull request
+	HeadBranch string // PR or check suite head branch, if known
+	Sender     string
+}
+
+// Supported event names
+const (
+	EventPing              = "ping"
+	EventIssues            = "issues"
+	EventIssueComment      = "issue_comment"
+	EventPullRequest       = "pull_request"
+	EventPullRequestReview = "pull_request_review"
+	EventCheckSuite        = "check_suite"
+)
+
+// payload holds the fields read from every supported event
+type payload struct {
+	Action     string `json:"action"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Sender struct {
+		Login string `json:"login"`
+	} `json:"sender"`
+	Issue *struct {
+		Number      int             `json:"number"`
+		PullRequest json.RawMessage `json:"pull_request"`
+	} `json:"issue"`
+	PullRequest *struct {
+		Number int `json:"number"`
+		Head   struct {
+			Ref string `json:"ref"`
+		} `json:"head"`
+	} `json:"pull_request"`
+	CheckSuite *struct {
+		HeadBranch   string `json:"head_branch"`
+		PullRequests []struct {
+			Number int `json:"number"`
+		} `json:"pull_requests"`
+	} `json:"check_suite"`
+}
+
+// IsSupported checks if the orchestrator handles an event name
+func IsSupported(name string) bool {
+	switch name {
+	case EventIssues, EventIssueComment, EventPullRequest, EventPullRequestReview, EventCheckSuite:
+		return true
+	}
+	return false
+}
+
+// ParseEvent decodes a delivery of a supported event
+func ParseEvent(name string, body []byte) (*Event, error) {
+	if !IsSupported(name) {
+		return nil, fmt.Errorf("unsupported event %q", name)
+	}
+
+	var p payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse %s payload: %w", name, err)
+	}
+	if p.Repository.FullName == "" {
+		return nil, fmt.Errorf("%s payload has no repository", name)
+	}
+
+	event := &Event{
+		Name:   name,
+		Action: p.Action,
+		Repo:   p.Repository.FullName,
+		Sender: p.Sender.Login,
+	}
+
+	switch name {
+	case EventIssues, EventIssueComment:
+		if p.Issue == nil {
+			return nil, fmt.Errorf("%s payload has no issue", name)
+		}
+		event.Number = p.Issue.Number
+		// Comments on PRs arrive as issue comments with a pull_request link
+		event.IsPR = len(p.Issue.PullRequest) > 0 && string(p.Issue.PullRequest) != "null"
+
+	case EventPullRequest, EventPullRequestReview:
+		if p.PullRequest == nil {
+			return nil, fmt.Errorf("%s payload has no pull request", name)
+		}
+		event.Number = p.PullRequest.Number
+		event.IsPR = true
+		event.HeadBranch = p.PullRequest.Head.Ref
+
+	case EventCheckSuite:
+		if p.CheckSuite == nil {
+			return nil, fmt.Errorf("%s payload has no check suite", name)
+		}
+		event.IsPR = true
+		event.HeadBranch = p.CheckSuite.HeadBranch
+		if len(p.CheckSuite.PullRequests) > 0 {
+			event.Number = p.CheckSuite.PullRequests[0].Number
+		}
+	}
+
+	return event, nil
+}
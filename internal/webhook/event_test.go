@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readPayload(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseEvent(t *testing.T) {
+	tests := []struct {
+		event   string
+		payload string
+		want    Event
+	}{
+		{
+			event:   EventIssues,
+			payload: "issues_labeled.json",
+			want:    Event{Name: EventIssues, Action: "labeled", Repo: "octo-org/widgets", Number: 42, Sender: "octocat"},
+		},
+		{
+			event:   EventIssueComment,
+			payload: "issue_comment_pr.json",
+			want:    Event{Name: EventIssueComment, Action: "created", Repo: "octo-org/widgets", Number: 57, IsPR: true, Sender: "octocat"},
+		},
+		{
+			event:   EventPullRequest,
+			payload: "pull_request_closed.json",
+			want:    Event{Name: EventPullRequest, Action: "closed", Repo: "octo-org/widgets", Number: 57, IsPR: true, HeadBranch: "claude/issue-42", Sender: "octocat"},
+		},
+		{
+			event:   EventPullRequestReview,
+			payload: "pull_request_review.json",
+			want:    Event{Name: EventPullRequestReview, Action: "submitted", Repo: "octo-org/widgets", Number: 57, IsPR: true, HeadBranch: "claude/issue-42", Sender: "octocat"},
+		},
+		{
+			event:   EventCheckSuite,
+			payload: "check_suite_completed.json",
+			want:    Event{Name: EventCheckSuite, Action: "completed", Repo: "octo-org/widgets", Number: 57, IsPR: true, HeadBranch: "claude/issue-42", Sender: "octocat"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.payload, func(t *testing.T) {
+			got, err := ParseEvent(tt.event, readPayload(t, tt.payload))
+			if err != nil {
+				t.Fatalf("ParseEvent() error = %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("ParseEvent() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEventErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+		body  string
+	}{
+		{"unsupported event", "star", `{"repository":{"full_name":"o/r"}}`},
+		{"invalid json", EventIssues, `{`},
+		{"missing repository", EventIssues, `{"issue":{"number":1}}`},
+		{"missing issue", EventIssues, `{"repository":{"full_name":"o/r"}}`},
+		{"missing pull request", EventPullRequest, `{"repository":{"full_name":"o/r"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseEvent(tt.event, []byte(tt.body)); err == nil {
+				t.Error("ParseEvent() error = nil, want error")
+			}
+		})
+	}
+}
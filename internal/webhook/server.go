@@ -0,0 +1,157 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+)
+
+// maxPayloadBytes is the largest delivery GitHub sends
+const maxPayloadBytes = 25 << 20
+
+// Handler receives verified events. It is called on the HTTP goroutine and
+// must not block.
+type Handler func(event *Event)
+
+// Server receives GitHub webhook deliveries
+type Server struct {
+	listen  string
+	path    string
+	secret  []byte
+	handler Handler
+	logger  *log.Logger
+
+	srv *http.Server
+	ln  net.Listener
+}
+
+// NewServer creates a webhook server from the webhook settings
+func NewServer(cfg config.WebhookConfig, handler Handler, logger *log.Logger) *Server {
+	s := &Server{
+		listen:  cfg.Listen,
+		path:    cfg.Path,
+		secret:  []byte(cfg.Secret),
+		handler: handler,
+		logger:  logger,
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(cfg.Path, s)
+	s.srv = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Start binds the listen address and serves deliveries in the background
+func (s *Server) Start() error {
+	ln, err := net.Listen("tcp", s.listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.listen, err)
+	}
+	s.ln = ln
+
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.log("Webhook server stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Addr returns the address the server is listening on
+func (s *Server) Addr() string {
+	if s.ln == nil {
+		return s.listen
+	}
+	return s.ln.Addr().String()
+}
+
+// Shutdown stops the server, waiting for in-flight deliveries
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
+
+// ServeHTTP verifies and dispatches a single delivery
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadBytes))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if !VerifySignature(s.secret, body, r.Header.Get("X-Hub-Signature-256")) {
+		s.log("Rejected webhook delivery %s: invalid signature", r.Header.Get("X-GitHub-Delivery"))
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	name := r.Header.Get("X-GitHub-Event")
+	if name == EventPing {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if !IsSupported(name) {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	event, err := ParseEvent(name, body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	event.DeliveryID = r.Header.Get("X-GitHub-Delivery")
+
+	s.handler(event)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// VerifySignature checks an X-Hub-Signature-256 header against the payload
+func VerifySignature(secret, body []byte, header string) bool {
+	if len(secret) == 0 {
+		return false
+	}
+
+	hexSig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	sig, err := hex.DecodeString(hexSig)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(sig, Sign(secret, body))
+}
+
+// Sign computes the HMAC-SHA256 of a payload, as GitHub does for deliveries
+func Sign(secret, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+func (s *Server) log(format string, args ...interface{}) {
+	if s.logger != nil {
+		s.logger.Printf(format, args...)
+	}
+}
@@ -0,0 +1,144 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nathanbarrett/dev-swarm-go/internal/config"
+)
+
+const testSecret = "It's a Secret to Everybody"
+
+func signature(secret string, body []byte) string {
+	return "sha256=" + hex.EncodeToString(Sign([]byte(secret), body))
+}
+
+func TestVerifySignature(t *testing.T) {
+	// Example from the GitHub webhook documentation
+	body := []byte("Hello, World!")
+	header := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		want   bool
+	}{
+		{"valid", testSecret, header, true},
+		{"wrong secret", "other", header, false},
+		{"empty secret", "", header, false},
+		{"missing prefix", testSecret, header[len("sha256="):], false},
+		{"not hex", testSecret, "sha256=zz", false},
+		{"empty header", testSecret, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifySignature([]byte(tt.secret), body, tt.header); got != tt.want {
+				t.Errorf("VerifySignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerDeliveries(t *testing.T) {
+	var received []*Event
+	server := NewServer(config.WebhookConfig{
+		Listen: "127.0.0.1:0",
+		Path:   "/webhook",
+		Secret: testSecret,
+	}, func(event *Event) {
+		received = append(received, event)
+	}, nil)
+
+	ts := httptest.NewServer(server.srv.Handler)
+	defer ts.Close()
+
+	labeled := readPayload(t, "issues_labeled.json")
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		event      string
+		body       []byte
+		signature  string
+		wantStatus int
+		wantEvents int
+	}{
+		{"valid delivery", http.MethodPost, "/webhook", EventIssues, labeled, signature(testSecret, labeled), http.StatusAccepted, 1},
+		{"bad signature", http.MethodPost, "/webhook", EventIssues, labeled, signature("wrong", labeled), http.StatusUnauthorized, 0},
+		{"missing signature", http.MethodPost, "/webhook", EventIssues, labeled, "", http.StatusUnauthorized, 0},
+		{"ping", http.MethodPost, "/webhook", EventPing, []byte(`{}`), signature(testSecret, []byte(`{}`)), http.StatusOK, 0},
+		{"unsupported event", http.MethodPost, "/webhook", "star", []byte(`{}`), signature(testSecret, []byte(`{}`)), http.StatusAccepted, 0},
+		{"malformed payload", http.MethodPost, "/webhook", EventIssues, []byte(`{`), signature(testSecret, []byte(`{`)), http.StatusBadRequest, 0},
+		{"wrong method", http.MethodGet, "/webhook", EventIssues, nil, "", http.StatusMethodNotAllowed, 0},
+		{"wrong path", http.MethodPost, "/other", EventIssues, labeled, signature(testSecret, labeled), http.StatusNotFound, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = nil
+
+			req, err := http.NewRequest(tt.method, ts.URL+tt.path, bytes.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("X-GitHub-Event", tt.event)
+			req.Header.Set("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958")
+			if tt.signature != "" {
+				req.Header.Set("X-Hub-Signature-256", tt.signature)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if len(received) != tt.wantEvents {
+				t.Fatalf("received %d events, want %d", len(received), tt.wantEvents)
+			}
+			if tt.wantEvents > 0 && received[0].DeliveryID != "72d3162e-cc78-11e3-81ab-4c9367dc0958" {
+				t.Errorf("DeliveryID = %q, want the X-GitHub-Delivery header", received[0].DeliveryID)
+			}
+		})
+	}
+}
+
+func TestServerStart(t *testing.T) {
+	server := NewServer(config.WebhookConfig{
+		Listen: "127.0.0.1:0",
+		Path:   "/webhook",
+		Secret: testSecret,
+	}, func(event *Event) {}, nil)
+
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer server.Shutdown(context.Background())
+
+	body := readPayload(t, "check_suite_completed.json")
+	req, err := http.NewRequest(http.MethodPost, "http://"+server.Addr()+"/webhook", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-GitHub-Event", EventCheckSuite)
+	req.Header.Set("X-Hub-Signature-256", signature(testSecret, body))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+}